@@ -0,0 +1,290 @@
+// Package app provides the registry of named editor actions and the
+// command palette that runs them.
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/keymap"
+)
+
+// Action is a named editor command. Actions are listed in the command
+// palette, can be recorded in macros and run by name from plugins.
+type Action struct {
+	Name        string // dotted name, e.g. "split.horizontal"
+	Description string
+	Mode        string   // key map mode: "" for normal mode, or keymap.Prompt
+	Keys        []string // default keys; the first is handled by the mode
+
+	// Run performs the action. When nil, the action is what its first
+	// key does in its mode.
+	Run func(m *Model) tea.Cmd
+}
+
+// actions holds the registered actions by name.
+var actions = make(map[string]*Action)
+
+// RegisterAction adds an action to the registry. Plugins use it to add
+// their own commands to the palette.
+func RegisterAction(action Action) error {
+	switch {
+	case action.Name == "":
+		return fmt.Errorf("action has no name")
+	case actions[action.Name] != nil:
+		return fmt.Errorf("action %s already registered", action.Name)
+	case action.Run == nil && len(action.Keys) == 0:
+		return fmt.Errorf("action %s has neither a key nor a function", action.Name)
+	}
+	actions[action.Name] = &action
+	return nil
+}
+
+// LookupAction returns the registered action with the given name.
+func LookupAction(name string) (Action, bool) {
+	action, ok := actions[name]
+	if !ok {
+		return Action{}, false
+	}
+	return *action, true
+}
+
+// Actions returns all registered actions sorted by name.
+func Actions() []Action {
+	list := make([]Action, 0, len(actions))
+	for _, action := range actions {
+		list = append(list, *action)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// RunAction runs the named action as if its default key was pressed in
+// its mode, whatever the key bindings. A macro being recorded records the
+// action by name.
+func (m *Model) RunAction(name string) (tea.Cmd, error) {
+	action, ok := actions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown action: %s", name)
+	}
+	if m.macro != nil {
+		m.macro.RecordAction(name)
+	}
+	return m.runAction(action), nil
+}
+
+// runAction performs an action.
+func (m *Model) runAction(action *Action) tea.Cmd {
+	if action.Run != nil {
+		return action.Run(m)
+	}
+	msg := keymap.Msg(action.Keys[0])
+	if action.Mode == keymap.Prompt {
+		_, cmd := m.dispatchKey(msg)
+		return cmd
+	}
+	_, cmd := m.handleNormalKey(msg)
+	return cmd
+}
+
+// openCommandPalette lists every normal mode action with its current
+// keys for running one by name. A macro being recorded gets the chosen
+// action instead of the key opening the palette.
+func (m *Model) openCommandPalette() {
+	if m.macro != nil {
+		m.macro.DropLastKey()
+	}
+	var items []pickerItem
+	for _, action := range Actions() {
+		if action.Mode != "" {
+			continue
+		}
+		name := action.Name
+		detail := name
+		if keys := m.getKeymap().Keys(keymap.Normal, name); len(keys) > 0 {
+			detail += "  " + strings.Join(keys, ", ")
+		}
+		items = append(items, pickerItem{
+			label:  action.Description,
+			detail: detail,
+			run: func() tea.Cmd {
+				cmd, err := m.RunAction(name)
+				if err != nil {
+					m.SetStatusMessage(err.Error())
+				}
+				return cmd
+			},
+		})
+	}
+	m.openPicker("Command Palette", items)
+	m.picker.fuzzy = true
+}
+
+func init() {
+	for _, action := range builtinActions {
+		if err := RegisterAction(action); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// builtinActions are the editor's own actions.
+var builtinActions = []Action{
+	// File operations
+	{Name: "file.exit", Description: "Exit, asking to save a modified buffer", Keys: []string{"ctrl+x"}},
+	{Name: "file.save", Description: "Save the file", Keys: []string{"ctrl+o"}},
+	{Name: "file.save-as", Description: "Save the file under another name", Run: func(m *Model) tea.Cmd {
+		m.mode = ModeSaveAs
+		m.inputBuffer = m.filepath
+		m.inputPrompt = "File Name to Write: "
+		return nil
+	}},
+	{Name: "file.open", Description: "Open a file", Keys: []string{"ctrl+r"}},
+	{Name: "file.find", Description: "Find a project file by fuzzy matching its path", Keys: []string{"alt+o"}},
+	{Name: "file.tree", Description: "Show, focus or hide the file tree", Keys: []string{"f9"}},
+	{Name: "help.about", Description: "Show the version and main keys", Keys: []string{"ctrl+g"}},
+	{Name: "palette.open", Description: "Open the command palette", Keys: []string{"f1"}},
+	{Name: "view.refresh", Description: "Refresh the screen", Keys: []string{"ctrl+l"}},
+
+	// Search and replace
+	{Name: "search.find", Description: "Search", Keys: []string{"ctrl+w"}},
+	{Name: "search.next", Description: "Find the next match", Keys: []string{"alt+w", "f3"}},
+	{Name: "search.previous", Description: "Find the previous match", Keys: []string{"ctrl+q", "shift+f3"}},
+	{Name: "search.replace", Description: "Replace", Keys: []string{"ctrl+\\", "alt+r"}},
+	{Name: "search.project", Description: "Search all files of the project", Keys: []string{"f6"}},
+	{Name: "search.project-results", Description: "Show the last project search results", Keys: []string{"alt+f6"}},
+	{Name: "search.project-replace", Description: "Replace in all files of the project", Keys: []string{"shift+f6", "f18"}},
+
+	// Editing
+	{Name: "edit.newline", Description: "Insert a line break", Keys: []string{"enter", "ctrl+m"}},
+	{Name: "edit.tab", Description: "Expand a snippet or insert indentation", Keys: []string{"tab", "ctrl+i"}},
+	{Name: "edit.backspace", Description: "Delete the character before the cursor", Keys: []string{"backspace", "ctrl+h"}},
+	{Name: "edit.delete", Description: "Delete the character under the cursor", Keys: []string{"delete", "ctrl+d"}},
+	{Name: "edit.delete-word-left", Description: "Delete the word left of the cursor", Keys: []string{"alt+backspace"}},
+	{Name: "edit.delete-word-right", Description: "Delete the word right of the cursor", Keys: []string{"ctrl+delete"}},
+	{Name: "edit.cut", Description: "Cut the line or selection", Keys: []string{"ctrl+k"}},
+	{Name: "edit.copy", Description: "Copy the line or selection", Keys: []string{"alt+6"}},
+	{Name: "edit.paste", Description: "Paste", Keys: []string{"ctrl+u"}},
+	{Name: "edit.cut-to-end", Description: "Cut from the cursor to the end of the line", Keys: []string{"alt+t"}},
+	{Name: "edit.undo", Description: "Undo", Keys: []string{"alt+u"}},
+	{Name: "edit.redo", Description: "Redo", Keys: []string{"alt+e"}},
+	{Name: "edit.comment", Description: "Comment or uncomment the line or selection", Keys: []string{"alt+3"}},
+	{Name: "edit.indent", Description: "Indent the line or selection", Keys: []string{"alt+}"}},
+	{Name: "edit.unindent", Description: "Unindent the line or selection", Keys: []string{"alt+{", "shift+tab"}},
+	{Name: "edit.move-up", Description: "Move the line or selection up", Keys: []string{"alt+up"}},
+	{Name: "edit.move-down", Description: "Move the line or selection down", Keys: []string{"alt+down"}},
+	{Name: "edit.duplicate", Description: "Duplicate the line or selection", Keys: []string{"alt+d"}},
+	{Name: "edit.join", Description: "Join the next line or the selected lines", Keys: []string{"alt+j"}},
+	{Name: "edit.complete", Description: "Complete the word at the cursor", Keys: []string{"ctrl+]"}},
+	{Name: "edit.transform", Description: "Transform the selection or buffer", Keys: []string{"alt+s"}},
+	{Name: "edit.execute", Description: "Pipe the selection or buffer through a command", Keys: []string{"alt+!"}},
+	{Name: "edit.overwrite", Description: "Toggle insert/overwrite mode", Keys: []string{"insert"}},
+
+	// Cursor movement and selection
+	{Name: "move.up", Description: "Move to the previous line", Keys: []string{"up", "ctrl+p"}},
+	{Name: "move.down", Description: "Move to the next line", Keys: []string{"down", "ctrl+n"}},
+	{Name: "move.left", Description: "Move back one character", Keys: []string{"left", "ctrl+b"}},
+	{Name: "move.right", Description: "Move forward one character", Keys: []string{"right", "ctrl+f"}},
+	{Name: "move.word-left", Description: "Move back one word", Keys: []string{"ctrl+left", "alt+space"}},
+	{Name: "move.word-right", Description: "Move forward one word", Keys: []string{"ctrl+right", "ctrl+space"}},
+	{Name: "move.line-start", Description: "Move to the beginning of the line", Keys: []string{"home", "ctrl+a"}},
+	{Name: "move.line-end", Description: "Move to the end of the line", Keys: []string{"end", "ctrl+e"}},
+	{Name: "move.page-up", Description: "Scroll up one page", Keys: []string{"pgup", "ctrl+y"}},
+	{Name: "move.page-down", Description: "Scroll down one page", Keys: []string{"pgdown", "ctrl+v"}},
+	{Name: "select.mark", Description: "Set or clear the mark", Keys: []string{"alt+a", "ctrl+6"}},
+	{Name: "select.up", Description: "Extend the selection up", Keys: []string{"shift+up"}},
+	{Name: "select.down", Description: "Extend the selection down", Keys: []string{"shift+down"}},
+	{Name: "select.left", Description: "Extend the selection left", Keys: []string{"shift+left"}},
+	{Name: "select.right", Description: "Extend the selection right", Keys: []string{"shift+right"}},
+
+	// Navigation
+	{Name: "goto.line", Description: "Go to a line", Keys: []string{"ctrl+_", "alt+g"}},
+	{Name: "goto.start", Description: "Go to the beginning of the file", Keys: []string{"alt+\\", "ctrl+home"}},
+	{Name: "goto.end", Description: "Go to the end of the file", Keys: []string{"alt+/", "ctrl+end"}},
+	{Name: "goto.bracket", Description: "Go to the matching bracket", Keys: []string{"alt+]"}},
+	{Name: "goto.position", Description: "Show the cursor position", Keys: []string{"ctrl+c"}},
+	{Name: "goto.definition", Description: "Go to the definition of the symbol", Keys: []string{"f12"}},
+	{Name: "goto.back", Description: "Return from a definition", Keys: []string{"alt+,"}},
+	{Name: "goto.next-diagnostic", Description: "Go to the next diagnostic", Keys: []string{"f8"}},
+	{Name: "goto.previous-diagnostic", Description: "Go to the previous diagnostic", Keys: []string{"shift+f8", "f20"}},
+
+	// Bookmarks and folding
+	{Name: "bookmark.toggle", Description: "Place or remove a bookmark", Keys: []string{"alt+insert"}},
+	{Name: "bookmark.next", Description: "Jump to the next bookmark", Keys: []string{"alt+pgdown"}},
+	{Name: "bookmark.previous", Description: "Jump to the previous bookmark", Keys: []string{"alt+pgup"}},
+	{Name: "bookmark.list", Description: "List the bookmarks of all tabs", Keys: []string{"alt+m"}},
+	{Name: "fold.toggle", Description: "Fold or unfold the block at the cursor", Keys: []string{"alt+z"}},
+	{Name: "fold.all", Description: "Fold all blocks, or unfold everything", Keys: []string{"alt+0"}},
+
+	// Tabs and splits
+	{Name: "tab.new", Description: "Open a new empty tab", Keys: []string{"ctrl+t"}},
+	{Name: "tab.next", Description: "Switch to the next tab", Keys: []string{"ctrl+pgdown"}},
+	{Name: "tab.previous", Description: "Switch to the previous tab", Keys: []string{"ctrl+pgup"}},
+	{Name: "tab.close", Description: "Close the current tab", Run: func(m *Model) tea.Cmd {
+		switch {
+		case m.modified:
+			m.SetStatusMessage("Save the tab before closing it")
+		case !m.CloseTab():
+			m.SetStatusMessage("Cannot close the last tab")
+		}
+		return nil
+	}},
+	{Name: "split.horizontal", Description: "Split the view side by side", Run: func(m *Model) tea.Cmd {
+		m.SplitHorizontal()
+		return nil
+	}},
+	{Name: "split.vertical", Description: "Split the view top and bottom", Keys: []string{"alt+-"}},
+	{Name: "split.close", Description: "Close the split", Keys: []string{"alt+c"}},
+	{Name: "split.previous", Description: "Switch to the left or top pane", Keys: []string{"alt+left", "alt+h"}},
+	{Name: "split.next", Description: "Switch to the right or bottom pane", Keys: []string{"alt+right", "alt+l"}},
+
+	// Toggles
+	{Name: "toggle.line-numbers", Description: "Toggle line numbers", Keys: []string{"alt+n"}},
+	{Name: "toggle.spell-check", Description: "Toggle underlining of misspelled words", Keys: []string{"alt+f7"}},
+	{Name: "toggle.tab-bar", Description: "Toggle the tab bar", Run: func(m *Model) tea.Cmd {
+		m.ToggleShowTabs()
+		return nil
+	}},
+
+	// Macros
+	{Name: "macro.record", Description: "Start or stop recording a macro", Keys: []string{"f4"}},
+	{Name: "macro.play", Description: "Play the macro", Keys: []string{"f5"}},
+	{Name: "macro.save", Description: "Save the macro under a name", Run: func(m *Model) tea.Cmd {
+		if !m.macro.HasKeys() {
+			m.SetStatusMessage("No macro recorded")
+			return nil
+		}
+		m.mode = ModeSaveMacro
+		m.inputBuffer = ""
+		m.inputPrompt = "Save macro as: "
+		return nil
+	}},
+	{Name: "macro.load", Description: "Load a saved macro", Run: func(m *Model) tea.Cmd {
+		m.mode = ModeLoadMacro
+		m.inputBuffer = ""
+		m.inputPrompt = "Load macro: "
+		return nil
+	}},
+
+	// Tools
+	{Name: "tool.lint", Description: "Run the linter", Keys: []string{"alt+b"}},
+	{Name: "tool.format", Description: "Format with the language server", Keys: []string{"alt+f"}},
+	{Name: "tool.hover", Description: "Show hover information", Keys: []string{"alt+k"}},
+	{Name: "tool.rename", Description: "Rename the symbol across files", Keys: []string{"f2"}},
+	{Name: "tool.spell", Description: "Go to the next misspelled word", Keys: []string{"f7"}},
+
+	// Prompts
+	{Name: "prompt.accept", Description: "Accept the input", Mode: keymap.Prompt, Keys: []string{"enter"}},
+	{Name: "prompt.cancel", Description: "Cancel the prompt", Mode: keymap.Prompt, Keys: []string{"esc"}},
+	{Name: "prompt.backspace", Description: "Delete the last character", Mode: keymap.Prompt, Keys: []string{"backspace"}},
+	{Name: "prompt.history-previous", Description: "Show the previous history entry", Mode: keymap.Prompt, Keys: []string{"up"}},
+	{Name: "prompt.history-next", Description: "Show the next history entry", Mode: keymap.Prompt, Keys: []string{"down"}},
+	{Name: "prompt.complete", Description: "Complete the path or name", Mode: keymap.Prompt, Keys: []string{"tab"}},
+	{Name: "prompt.complete-previous", Description: "Cycle back through completions", Mode: keymap.Prompt, Keys: []string{"shift+tab"}},
+	{Name: "prompt.browse", Description: "Pick the file in the file tree", Mode: keymap.Prompt, Keys: []string{"ctrl+t"}},
+	{Name: "prompt.output-mode", Description: "Replace or insert the command output", Mode: keymap.Prompt, Keys: []string{"ctrl+r"}},
+	{Name: "search.regexp", Description: "Switch project searches between text and regular expressions", Mode: keymap.Search, Keys: []string{"alt+r"}},
+}
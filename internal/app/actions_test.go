@@ -0,0 +1,101 @@
+package app
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/keymap"
+)
+
+func TestActionKeys(t *testing.T) {
+	for _, action := range builtinActions {
+		if len(action.Keys) == 0 {
+			continue
+		}
+		// The first key must reach its case in the mode's handler
+		if got := keymap.Msg(action.Keys[0]).String(); got != action.Keys[0] {
+			t.Errorf("%s: Msg(%q).String() = %q", action.Name, action.Keys[0], got)
+		}
+		for _, key := range action.Keys {
+			// Bubble Tea does not report Ctrl+Delete, its case is for the
+			// command palette
+			if _, err := keymap.Normalize(key); err != nil && key != "ctrl+delete" {
+				t.Errorf("%s: %v", action.Name, err)
+			}
+		}
+	}
+}
+
+func TestRegisterAction(t *testing.T) {
+	if err := RegisterAction(Action{Name: "edit.undo", Keys: []string{"alt+u"}}); err == nil {
+		t.Error("registering a duplicate name succeeded")
+	}
+	if err := RegisterAction(Action{Name: "test.nothing"}); err == nil {
+		t.Error("registering an action without a key or function succeeded")
+	}
+
+	ran := 0
+	err := RegisterAction(Action{Name: "test.count", Description: "Count runs", Run: func(m *Model) tea.Cmd {
+		ran++
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer delete(actions, "test.count")
+
+	m := New()
+	if _, err := m.RunAction("test.count"); err != nil || ran != 1 {
+		t.Errorf("RunAction = %v, ran %d times", err, ran)
+	}
+	if _, err := m.RunAction("test.missing"); err == nil {
+		t.Error("running an unknown action succeeded")
+	}
+}
+
+func TestCommandPalette(t *testing.T) {
+	m := NewFromFile("test.txt", "test.txt", "one\n")
+	m.SetSize(80, 20)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyF1})
+	if m.mode != ModePicker || !m.picker.fuzzy {
+		t.Fatalf("mode = %v, want the palette", m.mode)
+	}
+	typeText(m, "splhor")
+	if item := m.picker.items[m.picker.matches[0]]; item.label != "Split the view side by side" {
+		t.Errorf("best match = %q, want the horizontal split", item.label)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeNormal || !m.IsSplit() {
+		t.Errorf("mode = %v, split %v, want a split view", m.mode, m.IsSplit())
+	}
+}
+
+func TestMacroRecordsActions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	m := NewFromFile("test.txt", "test.txt", "one\n")
+	m.SetSize(80, 20)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyF4})
+	m.Update(tea.KeyMsg{Type: tea.KeyF1})
+	typeText(m, "duplicate")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(tea.KeyMsg{Type: tea.KeyF4})
+	if m.macro.KeyCount() != 1 || m.macro.keys[0].Action != "edit.duplicate" {
+		t.Fatalf("recorded %+v, want the edit.duplicate action", m.macro.keys)
+	}
+
+	// The action survives saving and loading
+	if err := m.macro.SaveMacro("dup"); err != nil {
+		t.Fatal(err)
+	}
+	m.macro.Clear()
+	if err := m.macro.LoadMacro("dup"); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyF5})
+	if got := m.Content(); got != "one\none\none\n" {
+		t.Errorf("content = %q, want three lines", got)
+	}
+}
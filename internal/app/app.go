@@ -3,11 +3,13 @@ package app
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 
 	"github.com/KilimcininKorOglu/gesh/internal/buffer"
 	"github.com/KilimcininKorOglu/gesh/internal/file"
@@ -20,14 +22,21 @@ import (
 var (
 	currentTheme = styles.DarkTheme
 
-	headerStyle      lipgloss.Style
-	statusStyle      lipgloss.Style
-	helpStyle        lipgloss.Style
-	helpKeyStyle     lipgloss.Style
-	lineNumberStyle  lipgloss.Style
-	editorStyle      lipgloss.Style
-	selectionStyle   lipgloss.Style
-	searchMatchStyle lipgloss.Style
+	headerStyle       lipgloss.Style
+	statusStyle       lipgloss.Style
+	helpStyle         lipgloss.Style
+	helpKeyStyle      lipgloss.Style
+	lineNumberStyle   lipgloss.Style
+	editorStyle       lipgloss.Style
+	selectionStyle    lipgloss.Style
+	searchMatchStyle  lipgloss.Style
+	bracketStyle      lipgloss.Style
+	gutterMarkerStyle lipgloss.Style
+
+	// Lint diagnostic styles
+	diagnosticErrorStyle   lipgloss.Style
+	diagnosticWarningStyle lipgloss.Style
+	diagnosticInfoStyle    lipgloss.Style
 
 	// Syntax highlighting styles
 	syntaxKeywordStyle  lipgloss.Style
@@ -84,6 +93,19 @@ func applyTheme(theme styles.Theme) {
 		Background(lipgloss.Color("#ffff00")).
 		Foreground(lipgloss.Color("#000000"))
 
+	bracketStyle = lipgloss.NewStyle().
+		Background(theme.SelectionBg).
+		Foreground(theme.HeaderFg).
+		Bold(true)
+
+	gutterMarkerStyle = lipgloss.NewStyle().
+		Foreground(theme.HelpKeyFg).
+		Bold(true)
+
+	diagnosticErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5555"))
+	diagnosticWarningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffb86c"))
+	diagnosticInfoStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#8be9fd"))
+
 	// Syntax highlighting colors (theme-aware)
 	syntaxKeywordStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff79c6"))
 	syntaxTypeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#8be9fd"))
@@ -123,16 +145,28 @@ func scrollTick() tea.Cmd {
 // Init initializes the model.
 func (m *Model) Init() tea.Cmd {
 	// Request initial window size to trigger first render
-	return tea.Batch(tea.WindowSize(), autoSaveTick())
+	return tea.Batch(tea.WindowSize(), autoSaveTick(), m.syncLSP())
 }
 
 // Update handles messages and updates the model.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	// Unfold the region the cursor was moved into
+	m.revealCursor()
+	return model, cmd
+}
+
+// update dispatches a message to its handler.
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		return m.handleKeyMsg(msg)
+		model, cmd := m.handleKeyMsg(msg)
+		m.showLineDiagnostic()
+		return model, tea.Batch(cmd, m.syncLSP())
 	case tea.MouseMsg:
-		return m.handleMouseMsg(msg)
+		model, cmd := m.handleMouseMsg(msg)
+		m.showLineDiagnostic()
+		return model, tea.Batch(cmd, m.syncLSP())
 	case tea.WindowSizeMsg:
 		m.SetSize(msg.Width, msg.Height)
 		return m, tea.ClearScreen
@@ -147,6 +181,38 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// Continue ticking
 		return m, autoSaveTick()
+	case commandDoneMsg:
+		m.finishCommand(msg)
+		return m, nil
+	case lintDoneMsg:
+		m.finishLint(msg)
+		return m, nil
+	case lspStartedMsg:
+		return m, m.finishLSPStart(msg)
+	case lspDiagnosticsMsg:
+		return m, m.finishLSPDiagnostics(msg)
+	case lspHoverMsg:
+		m.finishLSPHover(msg)
+		return m, nil
+	case lspDefinitionMsg:
+		m.finishLSPDefinition(msg)
+		return m, m.syncLSP()
+	case lspCompletionMsg:
+		m.finishLSPCompletion(msg)
+		return m, nil
+	case lspRenameMsg:
+		m.finishLSPRename(msg)
+		return m, m.syncLSP()
+	case lspFormatMsg:
+		m.finishLSPFormat(msg)
+		return m, m.syncLSP()
+	case projectSearchMsg:
+		return m, m.finishProjectSearch(msg)
+	case fileIndexMsg:
+		return m, m.finishFileIndex(msg)
+	case finderPreviewMsg:
+		m.finishPreview(msg)
+		return m, nil
 	case scrollTickMsg:
 		// Update smooth scroll animation
 		if m.UpdateSmoothScroll() {
@@ -161,6 +227,17 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // handleMouseMsg processes mouse input.
 func (m *Model) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	// Clicks on the file tree focus it; clicks beside it focus the editor
+	if m.treeVisible() && (m.mode == ModeNormal || m.mode == ModeFileTree) && m.tree.prompt == ModeNormal {
+		if m.handleTreeClick(msg) {
+			return m, nil
+		}
+		msg.X -= m.treeWidth() + 1
+		if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress {
+			m.mode = ModeNormal
+		}
+	}
+
 	// Only handle in normal mode
 	if m.mode != ModeNormal {
 		return m, nil
@@ -171,8 +248,10 @@ func (m *Model) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 		// User clicked - reset mouse scrolling mode
 		m.mouseScrolling = false
 
-		// Calculate which line was clicked (account for header)
-		clickedLine := m.viewportTopLine + msg.Y - 1 // -1 for header
+		// Calculate which line was clicked (account for header and folds)
+		ranges := m.hiddenRanges()
+		topRow := visibleRow(ranges, visibleLine(ranges, m.viewportTopLine))
+		clickedLine := lineAtRow(ranges, topRow+msg.Y-1) // -1 for header
 
 		// Check bounds
 		if clickedLine < 0 {
@@ -235,24 +314,28 @@ func (m *Model) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	case tea.MouseButtonWheelUp:
 		// Scroll up - user controls viewport
 		m.mouseScrolling = true
-		m.viewportTopLine -= 3
-		if m.viewportTopLine < 0 {
-			m.viewportTopLine = 0
+		ranges := m.hiddenRanges()
+		topRow := visibleRow(ranges, visibleLine(ranges, m.viewportTopLine)) - 3
+		if topRow < 0 {
+			topRow = 0
 		}
+		m.viewportTopLine = lineAtRow(ranges, topRow)
 
 	case tea.MouseButtonWheelDown:
 		// Scroll down - user controls viewport
 		m.mouseScrolling = true
-		m.viewportTopLine += 3
+		ranges := m.hiddenRanges()
+		topRow := visibleRow(ranges, visibleLine(ranges, m.viewportTopLine)) + 3
 		// Max scroll = show at least 1 line at bottom
 		visibleLines := m.height - 4 // header, status, 2 help lines
-		maxTop := m.buffer.LineCount() - visibleLines
+		maxTop := visibleRow(ranges, m.buffer.LineCount()-1) + 1 - visibleLines
 		if maxTop < 0 {
 			maxTop = 0
 		}
-		if m.viewportTopLine > maxTop {
-			m.viewportTopLine = maxTop
+		if topRow > maxTop {
+			topRow = maxTop
 		}
+		m.viewportTopLine = lineAtRow(ranges, topRow)
 	}
 
 	return m, nil
@@ -269,12 +352,41 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.macro.RecordKey(msg)
 	}
 
+	// The vi keymap handles its own keys of normal and visual mode
+	if m.vi != nil && m.mode == ModeNormal {
+		if handled, cmd := m.handleViKey(msg); handled {
+			return m, cmd
+		}
+	}
+
+	// Look the key up in the key bindings of the mode
+	msg, cmd, handled := m.applyKeymap(msg)
+	if handled {
+		return m, cmd
+	}
+	return m.dispatchKey(msg)
+}
+
+// dispatchKey handles a key, after the key bindings, in the current mode.
+func (m *Model) dispatchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Up/Down browse the history of a prompt; Enter records its input
+	if kind := promptHistoryKind(m.mode); kind != "" && m.handlePromptHistory(msg, kind) {
+		return m, nil
+	}
+
 	// Handle quit confirmation mode
 	if m.mode == ModeQuit {
 		switch msg.String() {
 		case "y", "Y":
 			// Save and quit
 			m.saveFile()
+			if m.modified {
+				// Saving failed (e.g. the formatter); keep the editor open
+				if m.mode == ModeQuit {
+					m.mode = ModeNormal
+				}
+				return m, nil
+			}
 			m.quitting = true
 			return m, tea.Quit
 		case "n", "N":
@@ -340,6 +452,80 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleLoadMacroInput(msg)
 	}
 
+	// Handle list picker mode
+	if m.mode == ModePicker {
+		return m.handlePickerInput(msg)
+	}
+
+	// Handle align on delimiter mode
+	if m.mode == ModeAlign {
+		return m.handleAlignInput(msg)
+	}
+
+	// Handle execute command mode
+	if m.mode == ModeExecute {
+		return m.handleExecuteInput(msg)
+	}
+
+	// Handle rename symbol mode
+	if m.mode == ModeRename {
+		return m.handleRenameInput(msg)
+	}
+
+	// Handle project search mode
+	if m.mode == ModeProjectSearch {
+		return m.handleProjectSearchInput(msg)
+	}
+
+	// Handle project replace modes
+	if m.mode == ModeProjectReplace || m.mode == ModeProjectReplaceWith {
+		return m.handleProjectReplaceInput(msg)
+	}
+	if m.mode == ModeReplacePreview {
+		return m.handleReplacePreviewInput(msg)
+	}
+	if m.mode == ModeFileFinder {
+		return m.handleFileFinderInput(msg)
+	}
+
+	// Handle file tree modes
+	if m.mode == ModeFileTree {
+		return m.handleFileTreeInput(msg)
+	}
+	if m.mode == ModeTreeCreate || m.mode == ModeTreeRename {
+		return m.handleTreePromptInput(msg)
+	}
+	if m.mode == ModeTreeDelete {
+		return m.handleTreeDeleteInput(msg)
+	}
+
+	// Handle the vi command line
+	if m.mode == ModeViCommand {
+		return m.handleViCommandInput(msg)
+	}
+
+	// Esc or Ctrl+C stops a running external command
+	if m.command != nil && (msg.String() == "esc" || msg.String() == "ctrl+c") {
+		m.cancelCommand()
+		return m, nil
+	}
+
+	// Snippet tab-stop navigation wraps normal key handling
+	if m.activeSnippet != nil {
+		return m.handleSnippetKey(msg)
+	}
+
+	// Word completion consumes its own keys; others end it
+	if m.completion != nil && m.handleCompletionKey(msg) {
+		return m, nil
+	}
+
+	return m.handleNormalKey(msg)
+}
+
+// handleNormalKey performs the normal mode command of a key, or inserts
+// the typed text. Named actions run through it too.
+func (m *Model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Normal mode key handling - NANO COMPATIBLE
 	switch msg.String() {
 
@@ -377,6 +563,11 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.SetStatusMessage("GESH 1.0.0 - Nano-compatible text editor | ^X Exit | ^O Save | ^W Search")
 		return m, nil
 
+	case "f1":
+		// Command palette: run any action by name
+		m.openCommandPalette()
+		return m, nil
+
 	// ==================== NANO SEARCH & REPLACE ====================
 
 	case "ctrl+w":
@@ -436,6 +627,99 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case "alt+3":
+		// Nano: Comment/uncomment line (or selected lines)
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.toggleComment()
+		return m, nil
+
+	case "alt+}":
+		// Nano: Indent line (or selected lines)
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.indentLines()
+		return m, nil
+
+	case "alt+{":
+		// Nano: Unindent line (or selected lines)
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.unindentLines()
+		return m, nil
+
+	case "alt+t":
+		// Nano: Cut from cursor to end of line
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.cutToEndOfLine()
+		return m, nil
+
+	case "alt+up":
+		// Move line (or selected lines) up
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.moveLinesUp()
+		return m, nil
+
+	case "alt+down":
+		// Move line (or selected lines) down
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.moveLinesDown()
+		return m, nil
+
+	case "alt+d":
+		// Duplicate line (or selected lines)
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.duplicateLines()
+		return m, nil
+
+	case "alt+j":
+		// Join next line (or selected lines)
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.joinLines()
+		return m, nil
+
+	case "ctrl+]":
+		// Nano: Complete word from open buffers (or the language server)
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		if cmd := m.lspComplete(); cmd != nil {
+			return m, cmd
+		}
+		m.startCompletion()
+		return m, nil
+
+	case "alt+s":
+		// Transform selection (or buffer): sort, case, encode, align
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.showTransforms()
+		return m, nil
+
 	case "alt+u":
 		// Nano: Undo
 		m.undo()
@@ -456,6 +740,15 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.NewTab()
 		return m, nil
 
+	case "alt+!":
+		// Execute command: pipe selection or buffer through a shell command
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.openExecutePrompt()
+		return m, nil
+
 	// ==================== NANO NAVIGATION ====================
 
 	case "ctrl+y":
@@ -484,6 +777,12 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.ensureCursorVisible()
 		return m, nil
 
+	case "alt+]":
+		// Nano: Go to matching bracket
+		m.clearSelection()
+		m.jumpToMatchingBracket()
+		return m, nil
+
 	case "ctrl+_", "alt+g":
 		// Nano: Go to line
 		m.mode = ModeGoto
@@ -622,9 +921,12 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.SetStatusMessage("File is read-only")
 			return m, nil
 		}
+		if m.deleteAutoPair() {
+			return m, nil
+		}
 		pos := m.buffer.CursorPos()
 		if r := m.buffer.Delete(); r != 0 {
-			m.history.Push(buffer.EditOperation{
+			m.pushEdit(buffer.EditOperation{
 				Type:     buffer.OpDelete,
 				Position: pos - 1,
 				Text:     string(r),
@@ -641,7 +943,7 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		pos := m.buffer.CursorPos()
 		if r := m.buffer.DeleteForward(); r != 0 {
-			m.history.Push(buffer.EditOperation{
+			m.pushEdit(buffer.EditOperation{
 				Type:     buffer.OpDelete,
 				Position: pos,
 				Text:     string(r),
@@ -705,7 +1007,7 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if indent != "" {
 			m.buffer.InsertString(indent)
 		}
-		m.history.Push(buffer.EditOperation{
+		m.pushEdit(buffer.EditOperation{
 			Type:     buffer.OpInsert,
 			Position: pos,
 			Text:     insertText,
@@ -719,24 +1021,75 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.SetStatusMessage("File is read-only")
 			return m, nil
 		}
+		if m.hasLineSelection() {
+			m.indentLines()
+			return m, nil
+		}
+		if m.expandSnippet() {
+			return m, nil
+		}
 		pos := m.buffer.CursorPos()
-		m.buffer.InsertString("    ")
-		m.history.Push(buffer.EditOperation{
+		indent := m.indentUnit()
+		m.buffer.InsertString(indent)
+		m.pushEdit(buffer.EditOperation{
 			Type:     buffer.OpInsert,
 			Position: pos,
-			Text:     "    ",
+			Text:     indent,
 		})
 		m.setModified()
 		return m, nil
 
+	case "shift+tab":
+		// Unindent line (or selected lines)
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.unindentLines()
+		return m, nil
+
 	case "insert":
 		// Toggle insert/overwrite mode
 		m.ToggleOverwriteMode()
 		return m, nil
 
+	// ==================== BOOKMARKS ====================
+
+	case "alt+insert":
+		// Nano: Place or remove an anchor
+		m.toggleBookmark()
+		return m, nil
+
+	case "alt+pgup":
+		// Nano: Jump to previous anchor
+		m.prevBookmark()
+		return m, nil
+
+	case "alt+pgdown":
+		// Nano: Jump to next anchor
+		m.nextBookmark()
+		return m, nil
+
+	case "alt+m":
+		// List bookmarks of all tabs
+		m.listBookmarks()
+		return m, nil
+
+	// ==================== CODE FOLDING (Extension) ====================
+
+	case "alt+z":
+		// Fold/unfold the block at the cursor
+		m.toggleFold()
+		return m, nil
+
+	case "alt+0":
+		// Fold all blocks, or unfold everything
+		m.toggleAllFolds()
+		return m, nil
+
 	// ==================== TAB MANAGEMENT (Extension) ====================
 
-	case "ctrl+tab", "ctrl+pgdn":
+	case "ctrl+tab", "ctrl+pgdn", "ctrl+pgdown":
 		m.NextTab()
 		return m, nil
 
@@ -793,6 +1146,93 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m.playMacro()
 
+	// ==================== LINT (Extension) ====================
+
+	case "alt+b":
+		// Nano: Linter (saves a modified file first)
+		return m, m.lintFile()
+
+	case "f8":
+		// Next diagnostic
+		m.gotoDiagnostic(true)
+		return m, nil
+
+	case "shift+f8", "f20":
+		// Previous diagnostic (xterm-style terminals send Shift+F8 as F20)
+		m.gotoDiagnostic(false)
+		return m, nil
+
+	// ==================== LANGUAGE SERVER (Extension) ====================
+
+	case "f12":
+		// Go to definition, from the tags file without a language server
+		if m.lspCommands[m.tabLanguage(m.tabs.ActiveTab())] == "" {
+			m.gotoTag()
+			return m, nil
+		}
+		return m, m.lspDefinition()
+
+	case "alt+,":
+		// Return from a definition
+		m.jumpBack()
+		return m, nil
+
+	case "alt+k":
+		// Show hover information
+		return m, m.lspHover()
+
+	case "f2":
+		// Rename symbol across files
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return m, nil
+		}
+		m.openRenamePrompt()
+		return m, nil
+
+	case "alt+f":
+		// Format with the language server
+		return m, m.lspFormat()
+
+	// ==================== PROJECT SEARCH (Extension) ====================
+
+	case "f6":
+		// Search all files of the project
+		m.openProjectSearchPrompt()
+		return m, nil
+
+	case "alt+o":
+		// Find a file of the project by fuzzy matching its path
+		return m, m.openFileFinder()
+
+	case "f9":
+		// Show, focus or hide the file tree sidebar
+		m.toggleFileTree()
+		return m, nil
+
+	case "alt+f6":
+		// Show the results of the last project search again
+		m.showProjectSearch()
+		return m, nil
+
+	case "shift+f6", "f18":
+		// Replace in all files of the project, with a preview
+		// (xterm-style terminals send Shift+F6 as F18)
+		m.openProjectReplacePrompt()
+		return m, nil
+
+	// ==================== SPELL CHECK (Extension) ====================
+
+	case "f7":
+		// Next misspelled word with suggestions
+		m.nextMisspelling()
+		return m, nil
+
+	case "alt+f7":
+		// Toggle underlining of misspelled words
+		m.toggleSpellCheck()
+		return m, nil
+
 	case "f3":
 		// Find next (also nano compatible)
 		m.nextMatch()
@@ -822,6 +1262,10 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.SetStatusMessage("File is read-only")
 				return m, nil
 			}
+			// Auto-pair brackets and quotes for single typed characters
+			if len(msg.Runes) == 1 && !msg.Paste && !m.overwriteMode && m.insertAutoPair(msg.Runes[0]) {
+				return m, nil
+			}
 			pos := m.buffer.CursorPos()
 			text := string(msg.Runes)
 
@@ -842,9 +1286,10 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				for _, r := range msg.Runes {
 					m.buffer.Insert(r)
 				}
+				m.shiftAutoClosers(pos, len(msg.Runes))
 			}
 
-			m.history.Push(buffer.EditOperation{
+			m.pushEdit(buffer.EditOperation{
 				Type:     buffer.OpInsert,
 				Position: pos,
 				Text:     text,
@@ -856,49 +1301,57 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// undo reverses the last edit operation.
+// undo reverses the last edit operation (or compound edit group).
 func (m *Model) undo() {
-	op := m.history.Undo()
-	if op == nil {
+	ops := m.history.UndoGroup()
+	if ops == nil {
 		m.SetStatusMessage("Nothing to undo")
 		return
 	}
 
-	// Reverse the operation
-	if op.Type == buffer.OpInsert {
-		// Undo insert: delete the text
-		m.buffer.MoveTo(op.Position)
-		for range []rune(op.Text) {
-			m.buffer.DeleteForward()
+	// Reverse the operations, most recent first
+	for _, op := range ops {
+		if op.Type == buffer.OpInsert {
+			// Undo insert: delete the text
+			m.buffer.MoveTo(op.Position)
+			for range []rune(op.Text) {
+				m.buffer.DeleteForward()
+			}
+			op.Type = buffer.OpDelete
+		} else {
+			// Undo delete: insert the text
+			m.buffer.MoveTo(op.Position)
+			m.buffer.InsertString(op.Text)
+			op.Type = buffer.OpInsert
 		}
-	} else {
-		// Undo delete: insert the text
-		m.buffer.MoveTo(op.Position)
-		m.buffer.InsertString(op.Text)
+		m.trackEdit(op)
 	}
 
 	m.SetStatusMessage("Undo")
 }
 
-// redo re-applies the last undone operation.
+// redo re-applies the last undone operation (or compound edit group).
 func (m *Model) redo() {
-	op := m.history.Redo()
-	if op == nil {
+	ops := m.history.RedoGroup()
+	if ops == nil {
 		m.SetStatusMessage("Nothing to redo")
 		return
 	}
 
-	// Re-apply the operation
-	if op.Type == buffer.OpInsert {
-		// Redo insert: insert the text
-		m.buffer.MoveTo(op.Position)
-		m.buffer.InsertString(op.Text)
-	} else {
-		// Redo delete: delete the text
-		m.buffer.MoveTo(op.Position)
-		for range []rune(op.Text) {
-			m.buffer.DeleteForward()
+	// Re-apply the operations in their original order
+	for _, op := range ops {
+		if op.Type == buffer.OpInsert {
+			// Redo insert: insert the text
+			m.buffer.MoveTo(op.Position)
+			m.buffer.InsertString(op.Text)
+		} else {
+			// Redo delete: delete the text
+			m.buffer.MoveTo(op.Position)
+			for range []rune(op.Text) {
+				m.buffer.DeleteForward()
+			}
 		}
+		m.trackEdit(op)
 	}
 
 	m.SetStatusMessage("Redo")
@@ -911,9 +1364,13 @@ func (m *Model) moveCursorUp() {
 		return
 	}
 
+	// Skip lines hidden by folds
+	ranges := m.hiddenRanges()
+	targetLine := lineAtRow(ranges, visibleRow(ranges, currentLine)-1)
+
 	currentCol := m.buffer.CurrentColumn()
-	targetLineStart := m.buffer.LineStart(currentLine - 1)
-	targetLineEnd := m.buffer.LineEnd(currentLine - 1)
+	targetLineStart := m.buffer.LineStart(targetLine)
+	targetLineEnd := m.buffer.LineEnd(targetLine)
 	targetLineLen := targetLineEnd - targetLineStart
 
 	// Calculate target position
@@ -928,13 +1385,17 @@ func (m *Model) moveCursorUp() {
 // moveCursorDown moves the cursor down one line.
 func (m *Model) moveCursorDown() {
 	currentLine := m.buffer.CurrentLine()
-	if currentLine >= m.buffer.LineCount()-1 {
+
+	// Skip lines hidden by folds
+	ranges := m.hiddenRanges()
+	targetLine := lineAtRow(ranges, visibleRow(ranges, currentLine)+1)
+	if targetLine >= m.buffer.LineCount() {
 		return
 	}
 
 	currentCol := m.buffer.CurrentColumn()
-	targetLineStart := m.buffer.LineStart(currentLine + 1)
-	targetLineEnd := m.buffer.LineEnd(currentLine + 1)
+	targetLineStart := m.buffer.LineStart(targetLine)
+	targetLineEnd := m.buffer.LineEnd(targetLine)
 	targetLineLen := targetLineEnd - targetLineStart
 
 	// Calculate target position
@@ -967,23 +1428,25 @@ func (m *Model) pageUp() {
 		visibleLines = 1
 	}
 
-	currentLine := m.buffer.CurrentLine()
-	targetLine := currentLine - visibleLines
-	if targetLine < 0 {
-		targetLine = 0
+	// Rows are counted in visible lines, skipping folded ones
+	ranges := m.hiddenRanges()
+	currentRow := visibleRow(ranges, m.buffer.CurrentLine())
+	targetRow := currentRow - visibleLines
+	if targetRow < 0 {
+		targetRow = 0
 	}
 
-	lineStart := m.buffer.LineStart(targetLine)
+	lineStart := m.buffer.LineStart(lineAtRow(ranges, targetRow))
 	if lineStart >= 0 {
 		m.buffer.MoveTo(lineStart)
 	}
 
 	// Start smooth scroll
-	newTopLine := m.viewportTopLine - visibleLines
-	if newTopLine < 0 {
-		newTopLine = 0
+	newTopRow := visibleRow(ranges, m.viewportTopLine) - visibleLines
+	if newTopRow < 0 {
+		newTopRow = 0
 	}
-	m.StartSmoothScroll(newTopLine)
+	m.StartSmoothScroll(lineAtRow(ranges, newTopRow))
 }
 
 // pageDown moves the cursor down by a page.
@@ -993,28 +1456,30 @@ func (m *Model) pageDown() {
 		visibleLines = 1
 	}
 
-	currentLine := m.buffer.CurrentLine()
-	maxLine := m.buffer.LineCount() - 1
-	targetLine := currentLine + visibleLines
-	if targetLine > maxLine {
-		targetLine = maxLine
+	// Rows are counted in visible lines, skipping folded ones
+	ranges := m.hiddenRanges()
+	currentRow := visibleRow(ranges, m.buffer.CurrentLine())
+	maxRow := visibleRow(ranges, m.buffer.LineCount()-1)
+	targetRow := currentRow + visibleLines
+	if targetRow > maxRow {
+		targetRow = maxRow
 	}
 
-	lineStart := m.buffer.LineStart(targetLine)
+	lineStart := m.buffer.LineStart(lineAtRow(ranges, targetRow))
 	if lineStart >= 0 {
 		m.buffer.MoveTo(lineStart)
 	}
 
 	// Start smooth scroll
-	maxTopLine := maxLine - visibleLines + 1
-	if maxTopLine < 0 {
-		maxTopLine = 0
+	maxTopRow := maxRow - visibleLines + 1
+	if maxTopRow < 0 {
+		maxTopRow = 0
 	}
-	newTopLine := m.viewportTopLine + visibleLines
-	if newTopLine > maxTopLine {
-		newTopLine = maxTopLine
+	newTopRow := visibleRow(ranges, m.viewportTopLine) + visibleLines
+	if newTopRow > maxTopRow {
+		newTopRow = maxTopRow
 	}
-	m.StartSmoothScroll(newTopLine)
+	m.StartSmoothScroll(lineAtRow(ranges, newTopRow))
 }
 
 // deleteLine deletes the current line.
@@ -1046,7 +1511,7 @@ func (m *Model) deleteLine() {
 	}
 
 	// Record for undo
-	m.history.Push(buffer.EditOperation{
+	m.pushEdit(buffer.EditOperation{
 		Type:     buffer.OpDelete,
 		Position: lineStart,
 		Text:     deletedText,
@@ -1109,11 +1574,19 @@ func (m *Model) playMacro() (tea.Model, tea.Cmd) {
 	keysPlayed := 0
 	var cmds []tea.Cmd
 	for {
-		key := m.macro.NextKey()
-		if key == nil {
+		step := m.macro.NextKey()
+		if step == nil {
 			break
 		}
-		_, cmd := m.handleKeyMsg(*key)
+		var cmd tea.Cmd
+		if step.Action != "" {
+			var err error
+			if cmd, err = m.RunAction(step.Action); err != nil {
+				m.SetStatusMessage(err.Error())
+			}
+		} else {
+			_, cmd = m.handleKeyMsg(step.Key)
+		}
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
@@ -1137,6 +1610,12 @@ func (m *Model) saveFile() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Format first; a failing formatter leaves the buffer and file untouched
+	if err := m.formatBuffer(); err != nil {
+		m.SetStatusMessage("Not saved, formatter failed: " + err.Error())
+		return m, nil
+	}
+
 	// Save to existing filepath with options
 	opts := file.SaveOptions{
 		TrimTrailingSpaces: m.trimTrailingSpaces,
@@ -1152,21 +1631,40 @@ func (m *Model) saveFile() (tea.Model, tea.Cmd) {
 	m.modified = false
 	m.UpdateLastSaveTime()
 	m.SetStatusMessage("Saved: " + m.filename)
+
+	// Keep persisted bookmarks in line with the saved content
+	m.syncToActiveTab()
+	if err := saveBookmarks(m.tabs.ActiveTab()); err != nil {
+		m.SetStatusMessage("Saved, but bookmarks could not be stored: " + err.Error())
+	}
+
+	if m.lintOnSave {
+		return m, m.startLint()
+	}
 	return m, nil
 }
 
 // handleSaveAsInput handles input in save-as mode.
 func (m *Model) handleSaveAsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.handlePathCompletion(msg, "", false) {
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "enter":
 		if m.inputBuffer != "" {
-			m.SetFilepath(m.inputBuffer)
+			m.SetFilepath(expandHome(m.inputBuffer))
 			m.mode = ModeNormal
 			m.inputBuffer = ""
 			return m.saveFile()
 		}
 		return m, nil
 
+	case "ctrl+t":
+		// Nano: browse for the file
+		m.browseForPrompt()
+		return m, nil
+
 	case "esc":
 		m.mode = ModeNormal
 		m.inputBuffer = ""
@@ -1319,6 +1817,7 @@ func (m *Model) goToMatch(index int) {
 		return
 	}
 	m.buffer.MoveTo(m.searchMatches[index])
+	m.revealLine(m.buffer.CurrentLine())
 }
 
 // nextMatch moves to the next search match.
@@ -1432,12 +1931,12 @@ func (m *Model) replaceOne() {
 	m.buffer.InsertString(m.replaceText)
 
 	// Record for undo
-	m.history.Push(buffer.EditOperation{
+	m.pushEdit(buffer.EditOperation{
 		Type:     buffer.OpDelete,
 		Position: pos,
 		Text:     m.searchQuery,
 	})
-	m.history.Push(buffer.EditOperation{
+	m.pushEdit(buffer.EditOperation{
 		Type:     buffer.OpInsert,
 		Position: pos,
 		Text:     m.replaceText,
@@ -1472,7 +1971,8 @@ func (m *Model) replaceAll() {
 			Text:     content,
 		})
 
-		// Replace buffer content
+		// Replace buffer content. The whole buffer is swapped, so there is
+		// no edit range to shift line-based state by.
 		m.buffer = buffer.NewFromString(newContent)
 
 		m.history.Push(buffer.EditOperation{
@@ -1557,27 +2057,39 @@ func (m *Model) handleReplaceAllConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // handleOpenInput handles input in open file mode.
 func (m *Model) handleOpenInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.handlePathCompletion(msg, "", false) {
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "enter":
 		if m.inputBuffer != "" {
-			content, err := file.Load(m.inputBuffer)
+			path := expandHome(m.inputBuffer)
+			info, err := file.LoadWithInfo(path)
 			if err != nil {
 				m.SetStatusMessage("Error: " + err.Error())
 			} else {
-				m.buffer = buffer.NewFromString(content)
-				m.history = buffer.NewHistory()
-				m.SetFilepath(m.inputBuffer)
-				m.modified = false
+				// A fresh tab drops the folds and other line-based state
+				// of the previous file
+				err := m.ReplaceTabWithFile(path, filepath.Base(path), info.Content, string(info.Encoding), string(info.LineEnding))
 				m.fileChanged = false // Reset external change flag
 				m.UpdateLastSaveTime()
-				m.syncToActiveTab()
-				m.SetStatusMessage("Opened: " + m.filename)
+				if err != nil {
+					m.SetStatusMessage("Error saving bookmarks: " + err.Error())
+				} else {
+					m.SetStatusMessage("Opened: " + m.filename)
+				}
 			}
 		}
 		m.mode = ModeNormal
 		m.inputBuffer = ""
 		return m, nil
 
+	case "ctrl+t":
+		// Nano: browse for the file
+		m.browseForPrompt()
+		return m, nil
+
 	case "esc":
 		m.mode = ModeNormal
 		m.inputBuffer = ""
@@ -1633,6 +2145,38 @@ func (m *Model) handleSaveMacroInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// handleAlignInput handles input in align on delimiter mode.
+func (m *Model) handleAlignInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.mode = ModeNormal
+		if m.inputBuffer != "" {
+			m.alignOnDelimiter(m.inputBuffer)
+		}
+		m.inputBuffer = ""
+		return m, nil
+
+	case "esc":
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		m.SetStatusMessage("")
+		return m, nil
+
+	case "backspace":
+		if len(m.inputBuffer) > 0 {
+			runes := []rune(m.inputBuffer)
+			m.inputBuffer = string(runes[:len(runes)-1])
+		}
+		return m, nil
+
+	default:
+		if len(msg.Runes) > 0 {
+			m.inputBuffer += string(msg.Runes)
+		}
+		return m, nil
+	}
+}
+
 // handleLoadMacroInput handles input in load macro mode.
 func (m *Model) handleLoadMacroInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -1709,7 +2253,7 @@ func (m *Model) cutLine() {
 		m.buffer.DeleteForward()
 	}
 
-	m.history.Push(buffer.EditOperation{
+	m.pushEdit(buffer.EditOperation{
 		Type:     buffer.OpDelete,
 		Position: lineStart,
 		Text:     deletedText,
@@ -1760,7 +2304,7 @@ func (m *Model) deleteWordLeft() {
 		m.buffer.DeleteForward()
 	}
 
-	m.history.Push(buffer.EditOperation{
+	m.pushEdit(buffer.EditOperation{
 		Type:     buffer.OpDelete,
 		Position: endPos,
 		Text:     deletedText,
@@ -1807,7 +2351,7 @@ func (m *Model) deleteWordRight() {
 		m.buffer.DeleteForward()
 	}
 
-	m.history.Push(buffer.EditOperation{
+	m.pushEdit(buffer.EditOperation{
 		Type:     buffer.OpDelete,
 		Position: startPos,
 		Text:     deletedText,
@@ -1837,7 +2381,7 @@ func (m *Model) paste() {
 	pos := m.buffer.CursorPos()
 	m.buffer.InsertString(m.clipboard)
 
-	m.history.Push(buffer.EditOperation{
+	m.pushEdit(buffer.EditOperation{
 		Type:     buffer.OpInsert,
 		Position: pos,
 		Text:     m.clipboard,
@@ -2140,6 +2684,96 @@ func (m *Model) renderLineWithSelection(runes []rune, lineStart, lineEnd, selSta
 	return result.String()
 }
 
+// renderLineWithBrackets renders a line with the matched bracket pair highlighted.
+// When withSyntax is set, the remaining text keeps its syntax colors.
+func (m *Model) renderLineWithBrackets(lineNum int, runes []rune, lineStart, cursorCol int, match bracketMatch, withSyntax bool) string {
+	// Resolve a base style for every rune
+	baseStyles := make([]lipgloss.Style, len(runes))
+	for i := range baseStyles {
+		baseStyles[i] = editorStyle
+	}
+	if withSyntax && m.highlighter != nil {
+		col := 0
+		for _, token := range m.highlighter.HighlightLine(lineNum, m.buffer.Line(lineNum)) {
+			style := getSyntaxStyle(token.Type)
+			for range token.Text {
+				if col < len(baseStyles) {
+					baseStyles[col] = style
+				}
+				col++
+			}
+		}
+	}
+
+	var result strings.Builder
+	for i, r := range runes {
+		if i == cursorCol {
+			result.WriteString(lipgloss.NewStyle().Reverse(true).Render(string(r)))
+		} else if match.contains(lineStart + i) {
+			result.WriteString(bracketStyle.Render(string(r)))
+		} else {
+			result.WriteString(baseStyles[i].Render(string(r)))
+		}
+	}
+
+	// Add cursor at end if needed
+	if cursorCol >= len(runes) && cursorCol >= 0 {
+		result.WriteString("█")
+	}
+
+	return result.String()
+}
+
+// underlineSpan marks rune columns [start, end) of a line to be drawn
+// with style on top of the normal line colors.
+type underlineSpan struct {
+	start, end int
+	style      lipgloss.Style
+}
+
+// lineUnderlines returns the spans of a line to draw underlined: misspelled
+// words and lint diagnostics. line is the text being rendered; nothing is
+// underlined when it differs from the buffer line (e.g. after word wrapping).
+func (m *Model) lineUnderlines(lineNum int, line string) []underlineSpan {
+	if line != m.buffer.Line(lineNum) {
+		return nil
+	}
+	spans := m.misspelledSpans(lineNum, line)
+	return append(spans, m.diagnosticSpans(lineNum, line)...)
+}
+
+// renderLineWithUnderlines renders a line with spans drawn in their style on
+// top of the plain or syntax colors.
+func (m *Model) renderLineWithUnderlines(lineNum int, runes []rune, spans []underlineSpan, withSyntax bool) string {
+	styles := make([]lipgloss.Style, len(runes))
+	for i := range styles {
+		styles[i] = editorStyle
+	}
+	if withSyntax && m.highlighter != nil {
+		col := 0
+		for _, token := range m.highlighter.HighlightLine(lineNum, m.buffer.Line(lineNum)) {
+			style := getSyntaxStyle(token.Type)
+			for range token.Text {
+				if col < len(styles) {
+					styles[col] = style
+				}
+				col++
+			}
+		}
+	}
+	for _, span := range spans {
+		for i := max(span.start, 0); i < span.end && i < len(styles); i++ {
+			styles[i] = span.style.Inherit(styles[i])
+		}
+	}
+
+	var result strings.Builder
+	for i, r := range runes {
+		result.WriteString(styles[i].Render(string(r)))
+	}
+	return result.String()
+}
+
 // selectAll selects all text in the buffer.
 func (m *Model) selectAll() {
 	m.selecting = true
@@ -2195,7 +2829,7 @@ func (m *Model) cutSelection() {
 	}
 
 	// Record for undo
-	m.history.Push(buffer.EditOperation{
+	m.pushEdit(buffer.EditOperation{
 		Type:     buffer.OpDelete,
 		Position: start,
 		Text:     m.clipboard,
@@ -2233,11 +2867,24 @@ func (m *Model) View() string {
 	b.WriteString("\n")
 
 	// Editor area (with split support)
-	if m.IsSplit() {
-		b.WriteString(m.renderSplitEditor())
+	var area string
+	if m.mode == ModePicker && m.picker != nil {
+		area = m.renderPicker()
+	} else if m.mode == ModeReplacePreview && m.projectReplace != nil {
+		area = m.renderReplacePreview()
+	} else if m.mode == ModeFileFinder && m.finder != nil {
+		area = m.renderFileFinder()
+	} else if m.treeVisible() {
+		area = m.renderWithFileTree()
+	} else if m.IsSplit() {
+		area = m.renderSplitEditor()
 	} else {
-		b.WriteString(m.renderEditor())
+		area = m.renderEditor()
 	}
+	if m.pathComplete != nil {
+		area = m.overlayPathCandidates(area)
+	}
+	b.WriteString(area)
 
 	// Status bar
 	b.WriteString(m.renderStatusBar())
@@ -2424,12 +3071,14 @@ func (m *Model) renderPaneLines(pane *Pane, height int) []string {
 	}
 	tab := m.tabs.Tabs()[pane.tabIndex]
 
-	// Use pane's viewport
-	topLine := pane.viewportTopLine
+	// Use pane's viewport, skipping lines hidden by folds
+	ranges := mergeFolds(tab.folds)
+	topRow := visibleRow(ranges, visibleLine(ranges, pane.viewportTopLine))
 	lineCount := tab.buffer.LineCount()
 
 	for i := 0; i < height; i++ {
-		lineNum := topLine + i
+		lineNum := lineAtRow(ranges, topRow+i)
+		_, folded := tab.folds[lineNum]
 		if lineNum >= lineCount {
 			// Empty line after file content
 			lines = append(lines, "")
@@ -2455,6 +3104,9 @@ func (m *Model) renderPaneLines(pane *Pane, height int) []string {
 		// Line content
 		lineContent := tab.buffer.Line(lineNum)
 		lineBuilder.WriteString(lineContent)
+		if folded {
+			lineBuilder.WriteString(gutterMarkerStyle.Render(fmt.Sprintf(" ⋯ %d lines", tab.folds[lineNum]-lineNum)))
+		}
 
 		lines = append(lines, lineBuilder.String())
 	}
@@ -2493,6 +3145,14 @@ func (m *Model) renderEditor() string {
 	cursorLine := m.buffer.CurrentLine()
 	cursorCol := m.buffer.CurrentColumn()
 
+	// Folded lines are skipped, so the viewport is laid out in visible rows
+	folds := m.activeFolds()
+	ranges := m.hiddenRanges()
+	bookmarks := m.tabBookmarks(m.tabs.ActiveTab())
+	cursorRow := visibleRow(ranges, cursorLine)
+	rowCount := visibleRow(ranges, lineCount-1) + 1
+	topRow := visibleRow(ranges, visibleLine(ranges, m.viewportTopLine))
+
 	// Adjust viewport to keep cursor visible with scroll padding
 	// But NOT when user is scrolling with mouse - let them scroll freely
 	if !m.mouseScrolling {
@@ -2501,26 +3161,32 @@ func (m *Model) renderEditor() string {
 			scrollPadding = visibleLines / 3
 		}
 
-		if cursorLine < m.viewportTopLine+scrollPadding {
-			m.viewportTopLine = cursorLine - scrollPadding
-			if m.viewportTopLine < 0 {
-				m.viewportTopLine = 0
+		if cursorRow < topRow+scrollPadding {
+			topRow = cursorRow - scrollPadding
+			if topRow < 0 {
+				topRow = 0
 			}
+			m.viewportTopLine = lineAtRow(ranges, topRow)
 		}
-		if cursorLine >= m.viewportTopLine+visibleLines-scrollPadding {
-			m.viewportTopLine = cursorLine - visibleLines + scrollPadding + 1
-			maxTopLine := lineCount - visibleLines
-			if maxTopLine < 0 {
-				maxTopLine = 0
+		if cursorRow >= topRow+visibleLines-scrollPadding {
+			topRow = cursorRow - visibleLines + scrollPadding + 1
+			maxTopRow := rowCount - visibleLines
+			if maxTopRow < 0 {
+				maxTopRow = 0
 			}
-			if m.viewportTopLine > maxTopLine {
-				m.viewportTopLine = maxTopLine
+			if topRow > maxTopRow {
+				topRow = maxTopRow
 			}
+			m.viewportTopLine = lineAtRow(ranges, topRow)
 		}
 	}
 
+	// Bracket under the cursor and its partner
+	match, hasMatch := m.findBracketMatch()
+
 	for i := 0; i < visibleLines; i++ {
-		lineNum := m.viewportTopLine + i
+		lineNum := lineAtRow(ranges, topRow+i)
+		_, folded := folds[lineNum]
 
 		if lineNum < lineCount {
 			// Line number with current line marker (if enabled)
@@ -2540,7 +3206,15 @@ func (m *Model) renderEditor() string {
 					lineNumStr = lineNumberStyle.Render(fmt.Sprintf(" %*d", numWidth, lineNum+1))
 				}
 				b.WriteString(lineNumStr)
-				b.WriteString(" │ ")
+				if folded {
+					b.WriteString(" " + gutterMarkerStyle.Render("▸") + " ")
+				} else if d, ok := m.lineDiagnostic(lineNum); ok {
+					b.WriteString(" " + diagnosticStyle(d.Severity).Render("●") + " ")
+				} else if isBookmarked(bookmarks, lineNum) {
+					b.WriteString(" " + gutterMarkerStyle.Render("◆") + " ")
+				} else {
+					b.WriteString(" │ ")
+				}
 			}
 
 			// Line content
@@ -2572,12 +3246,19 @@ func (m *Model) renderEditor() string {
 				hasSelection = selStart != selEnd
 			}
 
+			// Bracket match highlighting (not combined with selection)
+			hasBracket := hasMatch && !hasSelection &&
+				((match.start >= lineStart && match.start <= lineEnd) ||
+					(match.partnerStart >= lineStart && match.partnerStart <= lineEnd))
+
 			// Render line with selection and cursor
 			runes := []rune(lineContent)
 			if lineNum == cursorLine {
 				// Cursor line - render with cursor
 				if hasSelection {
 					b.WriteString(m.renderLineWithSelection(runes, lineStart, lineEnd, selStart, selEnd, cursorCol))
+				} else if hasBracket {
+					b.WriteString(m.renderLineWithBrackets(lineNum, runes, lineStart, cursorCol, match, false))
 				} else if cursorCol >= len(runes) {
 					b.WriteString(editorStyle.Render(lineContent))
 					b.WriteString("█")
@@ -2592,15 +3273,26 @@ func (m *Model) renderEditor() string {
 			} else if hasSelection && lineEnd > selStart && lineStart < selEnd {
 				// Line has selection
 				b.WriteString(m.renderLineWithSelection(runes, lineStart, lineEnd, selStart, selEnd, -1))
+			} else if hasBracket {
+				// Line holds the partner of the bracket under the cursor
+				b.WriteString(m.renderLineWithBrackets(lineNum, runes, lineStart, -1, match, m.syntaxHighlighting))
 			} else if m.searchQuery != "" && strings.Contains(lineContent, m.searchQuery) {
 				// Line has search matches
 				b.WriteString(m.renderLineWithSearchMatches(lineContent, m.searchQuery))
+			} else if spans := m.lineUnderlines(lineNum, lineContent); len(spans) > 0 {
+				// Line has misspelled words
+				b.WriteString(m.renderLineWithUnderlines(lineNum, runes, spans, m.syntaxHighlighting))
 			} else if m.syntaxHighlighting {
 				// Syntax highlighting with cache
 				b.WriteString(m.renderLineWithSyntax(lineNum, lineContent))
 			} else {
 				b.WriteString(editorStyle.Render(lineContent))
 			}
+
+			// Folded region indicator
+			if folded {
+				b.WriteString(gutterMarkerStyle.Render(fmt.Sprintf(" ⋯ %d lines", folds[lineNum]-lineNum)))
+			}
 		} else {
 			// Empty line indicator (after end of file)
 			if m.showLineNumbers {
@@ -2619,6 +3311,19 @@ func (m *Model) renderEditor() string {
 		b.WriteString("\n")
 	}
 
+	// Word completion popup below (or above) the cursor line
+	if m.completion != nil {
+		col := ansi.StringWidth(m.buffer.Slice(m.buffer.LineStart(cursorLine), m.completion.start))
+		if m.showLineNumbers {
+			numWidth := len(fmt.Sprintf("%d", lineCount))
+			if numWidth < 3 {
+				numWidth = 3
+			}
+			col += numWidth + 4 // "→123 │ "
+		}
+		return m.overlayCompletion(b.String(), cursorRow-topRow, col)
+	}
+
 	return b.String()
 }
 
@@ -2652,6 +3357,9 @@ func (m *Model) renderStatusBar() string {
 	if m.overwriteMode {
 		rightInfo = "OVR"
 	}
+	if m.vi != nil {
+		rightInfo = m.vi.status()
+	}
 	if m.macro != nil && m.macro.IsRecording() {
 		rightInfo = "REC"
 	}
@@ -2677,15 +3385,68 @@ func (m *Model) renderHelpBar() string {
 		return helpStyle.Width(m.width).Render(content) + "\n" +
 			helpStyle.Width(m.width).Render("")
 
-	case ModeSaveAs, ModeGoto, ModeSearch, ModeReplace, ModeReplaceConfirm, ModeReplaceAll, ModeReplaceAllConfirm, ModeOpen, ModeSaveMacro, ModeLoadMacro:
+	case ModeSaveAs, ModeGoto, ModeSearch, ModeReplace, ModeReplaceConfirm, ModeReplaceAll, ModeReplaceAllConfirm, ModeOpen, ModeSaveMacro, ModeLoadMacro, ModeAlign, ModeRename, ModeProjectReplaceWith, ModeTreeCreate, ModeTreeRename, ModeViCommand:
 		// Show input prompt
 		prompt := " " + m.inputPrompt + m.inputBuffer + "█"
 		return helpStyle.Width(m.width).Render(prompt) + "\n" +
 			helpStyle.Width(m.width).Render("")
 
+	case ModeProjectSearch, ModeProjectReplace:
+		prompt := " " + m.inputPrompt + m.inputBuffer + "█"
+		return helpStyle.Width(m.width).Render(prompt) + "\n" +
+			helpStyle.Width(m.width).Render(" [Enter] Search  [M-R] Regexp on/off  [Esc] Cancel")
+
+	case ModeExecute:
+		// Show command prompt with output mode hint
+		prompt := " " + m.inputPrompt + m.inputBuffer + "█"
+		return helpStyle.Width(m.width).Render(prompt) + "\n" +
+			helpStyle.Width(m.width).Render(" [Enter] Run  [^R] Replace/Insert output  [Esc] Cancel")
+
+	case ModeFileTree:
+		if m.tree != nil && m.tree.prompt != ModeNormal {
+			return helpStyle.Width(m.width).Render(" "+m.tree.promptText+"(browsing)") + "\n" +
+				helpStyle.Width(m.width).Render(" [Enter] Choose file/open dir  [←/→] Collapse/expand  [Esc] Back to prompt")
+		}
+		return helpStyle.Width(m.width).Render(" [Enter] Open  [←/→] Collapse/expand  [A] New  [R] Rename  [D] Delete") + "\n" +
+			helpStyle.Width(m.width).Render(" [</>] Resize  [Esc] Editor  [F9] Hide")
+
+	case ModeTreeDelete:
+		return helpStyle.Width(m.width).Render(" [Y] Delete  [N] Cancel") + "\n" +
+			helpStyle.Width(m.width).Render("")
+
+	case ModeReplacePreview:
+		return helpStyle.Width(m.width).Render(" [Space] Include/exclude  [A] All") + "\n" +
+			helpStyle.Width(m.width).Render(" [Enter] Replace  [↑/↓] Move  [Esc] Cancel")
+
+	case ModeFileFinder:
+		query := ""
+		if m.finder != nil {
+			query = m.finder.query
+		}
+		prompt := " Find file: " + query + "█"
+		return helpStyle.Width(m.width).Render(prompt) + "\n" +
+			helpStyle.Width(m.width).Render(" [Enter] Open in new tab  [M-Enter] Open here  [↑/↓] Move  [Esc] Cancel")
+
+	case ModePicker:
+		// Show picker filter
+		query := ""
+		if m.picker != nil {
+			query = m.picker.query
+		}
+		prompt := " Filter: " + query + "█"
+		return helpStyle.Width(m.width).Render(prompt) + "\n" +
+			helpStyle.Width(m.width).Render(" [Enter] Select  [↑/↓] Move  [Esc] Cancel")
+
 	default:
+		if m.vi != nil && m.vi.mode != viInsert {
+			line1 := " i Insert  v/V Visual  :w Save  :q Quit  u Undo  ^R Redo  / Search  F1 Palette"
+			line2 := " hjkl Move  w/b/e Word  0/$ Line  gg/G File  d/c/y Operators  p Put  . Repeat"
+			return helpStyle.Width(m.width).Render(line1) + "\n" +
+				helpStyle.Width(m.width).Render(line2)
+		}
+
 		// Nano style help - always visible, two lines
-		line1 := "^G Help  ^O Save  ^W Search  ^K Cut  M-6 Copy  ^C Pos  ^X Exit"
+		line1 := "^G Help  ^O Save  ^W Search  ^K Cut  M-6 Copy  ^C Pos  ^X Exit  F1 Palette"
 		line2 := "^R Read  ^\\ Replace  ^U Paste  M-U Undo  M-E Redo  ^Y/^V Pg  M-G Goto"
 
 		return helpStyle.Width(m.width).Render(line1) + "\n" +
@@ -0,0 +1,211 @@
+// Package app provides auto-pairing of brackets and quotes.
+package app
+
+import (
+	"unicode"
+
+	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+// autoCloseState tracks the closing characters inserted by auto-pairing, so
+// that typing over a closer only happens for those and not for closers the
+// user typed.
+type autoCloseState struct {
+	buffer  *buffer.GapBuffer
+	version int   // buffer version the positions refer to
+	closers []int // rune offsets of the inserted closers
+}
+
+// autoClosers returns the tracked closers. They are dropped once the buffer
+// changed in a way they were not adjusted for.
+func (m *Model) autoClosers() []int {
+	s := &m.autoClosed
+	if s.buffer != m.buffer || s.version != m.buffer.Version() {
+		*s = autoCloseState{}
+	}
+	return s.closers
+}
+
+// setAutoClosers records the tracked closers for the current buffer version.
+func (m *Model) setAutoClosers(closers []int) {
+	m.autoClosed = autoCloseState{
+		buffer:  m.buffer,
+		version: m.buffer.Version(),
+		closers: closers,
+	}
+}
+
+// shiftAutoClosers moves the tracked closers after n runes were typed at pos.
+func (m *Model) shiftAutoClosers(pos, n int) {
+	s := &m.autoClosed
+	if s.buffer != m.buffer || s.version+n != m.buffer.Version() {
+		*s = autoCloseState{}
+		return
+	}
+	for i, c := range s.closers {
+		if c >= pos {
+			s.closers[i] += n
+		}
+	}
+	s.version = m.buffer.Version()
+}
+
+// activeAutoPairs returns the auto-close pairs for the current language,
+// or nil when auto-pairing is disabled.
+func (m *Model) activeAutoPairs() []syntax.BracketPair {
+	if !m.autoPairs {
+		return nil
+	}
+	if m.highlighter == nil {
+		m.updateHighlighter()
+	}
+	if m.highlighter != nil {
+		return m.highlighter.Language().AutoClosePairs()
+	}
+	return syntax.DefaultAutoPairs
+}
+
+// findAutoPair returns the pair whose opener (or closer) is r.
+func findAutoPair(pairs []syntax.BracketPair, r rune, opener bool) (syntax.BracketPair, bool) {
+	for _, pair := range pairs {
+		s := pair.Close
+		if opener {
+			s = pair.Open
+		}
+		if s == string(r) {
+			return pair, true
+		}
+	}
+	return syntax.BracketPair{}, false
+}
+
+// insertAutoPair handles typing a single rune with auto-pairing.
+// Returns true if the rune was fully handled (wrap, type-over or auto-close).
+// Only closers inserted by auto-pairing are typed over.
+func (m *Model) insertAutoPair(r rune) bool {
+	pairs := m.activeAutoPairs()
+	if len(pairs) == 0 {
+		return false
+	}
+
+	pos := m.buffer.CursorPos()
+	next := m.buffer.RuneAt(pos)
+
+	// Wrap the current selection with the typed pair
+	if pair, ok := findAutoPair(pairs, r, true); ok && m.selecting && m.selectionStart != m.selectionEnd {
+		m.wrapSelection(pair)
+		return true
+	}
+
+	// Type over a closing character inserted by auto-pairing
+	if _, ok := findAutoPair(pairs, r, false); ok && next == r {
+		closers := m.autoClosers()
+		for i, c := range closers {
+			if c == pos {
+				m.setAutoClosers(append(closers[:i], closers[i+1:]...))
+				m.buffer.MoveRight()
+				return true
+			}
+		}
+	}
+
+	pair, ok := findAutoPair(pairs, r, true)
+	if !ok || !m.shouldAutoClose(pair, pos) {
+		return false
+	}
+
+	text := pair.Open + pair.Close
+	closers := m.autoClosers()
+	m.buffer.InsertString(text)
+	m.buffer.MoveLeft()
+	m.pushEdit(buffer.EditOperation{
+		Type:     buffer.OpInsert,
+		Position: pos,
+		Text:     text,
+	})
+	m.setModified()
+
+	n := len([]rune(text))
+	for i, c := range closers {
+		if c >= pos {
+			closers[i] += n
+		}
+	}
+	m.setAutoClosers(append(closers, pos+len([]rune(pair.Open))))
+	return true
+}
+
+// shouldAutoClose decides whether typing the opener at pos inserts its closer.
+// Closers are only added before whitespace, closing characters or the end of
+// line, and quotes are not paired directly after a word (e.g. "don't").
+func (m *Model) shouldAutoClose(pair syntax.BracketPair, pos int) bool {
+	next := m.buffer.RuneAt(pos)
+	if next != 0 && !unicode.IsSpace(next) {
+		if _, ok := findAutoPair(m.activeAutoPairs(), next, false); !ok {
+			return false
+		}
+	}
+	if pair.Open == pair.Close && pos > 0 {
+		prev := m.buffer.RuneAt(pos - 1)
+		if isWordRune(prev) || string(prev) == pair.Open {
+			return false
+		}
+	}
+	return true
+}
+
+// wrapSelection surrounds the selected text with the pair as a single undo step.
+// The selection is kept on the wrapped text.
+func (m *Model) wrapSelection(pair syntax.BracketPair) {
+	start, end := m.getSelectionBounds()
+	openLen := len([]rune(pair.Open))
+
+	m.history.BeginGroup()
+	defer m.history.EndGroup()
+
+	m.buffer.MoveTo(end)
+	m.buffer.InsertString(pair.Close)
+	m.pushEdit(buffer.EditOperation{
+		Type:     buffer.OpInsert,
+		Position: end,
+		Text:     pair.Close,
+	})
+
+	m.buffer.MoveTo(start)
+	m.buffer.InsertString(pair.Open)
+	m.pushEdit(buffer.EditOperation{
+		Type:     buffer.OpInsert,
+		Position: start,
+		Text:     pair.Open,
+	})
+
+	m.selectionStart = start + openLen
+	m.selectionEnd = end + openLen
+	m.buffer.MoveTo(m.selectionEnd)
+	m.setModified()
+}
+
+// deleteAutoPair deletes both characters when backspacing inside an empty pair.
+// Returns true if a pair was deleted.
+func (m *Model) deleteAutoPair() bool {
+	pos := m.buffer.CursorPos()
+	if pos == 0 {
+		return false
+	}
+	pair, ok := findAutoPair(m.activeAutoPairs(), m.buffer.RuneAt(pos-1), true)
+	if !ok || m.buffer.RuneAt(pos) != []rune(pair.Close)[0] {
+		return false
+	}
+
+	text := m.buffer.Slice(pos-1, pos+1)
+	m.buffer.DeleteForward()
+	m.buffer.Delete()
+	m.pushEdit(buffer.EditOperation{
+		Type:     buffer.OpDelete,
+		Position: pos - 1,
+		Text:     text,
+	})
+	m.setModified()
+	return true
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeText sends each rune of s to the model as a key press.
+func typeText(m *Model, s string) {
+	for _, r := range s {
+		m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestAutoPairInsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		typed    string
+		want     string
+		cursor   int
+	}{
+		{"paren", "a.go", "(", "()", 1},
+		{"type over", "a.go", "(x)", "(x)", 3},
+		{"nested", "a.go", "{[", "{[]}", 2},
+		{"quote", "a.go", `"`, `""`, 1},
+		{"apostrophe in word", "a.txt", "don't", "don't", 5},
+		{"backtick in go", "a.go", "`", "``", 1},
+		{"no quote pair in rust", "a.rs", "'", "'", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewFromFile(tt.filename, tt.filename, "")
+			m.SetAutoPairs(true)
+			typeText(m, tt.typed)
+
+			if got := m.buffer.String(); got != tt.want {
+				t.Errorf("buffer = %q, want %q", got, tt.want)
+			}
+			if got := m.buffer.CursorPos(); got != tt.cursor {
+				t.Errorf("cursor = %d, want %d", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestAutoPairTypeOverOnlyInserted(t *testing.T) {
+	// A closer that was already in the file is not typed over
+	m := NewWithContent("f(a)")
+	m.SetAutoPairs(true)
+	m.buffer.MoveTo(3)
+	typeText(m, ")")
+	if got := m.buffer.String(); got != "f(a))" {
+		t.Errorf("buffer = %q, want %q", got, "f(a))")
+	}
+
+	// Closers inserted by auto-pairing are typed over, even nested and
+	// after typing inside them
+	m = NewWithContent("")
+	m.SetAutoPairs(true)
+	typeText(m, "f([x]")
+	typeText(m, ")")
+	if got := m.buffer.String(); got != "f([x])" || m.buffer.CursorPos() != 6 {
+		t.Errorf("buffer = %q, cursor %d, want %q, 6", got, m.buffer.CursorPos(), "f([x])")
+	}
+
+	// Other edits stop the tracking
+	m = NewWithContent("")
+	m.SetAutoPairs(true)
+	typeText(m, "(")
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlK})
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlU})
+	m.buffer.MoveTo(1)
+	typeText(m, ")")
+	if got := m.buffer.String(); got != "())" {
+		t.Errorf("buffer = %q, want %q", got, "())")
+	}
+}
+
+func TestAutoPairNotBeforeWord(t *testing.T) {
+	m := NewWithContent("foo")
+	m.SetAutoPairs(true)
+	typeText(m, "(")
+
+	if got := m.buffer.String(); got != "(foo" {
+		t.Errorf("buffer = %q, want %q", got, "(foo")
+	}
+}
+
+func TestAutoPairBackspace(t *testing.T) {
+	m := NewWithContent("")
+	m.SetAutoPairs(true)
+	typeText(m, "[")
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyBackspace})
+
+	if got := m.buffer.String(); got != "" {
+		t.Errorf("buffer = %q, want empty", got)
+	}
+}
+
+func TestAutoPairWrapSelection(t *testing.T) {
+	m := NewWithContent("a word here")
+	m.SetAutoPairs(true)
+	m.selecting = true
+	m.selectionStart = 2
+	m.selectionEnd = 6
+	m.buffer.MoveTo(6)
+
+	typeText(m, "(")
+	if got := m.buffer.String(); got != "a (word) here" {
+		t.Fatalf("buffer = %q, want %q", got, "a (word) here")
+	}
+	if start, end := m.getSelectionBounds(); start != 3 || end != 7 {
+		t.Errorf("selection = [%d, %d), want [3, 7)", start, end)
+	}
+
+	m.undo()
+	if got := m.buffer.String(); got != "a word here" {
+		t.Errorf("after undo buffer = %q, want %q", got, "a word here")
+	}
+}
+
+func TestAutoPairDisabled(t *testing.T) {
+	m := NewWithContent("")
+	typeText(m, "(")
+
+	if got := m.buffer.String(); got != "(" {
+		t.Errorf("buffer = %q, want %q", got, "(")
+	}
+}
@@ -0,0 +1,257 @@
+// Package app provides per-tab bookmarks with persistence.
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/KilimcininKorOglu/gesh/internal/config"
+	"github.com/KilimcininKorOglu/gesh/internal/file"
+)
+
+// BookmarkFile represents the bookmarks file format.
+type BookmarkFile struct {
+	Version string           `json:"version"`
+	Files   map[string][]int `json:"files"` // absolute path -> 1-based lines
+}
+
+// getBookmarksFilePath returns the bookmarks file path.
+func getBookmarksFilePath() string {
+	return filepath.Join(config.GetStateDir(), "bookmarks.json")
+}
+
+// readBookmarkFile loads the bookmarks file, returning an empty one if missing.
+func readBookmarkFile() (*BookmarkFile, error) {
+	bookmarkFile := &BookmarkFile{
+		Version: "1.0",
+		Files:   make(map[string][]int),
+	}
+	data, err := os.ReadFile(getBookmarksFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return bookmarkFile, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, bookmarkFile); err != nil {
+		return nil, fmt.Errorf("corrupt bookmarks file: %w", err)
+	}
+	if bookmarkFile.Files == nil {
+		bookmarkFile.Files = make(map[string][]int)
+	}
+	return bookmarkFile, nil
+}
+
+// bookmarkKey returns the key under which bookmarks of path are stored.
+func bookmarkKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// tabBookmarks returns the bookmarks of a tab, loading them on first use.
+func (m *Model) tabBookmarks(tab *Tab) []int {
+	if tab.bookmarksLoaded {
+		return tab.bookmarks
+	}
+	tab.bookmarksLoaded = true
+	if tab.filepath == "" {
+		return nil
+	}
+
+	bookmarkFile, err := readBookmarkFile()
+	if err != nil {
+		return nil
+	}
+	lineCount := tab.buffer.LineCount()
+	for _, line := range bookmarkFile.Files[bookmarkKey(tab.filepath)] {
+		if line >= 1 && line <= lineCount {
+			tab.bookmarks = append(tab.bookmarks, line-1)
+		}
+	}
+	sort.Ints(tab.bookmarks)
+	return tab.bookmarks
+}
+
+// saveBookmarks writes the bookmarks of a tab to the state directory. The
+// line numbers must match the file on disk, so this is done when the file is
+// saved or, for unmodified files, when the tab is closed.
+func saveBookmarks(tab *Tab) error {
+	if tab.filepath == "" || !tab.bookmarksLoaded {
+		return nil
+	}
+
+	bookmarkFile, err := readBookmarkFile()
+	if err != nil {
+		return err
+	}
+
+	key := bookmarkKey(tab.filepath)
+	tab.bookmarksChanged = false
+	if len(tab.bookmarks) == 0 {
+		if _, ok := bookmarkFile.Files[key]; !ok {
+			return nil
+		}
+		delete(bookmarkFile.Files, key)
+	} else {
+		lines := make([]int, len(tab.bookmarks))
+		for i, line := range tab.bookmarks {
+			lines[i] = line + 1
+		}
+		bookmarkFile.Files[key] = lines
+	}
+
+	if err := os.MkdirAll(config.GetStateDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bookmarkFile, "", "  ")
+	if err != nil {
+		return err
+	}
+	return file.WriteAtomic(getBookmarksFilePath(), data)
+}
+
+// saveChangedBookmarks writes bookmarks toggled since the last save of an
+// unmodified tab. Bookmarks of modified tabs refer to unsaved lines and are
+// only written when the file is saved.
+func saveChangedBookmarks(tab *Tab) error {
+	if !tab.bookmarksChanged || tab.modified {
+		return nil
+	}
+	return saveBookmarks(tab)
+}
+
+// SaveBookmarks writes the changed bookmarks of all unmodified tabs. It is
+// called when the editor exits.
+func (m *Model) SaveBookmarks() error {
+	m.syncToActiveTab()
+	for _, tab := range m.tabs.Tabs() {
+		if err := saveChangedBookmarks(tab); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isBookmarked reports whether line is in the sorted bookmarks list.
+func isBookmarked(bookmarks []int, line int) bool {
+	i := sort.SearchInts(bookmarks, line)
+	return i < len(bookmarks) && bookmarks[i] == line
+}
+
+// toggleBookmark sets or removes a bookmark on the cursor line.
+func (m *Model) toggleBookmark() {
+	tab := m.tabs.ActiveTab()
+	if tab == nil {
+		return
+	}
+	bookmarks := m.tabBookmarks(tab)
+	line := m.buffer.CurrentLine()
+
+	i := sort.SearchInts(bookmarks, line)
+	if i < len(bookmarks) && bookmarks[i] == line {
+		tab.bookmarks = append(bookmarks[:i], bookmarks[i+1:]...)
+		m.SetStatusMessage(fmt.Sprintf("Bookmark removed from line %d", line+1))
+	} else {
+		tab.bookmarks = append(bookmarks, 0)
+		copy(tab.bookmarks[i+1:], tab.bookmarks[i:])
+		tab.bookmarks[i] = line
+		m.SetStatusMessage(fmt.Sprintf("Bookmark set on line %d", line+1))
+	}
+	tab.bookmarksChanged = true
+}
+
+// nextBookmark moves the cursor to the next bookmark, wrapping around.
+func (m *Model) nextBookmark() {
+	m.jumpToBookmark(1)
+}
+
+// prevBookmark moves the cursor to the previous bookmark, wrapping around.
+func (m *Model) prevBookmark() {
+	m.jumpToBookmark(-1)
+}
+
+// jumpToBookmark moves to the nearest bookmark in direction dir (1 or -1).
+func (m *Model) jumpToBookmark(dir int) {
+	tab := m.tabs.ActiveTab()
+	if tab == nil {
+		return
+	}
+	bookmarks := m.tabBookmarks(tab)
+	if len(bookmarks) == 0 {
+		m.SetStatusMessage("No bookmarks")
+		return
+	}
+
+	line := m.buffer.CurrentLine()
+	var target int
+	if dir > 0 {
+		i := sort.SearchInts(bookmarks, line+1)
+		if i == len(bookmarks) {
+			i = 0
+		}
+		target = bookmarks[i]
+	} else {
+		i := sort.SearchInts(bookmarks, line) - 1
+		if i < 0 {
+			i = len(bookmarks) - 1
+		}
+		target = bookmarks[i]
+	}
+
+	m.clearSelection()
+	m.revealLine(target)
+	m.moveToLineColumn(target, 0)
+	m.SetStatusMessage(fmt.Sprintf("Bookmark at line %d", target+1))
+}
+
+// listBookmarks shows a picker with the bookmarks of all tabs.
+func (m *Model) listBookmarks() {
+	var items []pickerItem
+	for i, tab := range m.tabs.Tabs() {
+		tabIndex := i
+		for _, line := range m.tabBookmarks(tab) {
+			target := line
+			items = append(items, pickerItem{
+				label:  fmt.Sprintf("%s:%d", tab.filename, line+1),
+				detail: strings.TrimSpace(tab.buffer.Line(line)),
+				onSelect: func() {
+					m.SelectTab(tabIndex)
+					m.clearSelection()
+					m.revealLine(target)
+					m.moveToLineColumn(target, 0)
+				},
+			})
+		}
+	}
+
+	if len(items) == 0 {
+		m.SetStatusMessage("No bookmarks")
+		return
+	}
+	m.openPicker("Bookmarks", items)
+}
+
+// shiftBookmarks updates bookmarked lines after lines editStart..editEnd
+// changed and the line count changed by delta. Bookmarks inside deleted lines
+// move to the start of the edit.
+func shiftBookmarks(bookmarks []int, editStart, editEnd, delta int) []int {
+	shifted := make([]int, 0, len(bookmarks))
+	for _, line := range bookmarks {
+		switch {
+		case line > editEnd:
+			line += delta
+		case line > editStart:
+			line = editStart
+		}
+		if len(shifted) == 0 || shifted[len(shifted)-1] != line {
+			shifted = append(shifted, line)
+		}
+	}
+	return shifted
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBookmarkNavigation(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m := NewWithContent("a\nb\nc\nd\ne")
+
+	m.moveToLineColumn(1, 0)
+	m.toggleBookmark()
+	m.moveToLineColumn(3, 0)
+	m.toggleBookmark()
+
+	m.nextBookmark()
+	if got := m.buffer.CurrentLine(); got != 1 {
+		t.Errorf("next bookmark wrapped to line %d, want 1", got)
+	}
+	m.nextBookmark()
+	if got := m.buffer.CurrentLine(); got != 3 {
+		t.Errorf("next bookmark = line %d, want 3", got)
+	}
+	m.prevBookmark()
+	if got := m.buffer.CurrentLine(); got != 1 {
+		t.Errorf("previous bookmark = line %d, want 1", got)
+	}
+
+	m.toggleBookmark()
+	if got := m.tabs.ActiveTab().bookmarks; !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("bookmarks = %v, want [3]", got)
+	}
+}
+
+func TestBookmarksTrackEdits(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m := NewWithContent("a\nb\nc")
+	m.moveToLineColumn(2, 0)
+	m.toggleBookmark()
+
+	m.moveToLineColumn(0, 1)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.tabs.ActiveTab().bookmarks; !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("after insert bookmarks = %v, want [3]", got)
+	}
+
+	m.undo()
+	if got := m.tabs.ActiveTab().bookmarks; !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("after undo bookmarks = %v, want [2]", got)
+	}
+
+	// Enter at the start of the bookmarked line moves the bookmark with it
+	m.moveToLineColumn(2, 0)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.tabs.ActiveTab().bookmarks; !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("after enter at column 0 bookmarks = %v, want [3]", got)
+	}
+	m.undo()
+	m.redo()
+	if got := m.tabs.ActiveTab().bookmarks; !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("after undo and redo bookmarks = %v, want [3]", got)
+	}
+
+	// Deleting the lines above pulls it up
+	m.moveToLineColumn(0, 0)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlK})
+	if got := m.tabs.ActiveTab().bookmarks; !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("after cutting a line bookmarks = %v, want [2]", got)
+	}
+}
+
+func TestBookmarksPersist(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reload := func() []int {
+		data, _ := os.ReadFile(path)
+		reopened := NewFromFile(path, "notes.txt", string(data))
+		return reopened.tabBookmarks(reopened.tabs.ActiveTab())
+	}
+
+	// Bookmarks are written when the editor exits, not on every toggle
+	m := NewFromFile(path, "notes.txt", "one\ntwo\nthree")
+	m.moveToLineColumn(2, 0)
+	m.toggleBookmark()
+	if got := reload(); len(got) != 0 {
+		t.Errorf("bookmarks written on toggle: %v", got)
+	}
+	if err := m.SaveBookmarks(); err != nil {
+		t.Fatalf("SaveBookmarks() error = %v", err)
+	}
+	if got := reload(); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("reloaded bookmarks = %v, want [2]", got)
+	}
+
+	// Bookmarks of unsaved edits are only written with the file
+	m.moveToLineColumn(0, 0)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if err := m.SaveBookmarks(); err != nil {
+		t.Fatalf("SaveBookmarks() error = %v", err)
+	}
+	if got := reload(); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("bookmarks of a modified file = %v, want [2]", got)
+	}
+	m.saveFile()
+	if got := reload(); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("bookmarks after saving = %v, want [3]", got)
+	}
+}
+
+func TestShiftBookmarks(t *testing.T) {
+	tests := []struct {
+		name               string
+		bookmarks          []int
+		editStart, editEnd int
+		delta              int
+		want               []int
+	}{
+		{"insert above", []int{1, 5}, 3, 3, 2, []int{1, 7}},
+		{"delete range", []int{1, 4, 5, 9}, 3, 6, -3, []int{1, 3, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shiftBookmarks(tt.bookmarks, tt.editStart, tt.editEnd, tt.delta)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shiftBookmarks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListBookmarksPicker(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m := NewWithContent("first\nsecond")
+	m.moveToLineColumn(1, 0)
+	m.toggleBookmark()
+	m.moveToLineColumn(0, 0)
+
+	m.listBookmarks()
+	if m.mode != ModePicker {
+		t.Fatalf("mode = %v, want ModePicker", m.mode)
+	}
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.mode != ModeNormal {
+		t.Errorf("mode = %v after selecting, want ModeNormal", m.mode)
+	}
+	if got := m.buffer.CurrentLine(); got != 1 {
+		t.Errorf("cursor line = %d, want 1", got)
+	}
+}
+
+func TestBookmarksOpenFileInTab(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("one\ntwo\nthree"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewFromFile(first, "first.txt", "one\ntwo\nthree")
+	m.moveToLineColumn(2, 0)
+	m.toggleBookmark()
+
+	// Opening another file into the tab saves the bookmarks of the first
+	// and does not carry them over
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	typeText(m, second)
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.tabBookmarks(m.tabs.ActiveTab()); len(got) != 0 {
+		t.Errorf("bookmarks of the opened file = %v, want none", got)
+	}
+	reopened := NewFromFile(first, "first.txt", "one\ntwo\nthree")
+	if got := reopened.tabBookmarks(reopened.tabs.ActiveTab()); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("saved bookmarks of the replaced file = %v, want [2]", got)
+	}
+}
@@ -0,0 +1,511 @@
+// Package app provides bracket matching for the editor.
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+// bracketPairs maps every single-character bracket to its partner.
+var bracketPairs = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+}
+
+// isOpenBracket returns true for opening single-character brackets.
+func isOpenBracket(r rune) bool {
+	return r == '(' || r == '[' || r == '{'
+}
+
+// tagPattern matches opening, closing and self-closing markup tags.
+var tagPattern = regexp.MustCompile(`<(/?)([A-Za-z][\w:.-]*)(?:[^<>"']|"[^"]*"|'[^']*')*?(/?)>`)
+
+// voidTags are HTML elements that never have a closing tag.
+var voidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// bracketWindowLines is how many lines above and below the cursor the
+// highlight of a matching delimiter scans for its partner.
+const bracketWindowLines = 1000
+
+// wordPairPatterns caches the compiled keyword pair patterns of each language.
+var wordPairPatterns = struct {
+	sync.Mutex
+	byLang map[*syntax.Language][]*regexp.Regexp
+}{byLang: make(map[*syntax.Language][]*regexp.Regexp)}
+
+// wordPairRegexps returns the patterns matching either word of each of the
+// language's word pairs, compiling them on first use.
+func wordPairRegexps(lang *syntax.Language) []*regexp.Regexp {
+	wordPairPatterns.Lock()
+	defer wordPairPatterns.Unlock()
+	if patterns, ok := wordPairPatterns.byLang[lang]; ok {
+		return patterns
+	}
+	patterns := make([]*regexp.Regexp, len(lang.WordPairs))
+	for i, pair := range lang.WordPairs {
+		patterns[i] = regexp.MustCompile(fmt.Sprintf(`\b(%s|%s)\b`,
+			regexp.QuoteMeta(pair.Open), regexp.QuoteMeta(pair.Close)))
+	}
+	wordPairPatterns.byLang[lang] = patterns
+	return patterns
+}
+
+// bracketMatch describes a matched pair of delimiters as rune ranges.
+// start/end cover the delimiter under the cursor, partnerStart/partnerEnd its partner.
+type bracketMatch struct {
+	start, end               int
+	partnerStart, partnerEnd int
+}
+
+// contains returns true if pos falls inside either delimiter of the match.
+func (bm bracketMatch) contains(pos int) bool {
+	return (pos >= bm.start && pos < bm.end) ||
+		(pos >= bm.partnerStart && pos < bm.partnerEnd)
+}
+
+// bracketCache remembers the last match found for a buffer state so that
+// rendering does not rescan an unchanged buffer on every frame.
+type bracketCache struct {
+	buffer  *buffer.GapBuffer
+	version int
+	pos     int
+	lang    *syntax.Language
+	match   bracketMatch
+	ok      bool
+}
+
+// bracketText holds a snapshot of the buffer (or of a window of it) split
+// into lines for scanning.
+type bracketText struct {
+	lines      []string
+	lineStarts []int // rune offset of each line start
+	firstLine  int   // buffer line number of lines[0]
+	masks      map[int][]bool
+	m          *Model
+}
+
+// newBracketText snapshots the active buffer for bracket scanning.
+func (m *Model) newBracketText() *bracketText {
+	return m.bracketTextFrom(m.buffer.String(), 0, 0)
+}
+
+// newBracketWindow snapshots up to radius lines above and below the cursor
+// line, reading only that part of the buffer.
+func (m *Model) newBracketWindow(radius int) *bracketText {
+	pos := m.buffer.CursorPos()
+	start, above := pos, 0
+	for start > 0 {
+		if m.buffer.RuneAt(start-1) == '\n' {
+			if above == radius {
+				break
+			}
+			above++
+		}
+		start--
+	}
+	end, below := pos, 0
+	for end < m.buffer.Len() {
+		if m.buffer.RuneAt(end) == '\n' {
+			if below == radius {
+				break
+			}
+			below++
+		}
+		end++
+	}
+	return m.bracketTextFrom(m.buffer.Slice(start, end), start, m.buffer.CurrentLine()-above)
+}
+
+// bracketTextFrom splits text, which starts at rune offset start on buffer
+// line firstLine, into lines for scanning.
+func (m *Model) bracketTextFrom(text string, start, firstLine int) *bracketText {
+	lines := strings.Split(text, "\n")
+	starts := make([]int, len(lines))
+	pos := start
+	for i, line := range lines {
+		starts[i] = pos
+		pos += utf8.RuneCountInString(line) + 1
+	}
+	return &bracketText{
+		lines:      lines,
+		lineStarts: starts,
+		firstLine:  firstLine,
+		masks:      make(map[int][]bool),
+		m:          m,
+	}
+}
+
+// codeMask returns, per rune of the line, whether it is code rather than
+// part of a string or comment token. Lines are tokenized lazily.
+func (bt *bracketText) codeMask(lineNum int) []bool {
+	if mask, ok := bt.masks[lineNum]; ok {
+		return mask
+	}
+	line := bt.lines[lineNum]
+	mask := make([]bool, utf8.RuneCountInString(line))
+	for i := range mask {
+		mask[i] = true
+	}
+	if h := bt.m.highlighter; h != nil {
+		col := 0
+		for _, token := range h.HighlightLine(bt.firstLine+lineNum, line) {
+			n := utf8.RuneCountInString(token.Text)
+			if token.Type == syntax.TokenString || token.Type == syntax.TokenComment {
+				for i := col; i < col+n && i < len(mask); i++ {
+					mask[i] = false
+				}
+			}
+			col += n
+		}
+	}
+	bt.masks[lineNum] = mask
+	return mask
+}
+
+// isCode reports whether the rune at line/col is outside strings and comments.
+func (bt *bracketText) isCode(lineNum, col int) bool {
+	mask := bt.codeMask(lineNum)
+	if col < 0 || col >= len(mask) {
+		return true
+	}
+	return mask[col]
+}
+
+// lineOf returns the index in lines of the line containing the rune offset pos.
+func (bt *bracketText) lineOf(pos int) int {
+	line := 0
+	for line+1 < len(bt.lineStarts) && bt.lineStarts[line+1] <= pos {
+		line++
+	}
+	return line
+}
+
+// findBracketMatch finds the delimiter under (or just before) the cursor and
+// its partner for highlighting. Only bracketWindowLines lines around the
+// cursor are scanned and the result is reused until the buffer, cursor or
+// language changes.
+func (m *Model) findBracketMatch() (bracketMatch, bool) {
+	lang := m.bracketLanguage()
+	pos := m.buffer.CursorPos()
+	c := &m.brackets
+	if c.buffer == m.buffer && c.version == m.buffer.Version() && c.pos == pos && c.lang == lang {
+		return c.match, c.ok
+	}
+	match, ok := m.newBracketWindow(bracketWindowLines).match(pos, lang)
+	*c = bracketCache{
+		buffer:  m.buffer,
+		version: m.buffer.Version(),
+		pos:     pos,
+		lang:    lang,
+		match:   match,
+		ok:      ok,
+	}
+	return match, ok
+}
+
+// findBracketPartner is findBracketMatch over the whole buffer, used when
+// jumping to a partner that may be off screen.
+func (m *Model) findBracketPartner() (bracketMatch, bool) {
+	lang := m.bracketLanguage()
+	return m.newBracketText().match(m.buffer.CursorPos(), lang)
+}
+
+// bracketLanguage returns the language of the active buffer, if known.
+func (m *Model) bracketLanguage() *syntax.Language {
+	if m.highlighter == nil {
+		m.updateHighlighter()
+	}
+	if m.highlighter == nil {
+		return nil
+	}
+	return m.highlighter.Language()
+}
+
+// match finds the delimiter at (or just before) pos and its partner.
+// Single-character brackets, language word pairs and markup tags are
+// supported. Delimiters inside strings or comments only match delimiters in
+// the same context.
+func (bt *bracketText) match(pos int, lang *syntax.Language) (bracketMatch, bool) {
+	if match, ok := bt.matchChar(pos); ok {
+		return match, true
+	}
+
+	if lang != nil && len(lang.WordPairs) > 0 {
+		if match, ok := bt.matchWord(pos, lang.WordPairs, wordPairRegexps(lang)); ok {
+			return match, true
+		}
+	}
+	if lang != nil && lang.MatchTags {
+		if match, ok := bt.matchTag(pos); ok {
+			return match, true
+		}
+	}
+
+	if pos > 0 {
+		return bt.matchChar(pos - 1)
+	}
+	return bracketMatch{}, false
+}
+
+// matchChar matches a single-character bracket located at pos.
+func (bt *bracketText) matchChar(pos int) (bracketMatch, bool) {
+	line := bt.lineOf(pos)
+	runes := []rune(bt.lines[line])
+	col := pos - bt.lineStarts[line]
+	if col < 0 || col >= len(runes) {
+		return bracketMatch{}, false
+	}
+	r := runes[col]
+	partner, ok := bracketPairs[r]
+	if !ok {
+		return bracketMatch{}, false
+	}
+	code := bt.isCode(line, col)
+
+	step := 1
+	if !isOpenBracket(r) {
+		step = -1
+	}
+
+	depth := 0
+	for ln := line; ln >= 0 && ln < len(bt.lines); ln += step {
+		lineRunes := []rune(bt.lines[ln])
+		start := 0
+		if step < 0 {
+			start = len(lineRunes) - 1
+		}
+		if ln == line {
+			start = col
+		}
+		for c := start; c >= 0 && c < len(lineRunes); c += step {
+			if lineRunes[c] != r && lineRunes[c] != partner {
+				continue
+			}
+			if bt.isCode(ln, c) != code {
+				continue
+			}
+			if lineRunes[c] == r {
+				depth++
+			} else {
+				depth--
+			}
+			if depth == 0 {
+				other := bt.lineStarts[ln] + c
+				return bracketMatch{
+					start: pos, end: pos + 1,
+					partnerStart: other, partnerEnd: other + 1,
+				}, true
+			}
+		}
+	}
+	return bracketMatch{}, false
+}
+
+// wordAt returns the bounds of the identifier-like word covering pos.
+func (bt *bracketText) wordAt(pos int) (line, start, end int, ok bool) {
+	line = bt.lineOf(pos)
+	runes := []rune(bt.lines[line])
+	col := pos - bt.lineStarts[line]
+	if col < 0 || col >= len(runes) || !isWordRune(runes[col]) {
+		return 0, 0, 0, false
+	}
+	start, end = col, col
+	for start > 0 && isWordRune(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isWordRune(runes[end]) {
+		end++
+	}
+	return line, start, end, true
+}
+
+// matchWord matches keyword pairs such as if/fi or begin/end. patterns holds
+// the compiled pattern of each pair.
+func (bt *bracketText) matchWord(pos int, pairs []syntax.BracketPair, patterns []*regexp.Regexp) (bracketMatch, bool) {
+	line, start, end, ok := bt.wordAt(pos)
+	if !ok {
+		return bracketMatch{}, false
+	}
+	word := string([]rune(bt.lines[line])[start:end])
+
+	for i, pair := range pairs {
+		var forward bool
+		switch word {
+		case pair.Open:
+			forward = true
+		case pair.Close:
+			forward = false
+		default:
+			continue
+		}
+
+		pattern := patterns[i]
+		code := bt.isCode(line, start)
+		origin := bt.lineStarts[line] + start
+
+		type hit struct {
+			pos, length int
+			open        bool
+		}
+		depth := 0
+		step := 1
+		if !forward {
+			step = -1
+		}
+		for ln := line; ln >= 0 && ln < len(bt.lines); ln += step {
+			text := bt.lines[ln]
+			var hits []hit
+			for _, loc := range pattern.FindAllStringIndex(text, -1) {
+				col := utf8.RuneCountInString(text[:loc[0]])
+				if bt.isCode(ln, col) != code {
+					continue
+				}
+				p := bt.lineStarts[ln] + col
+				if (forward && p < origin) || (!forward && p > origin) {
+					continue
+				}
+				hits = append(hits, hit{
+					pos:    p,
+					length: utf8.RuneCountInString(text[loc[0]:loc[1]]),
+					open:   text[loc[0]:loc[1]] == pair.Open,
+				})
+			}
+			if !forward {
+				for i, j := 0, len(hits)-1; i < j; i, j = i+1, j-1 {
+					hits[i], hits[j] = hits[j], hits[i]
+				}
+			}
+			for _, h := range hits {
+				if h.open == forward {
+					depth++
+				} else {
+					depth--
+				}
+				if depth == 0 {
+					return bracketMatch{
+						start: origin, end: origin + end - start,
+						partnerStart: h.pos, partnerEnd: h.pos + h.length,
+					}, true
+				}
+			}
+		}
+		return bracketMatch{}, false
+	}
+	return bracketMatch{}, false
+}
+
+// matchTag matches a markup tag containing pos with its opening or closing tag.
+func (bt *bracketText) matchTag(pos int) (bracketMatch, bool) {
+	text := strings.Join(bt.lines, "\n")
+	runeOffsets := byteToRuneOffsets(text)
+	base := bt.lineStarts[0]
+
+	type tag struct {
+		start, end int // rune offsets
+		name       string
+		closing    bool
+	}
+	var tags []tag
+	current := -1
+	for _, loc := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		start := base + runeOffsets[loc[0]]
+		line := bt.lineOf(start)
+		if !bt.isCode(line, start-bt.lineStarts[line]) {
+			continue
+		}
+		name := strings.ToLower(text[loc[4]:loc[5]])
+		selfClosing := loc[7] > loc[6]
+		if selfClosing || voidTags[name] {
+			continue
+		}
+		t := tag{
+			start:   start,
+			end:     base + runeOffsets[loc[1]],
+			name:    name,
+			closing: loc[3] > loc[2],
+		}
+		if pos >= t.start && pos < t.end {
+			current = len(tags)
+		}
+		tags = append(tags, t)
+	}
+	if current < 0 {
+		return bracketMatch{}, false
+	}
+
+	origin := tags[current]
+	depth := 0
+	if !origin.closing {
+		for i := current; i < len(tags); i++ {
+			if tags[i].name != origin.name {
+				continue
+			}
+			if tags[i].closing {
+				depth--
+			} else {
+				depth++
+			}
+			if depth == 0 {
+				return bracketMatch{origin.start, origin.end, tags[i].start, tags[i].end}, true
+			}
+		}
+	} else {
+		for i := current; i >= 0; i-- {
+			if tags[i].name != origin.name {
+				continue
+			}
+			if tags[i].closing {
+				depth++
+			} else {
+				depth--
+			}
+			if depth == 0 {
+				return bracketMatch{origin.start, origin.end, tags[i].start, tags[i].end}, true
+			}
+		}
+	}
+	return bracketMatch{}, false
+}
+
+// byteToRuneOffsets maps every byte offset of s (plus len(s)) to a rune offset.
+func byteToRuneOffsets(s string) []int {
+	offsets := make([]int, len(s)+1)
+	runeIdx := 0
+	for i := range s {
+		for j := i; j < len(s) && (j == i || !utf8.RuneStart(s[j])); j++ {
+			offsets[j] = runeIdx
+		}
+		runeIdx++
+	}
+	offsets[len(s)] = runeIdx
+	return offsets
+}
+
+// isWordRune returns true for letters, digits and underscore.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// jumpToMatchingBracket moves the cursor to the partner of the delimiter
+// under the cursor (nano Alt+]).
+func (m *Model) jumpToMatchingBracket() {
+	match, ok := m.findBracketPartner()
+	if !ok {
+		m.SetStatusMessage("Not a bracket")
+		return
+	}
+	m.buffer.MoveTo(match.partnerStart)
+	m.ensureCursorVisible()
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindBracketMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		cursor   int
+		want     int // partner start, -1 for no match
+	}{
+		{"paren forward", "a.go", "f(a, (b))", 1, 8},
+		{"paren backward", "a.go", "f(a, (b))", 8, 1},
+		{"across lines", "a.go", "func() {\n\treturn\n}", 7, 17},
+		{"after cursor", "a.go", "(x)", 3, 0},
+		{"skip string", "a.go", `f(")", x)`, 1, 8},
+		{"no bracket", "a.go", "abc", 1, -1},
+		{"shell if/fi", "a.sh", "if x; then\n  if y; then z; fi\nfi", 0, 30},
+		{"shell fi/if", "a.sh", "if x; then\n  if y; then z; fi\nfi", 31, 0},
+		{"html tags", "a.html", "<div><div></div></div>", 1, 16},
+		{"html closing", "a.html", "<ul><li>x</li></ul>", 15, 0},
+		{"html void", "a.html", "<p><br></p>", 0, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewFromFile(tt.filename, tt.filename, tt.content)
+			m.updateHighlighter()
+			m.buffer.MoveTo(tt.cursor)
+
+			match, ok := m.findBracketMatch()
+			if tt.want < 0 {
+				if ok {
+					t.Errorf("findBracketMatch() = %+v, want no match", match)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("findBracketMatch() found no match, want %d", tt.want)
+			}
+			if match.partnerStart != tt.want {
+				t.Errorf("partnerStart = %d, want %d", match.partnerStart, tt.want)
+			}
+		})
+	}
+}
+
+func TestJumpToMatchingBracket(t *testing.T) {
+	m := NewWithContent("if (a[0]) {}")
+	m.buffer.MoveTo(3)
+
+	m.jumpToMatchingBracket()
+	if got := m.buffer.CursorPos(); got != 8 {
+		t.Errorf("cursor = %d, want 8", got)
+	}
+
+	m.jumpToMatchingBracket()
+	if got := m.buffer.CursorPos(); got != 3 {
+		t.Errorf("cursor = %d, want 3", got)
+	}
+}
+
+func TestFindBracketMatchWindow(t *testing.T) {
+	// Matches are found in a window around the cursor, with tags and word
+	// pairs placed deep in the file
+	filler := strings.Repeat("x\n", 3*bracketWindowLines)
+	m := NewFromFile("a.html", "a.html", filler+"<b><i>x</i></b>")
+	m.updateHighlighter()
+	m.buffer.MoveTo(len(filler) + 3)
+	match, ok := m.findBracketMatch()
+	if !ok || match.partnerStart != len(filler)+7 {
+		t.Errorf("findBracketMatch() = %+v, %v, want partner %d", match, ok, len(filler)+7)
+	}
+
+	// A partner beyond the window is not highlighted but can be jumped to
+	m = NewWithContent("(" + filler + ")")
+	m.buffer.MoveTo(0)
+	if match, ok := m.findBracketMatch(); ok {
+		t.Errorf("findBracketMatch() = %+v, want no match outside the window", match)
+	}
+	m.jumpToMatchingBracket()
+	if got := m.buffer.CursorPos(); got != len(filler)+1 {
+		t.Errorf("cursor = %d, want %d", got, len(filler)+1)
+	}
+}
+
+func TestFindBracketMatchCache(t *testing.T) {
+	m := NewWithContent("(a)")
+	m.buffer.MoveTo(0)
+	if match, ok := m.findBracketMatch(); !ok || match.partnerStart != 2 {
+		t.Fatalf("findBracketMatch() = %+v, %v", match, ok)
+	}
+
+	// Editing the buffer invalidates the cached match
+	m.buffer.MoveTo(1)
+	m.buffer.Insert('(')
+	m.buffer.MoveTo(0)
+	if match, ok := m.findBracketMatch(); ok {
+		t.Errorf("findBracketMatch() = %+v after edit, want no match", match)
+	}
+}
@@ -0,0 +1,118 @@
+// Package app provides line comment toggling.
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+// toggleComment comments or uncomments the current line or the selected lines
+// using the comment syntax of the current language.
+func (m *Model) toggleComment() {
+	if m.highlighter == nil {
+		m.updateHighlighter()
+	}
+	var lang *syntax.Language
+	if m.highlighter != nil {
+		lang = m.highlighter.Language()
+	}
+	if lang == nil || (lang.LineComment == "" && lang.BlockComment.Open == "") {
+		m.SetStatusMessage("No comment syntax for this file type")
+		return
+	}
+
+	first, last := m.selectedLines()
+	lines := m.linesInRange(first, last)
+	newLines, commented := toggleCommentLines(lines, lang.LineComment, lang.BlockComment)
+	if newLines == nil {
+		return
+	}
+
+	line := m.buffer.CurrentLine()
+	col := m.buffer.CurrentColumn()
+	m.replaceLines(first, last, newLines)
+
+	// Keep the cursor at the same place in the text when nothing is selected
+	if !m.selecting {
+		old := []rune(lines[line-first])
+		delta := len([]rune(newLines[line-first])) - len(old)
+		if col >= len(old)-len([]rune(strings.TrimLeft(string(old), " \t"))) {
+			col += delta
+		}
+		m.moveToLineColumn(line, col)
+	}
+
+	count := last - first + 1
+	action := "Uncommented"
+	if commented {
+		action = "Commented"
+	}
+	if count == 1 {
+		m.SetStatusMessage(action + " 1 line")
+	} else {
+		m.SetStatusMessage(fmt.Sprintf("%s %d lines", action, count))
+	}
+}
+
+// toggleCommentLines comments or uncomments lines. If every non-blank line is
+// already commented the comments are removed, otherwise all non-blank lines
+// are commented at their common indentation. Languages without a line comment
+// prefix wrap each line in the block comment delimiters instead.
+// Returns nil if there is nothing to change, and whether lines were commented.
+func toggleCommentLines(lines []string, prefix string, block syntax.BracketPair) ([]string, bool) {
+	minIndent := -1
+	allCommented := true
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if indent := len(line) - len(trimmed); minIndent < 0 || indent < minIndent {
+			minIndent = indent
+		}
+		if !isCommented(trimmed, prefix, block) {
+			allCommented = false
+		}
+	}
+	if minIndent < 0 {
+		return nil, false
+	}
+
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			result[i] = line
+			continue
+		}
+		indent := line[:len(line)-len(trimmed)]
+
+		switch {
+		case allCommented && prefix != "":
+			rest := strings.TrimPrefix(trimmed, prefix)
+			result[i] = indent + strings.TrimPrefix(rest, " ")
+		case allCommented:
+			rest := strings.TrimRight(trimmed, " \t")
+			rest = strings.TrimSuffix(strings.TrimPrefix(rest, block.Open), block.Close)
+			rest = strings.TrimSuffix(strings.TrimPrefix(rest, " "), " ")
+			result[i] = indent + rest
+		case prefix != "":
+			result[i] = line[:minIndent] + prefix + " " + line[minIndent:]
+		default:
+			result[i] = indent + block.Open + " " + trimmed + " " + block.Close
+		}
+	}
+	return result, !allCommented
+}
+
+// isCommented reports whether a line (without leading indentation) is commented.
+func isCommented(trimmed, prefix string, block syntax.BracketPair) bool {
+	if prefix != "" {
+		return strings.HasPrefix(trimmed, prefix)
+	}
+	trimmed = strings.TrimRight(trimmed, " \t")
+	return len(trimmed) >= len(block.Open)+len(block.Close) &&
+		strings.HasPrefix(trimmed, block.Open) && strings.HasSuffix(trimmed, block.Close)
+}
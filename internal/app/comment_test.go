@@ -0,0 +1,97 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+func TestToggleCommentLines(t *testing.T) {
+	cBlock := syntax.BracketPair{Open: "/*", Close: "*/"}
+
+	tests := []struct {
+		name      string
+		lines     []string
+		prefix    string
+		block     syntax.BracketPair
+		want      []string
+		commented bool
+	}{
+		{
+			"comment at common indent",
+			[]string{"\tif x {", "", "\t\ty()", "\t}"},
+			"//", cBlock,
+			[]string{"\t// if x {", "", "\t// \ty()", "\t// }"},
+			true,
+		},
+		{
+			"uncomment",
+			[]string{"  # a", "  #b"},
+			"#", syntax.BracketPair{},
+			[]string{"  a", "  b"},
+			false,
+		},
+		{
+			"mixed lines are commented",
+			[]string{"# a", "b"},
+			"#", syntax.BracketPair{},
+			[]string{"# # a", "# b"},
+			true,
+		},
+		{
+			"block comment",
+			[]string{"  color: red;"},
+			"", cBlock,
+			[]string{"  /* color: red; */"},
+			true,
+		},
+		{
+			"block uncomment",
+			[]string{"  /* color: red; */"},
+			"", cBlock,
+			[]string{"  color: red;"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, commented := toggleCommentLines(tt.lines, tt.prefix, tt.block)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toggleCommentLines() = %q, want %q", got, tt.want)
+			}
+			if commented != tt.commented {
+				t.Errorf("commented = %v, want %v", commented, tt.commented)
+			}
+		})
+	}
+}
+
+func TestToggleCommentSelection(t *testing.T) {
+	m := NewFromFile("a.py", "a.py", "x = 1\n    y = 2\nz = 3\n")
+	m.selecting = true
+	m.selectionStart = 0
+	m.selectionEnd = m.buffer.LineStart(2)
+	m.buffer.MoveTo(m.selectionEnd)
+
+	m.toggleComment()
+	want := "# x = 1\n#     y = 2\nz = 3\n"
+	if got := m.buffer.String(); got != want {
+		t.Fatalf("buffer = %q, want %q", got, want)
+	}
+
+	m.undo()
+	if got := m.buffer.String(); got != "x = 1\n    y = 2\nz = 3\n" {
+		t.Errorf("after undo buffer = %q", got)
+	}
+}
+
+func TestToggleCommentNoSyntax(t *testing.T) {
+	m := NewFromFile("a.json", "a.json", "{}")
+	m.toggleComment()
+
+	if got := m.buffer.String(); got != "{}" {
+		t.Errorf("buffer = %q, want unchanged", got)
+	}
+}
@@ -0,0 +1,269 @@
+// Package app provides word completion from open buffers.
+package app
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+)
+
+// maxCompletionItems is the number of candidates shown in the popup.
+const maxCompletionItems = 8
+
+// completionState tracks an in-progress word completion.
+type completionState struct {
+	start      int      // rune offset where the prefix begins
+	prefix     string   // text typed before completion started
+	candidates []string // words starting with prefix, best first
+	index      int      // inserted candidate, -1 for the original prefix
+}
+
+// current returns the text currently inserted for the completion.
+func (c *completionState) current() string {
+	if c.index < 0 {
+		return c.prefix
+	}
+	return c.candidates[c.index]
+}
+
+// startCompletion begins completing the word before the cursor.
+func (m *Model) startCompletion() {
+	pos := m.buffer.CursorPos()
+	start := pos
+	for start > 0 && isWordRune(m.buffer.RuneAt(start-1)) {
+		start--
+	}
+	if start == pos {
+		m.SetStatusMessage("No word to complete")
+		return
+	}
+
+	prefix := m.buffer.Slice(start, pos)
+	candidates := m.completionCandidates(prefix, start)
+	if len(candidates) == 0 {
+		m.SetStatusMessage("No completions for \"" + prefix + "\"")
+		return
+	}
+
+	m.completion = &completionState{
+		start:      start,
+		prefix:     prefix,
+		candidates: candidates,
+		index:      -1,
+	}
+	m.cycleCompletion(1)
+}
+
+// completionCandidates returns words starting with prefix, from the current
+// tab ranked by distance to pos, followed by words from the other tabs.
+func (m *Model) completionCandidates(prefix string, pos int) []string {
+	distance := make(map[string]int)
+	var others []string
+
+	collectWords(m.buffer.String(), prefix, func(word string, offset int) {
+		if offset == pos {
+			return // the word being completed
+		}
+		d := offset - pos
+		if d < 0 {
+			d = -d
+		}
+		if old, ok := distance[word]; !ok || d < old {
+			distance[word] = d
+		}
+	})
+
+	for i, tab := range m.tabs.Tabs() {
+		if i == m.tabs.ActiveIndex() {
+			continue
+		}
+		collectWords(tab.buffer.String(), prefix, func(word string, _ int) {
+			if _, ok := distance[word]; !ok {
+				distance[word] = -1
+				others = append(others, word)
+			}
+		})
+	}
+
+	var nearby []string
+	for word, d := range distance {
+		if d >= 0 {
+			nearby = append(nearby, word)
+		}
+	}
+	sort.Slice(nearby, func(i, j int) bool {
+		di, dj := distance[nearby[i]], distance[nearby[j]]
+		if di != dj {
+			return di < dj
+		}
+		return nearby[i] < nearby[j]
+	})
+	return append(nearby, others...)
+}
+
+// collectWords calls fn for every word in text that starts with (and is
+// longer than) prefix, with the rune offset of the word.
+func collectWords(text, prefix string, fn func(word string, offset int)) {
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && isWordRune(runes[i]) {
+			i++
+		}
+		word := string(runes[start:i])
+		if len(word) > len(prefix) && strings.HasPrefix(word, prefix) {
+			fn(word, start)
+		}
+	}
+}
+
+// cycleCompletion replaces the inserted text with the next (dir 1) or
+// previous (dir -1) candidate, passing through the original prefix.
+func (m *Model) cycleCompletion(dir int) {
+	c := m.completion
+	next := c.index + dir
+	if next >= len(c.candidates) {
+		next = -1
+	} else if next < -1 {
+		next = len(c.candidates) - 1
+	}
+	m.replaceCompletionText(next)
+}
+
+// replaceCompletionText swaps the inserted text for candidate index without
+// recording history; the final choice is recorded when completion ends.
+func (m *Model) replaceCompletionText(index int) {
+	c := m.completion
+	old := c.current()
+	c.index = index
+
+	m.buffer.MoveTo(c.start + len([]rune(old)))
+	for range []rune(old) {
+		m.buffer.Delete()
+	}
+	m.buffer.InsertString(c.current())
+	m.invalidateSyntaxCache(m.buffer.CurrentLine())
+}
+
+// finishCompletion ends completion, keeping the inserted word.
+func (m *Model) finishCompletion() {
+	c := m.completion
+	m.completion = nil
+	word := c.current()
+	if word == c.prefix {
+		return
+	}
+
+	m.history.BeginGroup()
+	if c.prefix != "" {
+		m.pushEdit(buffer.EditOperation{
+			Type:     buffer.OpDelete,
+			Position: c.start,
+			Text:     c.prefix,
+		})
+	}
+	m.pushEdit(buffer.EditOperation{
+		Type:     buffer.OpInsert,
+		Position: c.start,
+		Text:     word,
+	})
+	m.history.EndGroup()
+	m.setModified()
+}
+
+// cancelCompletion ends completion, restoring the original prefix.
+func (m *Model) cancelCompletion() {
+	m.replaceCompletionText(-1)
+	m.completion = nil
+	m.SetStatusMessage("")
+}
+
+// handleCompletionKey handles a key press while completing.
+// Returns true if the key was consumed.
+func (m *Model) handleCompletionKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "ctrl+]", "down":
+		m.cycleCompletion(1)
+		return true
+	case "up":
+		m.cycleCompletion(-1)
+		return true
+	case "esc":
+		m.cancelCompletion()
+		return true
+	case "enter", "tab":
+		m.finishCompletion()
+		return true
+	}
+	m.finishCompletion()
+	return false
+}
+
+// overlayCompletion draws the completion popup over the rendered editor rows.
+// row is the screen row of the cursor line, col the screen column of the word.
+func (m *Model) overlayCompletion(rendered string, row, col int) string {
+	c := m.completion
+	rows := strings.Split(strings.TrimSuffix(rendered, "\n"), "\n")
+
+	// Show a window of candidates around the selection
+	first := 0
+	if c.index >= maxCompletionItems {
+		first = c.index - maxCompletionItems + 1
+	}
+	last := first + maxCompletionItems
+	if last > len(c.candidates) {
+		last = len(c.candidates)
+	}
+
+	width := 0
+	for _, word := range c.candidates[first:last] {
+		if w := ansi.StringWidth(word); w > width {
+			width = w
+		}
+	}
+
+	// Below the cursor line if it fits, otherwise above
+	top := row + 1
+	if top+last-first > len(rows) {
+		top = row - (last - first)
+	}
+	if top < 0 {
+		top = 0
+	}
+	if col+width+2 > m.width {
+		col = m.width - width - 2
+	}
+	if col < 0 {
+		col = 0
+	}
+
+	for i, word := range c.candidates[first:last] {
+		r := top + i
+		if r >= len(rows) {
+			break
+		}
+		cell := " " + word + strings.Repeat(" ", width-ansi.StringWidth(word)) + " "
+		if first+i == c.index {
+			cell = selectionStyle.Render(cell)
+		} else {
+			cell = statusStyle.Render(cell)
+		}
+
+		line := rows[r]
+		left := ansi.Truncate(line, col, "")
+		if pad := col - ansi.StringWidth(left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+		right := ansi.TruncateLeft(line, col+width+2, "")
+		rows[r] = left + cell + right
+	}
+	return strings.Join(rows, "\n") + "\n"
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCompletionCandidates(t *testing.T) {
+	m := NewWithContent("handler hand\nhandle handy")
+	m.OpenFileInNewTab("other.go", "other.go", "handshake handler", "UTF-8", "LF")
+	m.SelectTab(0)
+
+	got := m.completionCandidates("hand", 8)
+	want := []string{"handle", "handler", "handy", "handshake"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("completionCandidates() = %v, want %v", got, want)
+	}
+}
+
+func TestCompletionCycleAndAccept(t *testing.T) {
+	m := NewWithContent("apple apricot\nap")
+	m.buffer.MoveToEnd()
+	ctrlBracket := tea.KeyMsg{Type: tea.KeyCtrlCloseBracket}
+
+	m.handleKeyMsg(ctrlBracket)
+	if got := m.buffer.Line(1); got != "apricot" {
+		t.Fatalf("first completion = %q, want %q", got, "apricot")
+	}
+	if got := m.View(); !strings.Contains(got, "apple") {
+		t.Error("View() does not show the completion popup")
+	}
+
+	m.handleKeyMsg(ctrlBracket)
+	if got := m.buffer.Line(1); got != "apple" {
+		t.Fatalf("second completion = %q, want %q", got, "apple")
+	}
+
+	typeText(m, "s")
+	if m.completion != nil {
+		t.Error("completion still active after typing")
+	}
+	if got := m.buffer.Line(1); got != "apples" {
+		t.Errorf("line = %q, want %q", got, "apples")
+	}
+
+	m.undo()
+	m.undo()
+	if got := m.buffer.Line(1); got != "ap" {
+		t.Errorf("after undo line = %q, want %q", got, "ap")
+	}
+}
+
+func TestCompletionEscRestoresPrefix(t *testing.T) {
+	m := NewWithContent("banana\nba")
+	m.buffer.MoveToEnd()
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyCtrlCloseBracket})
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEsc})
+
+	if got := m.buffer.String(); got != "banana\nba" {
+		t.Errorf("buffer = %q, want %q", got, "banana\nba")
+	}
+	if m.IsModified() {
+		t.Error("buffer marked modified after cancelled completion")
+	}
+}
@@ -0,0 +1,216 @@
+// Package app provides shared helpers for compound buffer edits.
+package app
+
+import (
+	"strings"
+
+	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+)
+
+// replaceRange replaces the text between start and end (rune offsets) with
+// text, recorded as a single undo step. The cursor is left after the new text.
+func (m *Model) replaceRange(start, end int, text string) {
+	old := m.buffer.Slice(start, end)
+	if old == text {
+		m.buffer.MoveTo(start + len([]rune(text)))
+		return
+	}
+
+	m.history.BeginGroup()
+	defer m.history.EndGroup()
+
+	m.buffer.MoveTo(start)
+	firstLine := m.buffer.CurrentLine()
+
+	if old != "" {
+		m.buffer.MoveTo(start + len([]rune(old)))
+		for range []rune(old) {
+			m.buffer.Delete()
+		}
+		m.pushEdit(buffer.EditOperation{
+			Type:     buffer.OpDelete,
+			Position: start,
+			Text:     old,
+		})
+	}
+	if text != "" {
+		m.buffer.InsertString(text)
+		m.pushEdit(buffer.EditOperation{
+			Type:     buffer.OpInsert,
+			Position: start,
+			Text:     text,
+		})
+	}
+
+	m.setModified()
+	m.invalidateSyntaxCache(firstLine)
+}
+
+// lineAt returns the line number (0-indexed) containing the rune offset pos.
+func (m *Model) lineAt(pos int) int {
+	line := 0
+	for i := 0; i < pos && i < m.buffer.Len(); i++ {
+		if m.buffer.RuneAt(i) == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+// selectedLines returns the first and last line covered by the selection,
+// or the current line when nothing is selected. A selection ending at the
+// very start of a line does not include that line.
+func (m *Model) selectedLines() (first, last int) {
+	if !m.selecting || m.selectionStart == m.selectionEnd {
+		line := m.buffer.CurrentLine()
+		return line, line
+	}
+	start, end := m.getSelectionBounds()
+	first = m.lineAt(start)
+	last = m.lineAt(end)
+	if last > first && m.buffer.LineStart(last) == end {
+		last--
+	}
+	return first, last
+}
+
+// hasTextSelection returns true if a non-empty selection is active.
+func (m *Model) hasTextSelection() bool {
+	return m.selecting && m.selectionStart != m.selectionEnd
+}
+
+// hasLineSelection returns true if the selection spans more than one line.
+func (m *Model) hasLineSelection() bool {
+	if !m.selecting || m.selectionStart == m.selectionEnd {
+		return false
+	}
+	first, last := m.selectedLines()
+	return last > first
+}
+
+// linesInRange returns the content of lines first through last.
+func (m *Model) linesInRange(first, last int) []string {
+	start := m.buffer.LineStart(first)
+	end := m.buffer.LineEnd(last)
+	return strings.Split(m.buffer.Slice(start, end), "\n")
+}
+
+// replaceLines replaces lines first through last with newLines as a single
+// undo step. If a selection is active it is moved to cover the new lines.
+func (m *Model) replaceLines(first, last int, newLines []string) {
+	start := m.buffer.LineStart(first)
+	end := m.buffer.LineEnd(last)
+	m.replaceRange(start, end, strings.Join(newLines, "\n"))
+
+	if m.selecting {
+		m.selectionStart = start
+		m.selectionEnd = m.buffer.LineEnd(first + len(newLines) - 1)
+		m.buffer.MoveTo(m.selectionEnd)
+	}
+}
+
+// moveToLineColumn moves the cursor to line/col, clamping col to the line length.
+func (m *Model) moveToLineColumn(line, col int) {
+	lineStart := m.buffer.LineStart(line)
+	lineEnd := m.buffer.LineEnd(line)
+	if lineStart < 0 {
+		return
+	}
+	if col < 0 {
+		col = 0
+	}
+	if col > lineEnd-lineStart {
+		col = lineEnd - lineStart
+	}
+	m.buffer.MoveTo(lineStart + col)
+}
+
+// linePosition is a buffer position expressed as a line and column.
+type linePosition struct {
+	line, col int
+}
+
+// linePositionOf converts a rune offset into a line and column.
+func (m *Model) linePositionOf(pos int) linePosition {
+	line := m.lineAt(pos)
+	return linePosition{line, pos - m.buffer.LineStart(line)}
+}
+
+// positionOf converts a line and column into a rune offset, clamping the
+// column to the line length. Lines past the end map to the end of the buffer.
+func (m *Model) positionOf(p linePosition) int {
+	lineStart := m.buffer.LineStart(p.line)
+	if lineStart < 0 {
+		return m.buffer.Len()
+	}
+	col := p.col
+	if col < 0 {
+		col = 0
+	}
+	if lineLen := m.buffer.LineEnd(p.line) - lineStart; col > lineLen {
+		col = lineLen
+	}
+	return lineStart + col
+}
+
+// pushEdit records an edit that was just applied to the buffer for undo and
+// keeps line-based state in line with it.
+func (m *Model) pushEdit(op buffer.EditOperation) {
+	m.history.Push(op)
+	m.trackEdit(op)
+}
+
+// trackEdit shifts folds, bookmarks and diagnostics for an insert or delete
+// of op.Text at op.Position that was applied to the buffer.
+func (m *Model) trackEdit(op buffer.EditOperation) {
+	lines := strings.Count(op.Text, "\n")
+	if lines == 0 {
+		return
+	}
+	line := m.lineAt(op.Position)
+	if op.Type == buffer.OpDelete {
+		m.trackLineChanges(line, line+lines, -lines)
+		return
+	}
+	// Text inserted at the start of a line moves that line down too
+	if op.Position == m.buffer.LineStart(line) {
+		line--
+	}
+	m.trackLineChanges(line, line, lines)
+}
+
+// trackLineChanges shifts folds, bookmarks and diagnostics after an edit
+// that joined lines editStart+1..editEnd into editStart and moved the lines
+// after editEnd by delta.
+func (m *Model) trackLineChanges(editStart, editEnd, delta int) {
+	tab := m.tabs.ActiveTab()
+	if tab == nil {
+		return
+	}
+
+	if len(tab.folds) > 0 {
+		count := m.buffer.LineCount()
+		folds := make(map[int]int, len(tab.folds))
+		for start, end := range tab.folds {
+			// Folds touched by the edit are dropped rather than guessed
+			if start <= editEnd && end > editStart {
+				continue
+			}
+			if start > editEnd {
+				start += delta
+				end += delta
+			}
+			if start >= 0 && end < count && end > start {
+				folds[start] = end
+			}
+		}
+		tab.folds = folds
+	}
+
+	if len(tab.bookmarks) > 0 {
+		tab.bookmarks = shiftBookmarks(tab.bookmarks, editStart, editEnd, delta)
+	}
+	shiftDiagnostics(tab.diagnostics, editStart, editEnd, delta)
+	shiftDiagnostics(tab.lspDiagnostics, editStart, editEnd, delta)
+	tab.diagnosticIndex = nil
+}
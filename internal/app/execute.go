@@ -0,0 +1,243 @@
+// Package app provides piping text through external commands.
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+)
+
+// commandRun is an external command started from the execute prompt.
+type commandRun struct {
+	id          int
+	command     string
+	buffer      *buffer.GapBuffer // buffer the output goes to
+	version     int               // buffer version when the command started
+	start, end  int               // range replaced by the output
+	trimNewline bool              // drop the final newline of the output
+	cancel      context.CancelFunc
+}
+
+// commandDoneMsg reports the result of an external command.
+type commandDoneMsg struct {
+	id       int
+	stdout   string
+	stderr   string
+	exitCode int
+	err      error // failure to run, or cancellation
+}
+
+// openExecutePrompt shows the execute command prompt. With a selection the
+// output replaces it by default; otherwise the output is inserted.
+func (m *Model) openExecutePrompt() {
+	m.mode = ModeExecute
+	m.inputBuffer = ""
+	m.commandReplace = m.hasTextSelection()
+	m.updateExecutePrompt()
+}
+
+// updateExecutePrompt sets the prompt text for the current output mode.
+func (m *Model) updateExecutePrompt() {
+	source := "buffer"
+	if m.hasTextSelection() {
+		source = "selection"
+	}
+	if m.commandReplace {
+		m.inputPrompt = "Pipe " + source + " through: "
+	} else {
+		m.inputPrompt = "Insert output of (" + source + " on stdin): "
+	}
+}
+
+// handleExecuteInput handles input in execute command mode.
+func (m *Model) handleExecuteInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		command := m.inputBuffer
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		if strings.TrimSpace(command) == "" {
+			return m, nil
+		}
+		return m, m.executeCommand(command)
+
+	case "esc", "ctrl+c":
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		m.SetStatusMessage("")
+		return m, nil
+
+	case "ctrl+r":
+		// Toggle between replacing the input and inserting at the cursor
+		m.commandReplace = !m.commandReplace
+		m.updateExecutePrompt()
+		return m, nil
+
+	case "backspace":
+		if len(m.inputBuffer) > 0 {
+			runes := []rune(m.inputBuffer)
+			m.inputBuffer = string(runes[:len(runes)-1])
+		}
+		return m, nil
+
+	default:
+		if len(msg.Runes) > 0 {
+			m.inputBuffer += string(msg.Runes)
+		}
+		return m, nil
+	}
+}
+
+// executeCommand starts command in the background with the selection (or
+// the whole buffer) on stdin. The result arrives as a commandDoneMsg.
+func (m *Model) executeCommand(command string) tea.Cmd {
+	if m.command != nil {
+		m.SetStatusMessage("A command is already running")
+		return nil
+	}
+
+	start, end := 0, m.buffer.Len()
+	if m.hasTextSelection() {
+		start, end = m.getSelectionBounds()
+	}
+	input := m.buffer.Slice(start, end)
+	trimNewline := !strings.HasSuffix(input, "\n")
+	if !m.commandReplace {
+		start = m.buffer.CursorPos()
+		end = start
+		trimNewline = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.commandSeq++
+	m.command = &commandRun{
+		id:          m.commandSeq,
+		command:     command,
+		buffer:      m.buffer,
+		version:     m.buffer.Version(),
+		start:       start,
+		end:         end,
+		trimNewline: trimNewline,
+		cancel:      cancel,
+	}
+	m.SetStatusMessage("Running: " + command + "  [Esc] Cancel")
+
+	id := m.commandSeq
+	return func() tea.Msg {
+		return runCommand(ctx, id, command, input)
+	}
+}
+
+// shellCommand returns the command that runs command in the system shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// runCommand runs command with input on stdin and collects its output.
+func runCommand(ctx context.Context, id int, command, input string) commandDoneMsg {
+	cmd := shellCommand(ctx, command)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait for children still holding the output pipes after a kill
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	msg := commandDoneMsg{id: id, stdout: stdout.String(), stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		msg.err = ctx.Err()
+	case errors.As(err, &exitErr):
+		msg.exitCode = exitErr.ExitCode()
+	case err != nil:
+		msg.err = err
+	}
+	return msg
+}
+
+// cancelCommand stops the running command.
+func (m *Model) cancelCommand() {
+	if m.command != nil {
+		m.command.cancel()
+		m.SetStatusMessage("Cancelling: " + m.command.command)
+	}
+}
+
+// finishCommand applies the output of a finished command as a single undo
+// step. Output of failed commands is discarded.
+func (m *Model) finishCommand(msg commandDoneMsg) {
+	run := m.command
+	if run == nil || run.id != msg.id {
+		return
+	}
+	m.command = nil
+	run.cancel()
+
+	stderr := firstLine(msg.stderr)
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.SetStatusMessage("Command cancelled")
+		return
+	case msg.err != nil:
+		m.SetStatusMessage("Error running command: " + msg.err.Error())
+		return
+	case msg.exitCode != 0:
+		status := fmt.Sprintf("Exit %d, output discarded", msg.exitCode)
+		if stderr != "" {
+			status += ": " + stderr
+		}
+		m.SetStatusMessage(status)
+		return
+	}
+
+	output := msg.stdout
+	if run.trimNewline {
+		output = strings.TrimSuffix(strings.TrimSuffix(output, "\n"), "\r")
+	}
+
+	// The target range is only valid if the buffer is unchanged
+	if run.buffer != m.buffer || run.buffer.Version() != run.version {
+		m.clipboard = output
+		m.SetStatusMessage("Buffer changed while the command ran; output copied to clipboard")
+		return
+	}
+	if m.readonly {
+		m.clipboard = output
+		m.SetStatusMessage("File is read-only; output copied to clipboard")
+		return
+	}
+
+	m.clearSelection()
+	m.replaceRange(run.start, run.end, output)
+	status := "Exit 0"
+	if stderr != "" {
+		status += ": " + stderr
+	} else {
+		status += fmt.Sprintf(": %d lines of output", strings.Count(output, "\n")+1)
+	}
+	m.SetStatusMessage(status)
+}
+
+// firstLine returns the first non-empty line of s, trimmed.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runExecute types command into the execute prompt and applies the result.
+func runExecute(t *testing.T, m *Model, command string) {
+	t.Helper()
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!"), Alt: true})
+	if m.mode != ModeExecute {
+		t.Fatalf("mode = %v, want ModeExecute", m.mode)
+	}
+	typeText(m, command)
+	_, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("no command started")
+	}
+	m.Update(cmd())
+}
+
+func TestExecutePipesSelection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	m := NewWithContent("keep\nb\na\nkeep")
+	m.moveToLineColumn(1, 0)
+	m.startSelection()
+	m.moveToLineColumn(3, 0)
+	m.updateSelection()
+
+	runExecute(t, m, "sort")
+	if got := m.buffer.String(); got != "keep\na\nb\nkeep" {
+		t.Errorf("after sort = %q", got)
+	}
+
+	m.undo()
+	if got := m.buffer.String(); got != "keep\nb\na\nkeep" {
+		t.Errorf("after undo = %q", got)
+	}
+}
+
+func TestExecuteInsertsOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	m := NewWithContent("one two")
+	m.buffer.MoveToEnd()
+
+	runExecute(t, m, "wc -w | tr -d ' '")
+	if got := m.buffer.String(); got != "one two2" {
+		t.Errorf("buffer = %q, want %q", got, "one two2")
+	}
+}
+
+func TestExecuteFailureKeepsBuffer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	m := NewWithContent("text")
+	m.selectAll()
+
+	runExecute(t, m, "echo broken >&2; exit 3")
+	if got := m.buffer.String(); got != "text" {
+		t.Errorf("buffer = %q, want unchanged", got)
+	}
+	if !strings.Contains(m.statusMessage, "Exit 3") || !strings.Contains(m.statusMessage, "broken") {
+		t.Errorf("status = %q, want exit code and stderr", m.statusMessage)
+	}
+}
+
+func TestExecuteCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	m := NewWithContent("text")
+	m.openExecutePrompt()
+	typeText(m, "sleep 10")
+	_, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEsc})
+	m.Update(cmd())
+	if m.command != nil {
+		t.Error("command still running after cancel")
+	}
+	if got := m.buffer.String(); got != "text" {
+		t.Errorf("buffer = %q, want unchanged", got)
+	}
+	if m.statusMessage != "Command cancelled" {
+		t.Errorf("status = %q, want %q", m.statusMessage, "Command cancelled")
+	}
+}
@@ -0,0 +1,709 @@
+// Package app provides the file tree sidebar.
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/KilimcininKorOglu/gesh/internal/ui/styles"
+)
+
+// File tree sidebar widths, in columns.
+const (
+	defaultTreeWidth = 30
+	minTreeWidth     = 15
+	treeWidthStep    = 5
+	minEditorWidth   = 20
+)
+
+// treeNode is a file or directory shown in the file tree.
+type treeNode struct {
+	path     string
+	name     string
+	dir      bool
+	depth    int
+	expanded bool
+	loaded   bool // children have been read
+	children []*treeNode
+}
+
+// fileTree is the directory tree sidebar. It is shown next to the editor
+// area, which keeps its own split layout in the remaining width.
+type fileTree struct {
+	root     *treeNode
+	rows     []*treeNode // visible nodes in display order
+	selected int
+	top      int
+	width    int
+	visible  bool
+
+	// Set while browsing for a prompt: Enter on a file fills in the prompt
+	prompt     Mode
+	promptText string
+	hideAfter  bool // the tree was hidden before browsing
+}
+
+// newFileTree creates a tree rooted at dir with the root expanded.
+func newFileTree(dir string) *fileTree {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	t := &fileTree{
+		root:  &treeNode{path: dir, name: filepath.Base(dir), dir: true, depth: -1},
+		width: defaultTreeWidth,
+	}
+	t.expand(t.root)
+	t.rebuild()
+	return t
+}
+
+// readDir reads the children of a directory: directories first, then files,
+// each sorted by name. The .git directory is left out.
+func readDir(parent *treeNode) ([]*treeNode, error) {
+	entries, err := os.ReadDir(parent.path)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*treeNode, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		dir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(parent.path, entry.Name())); err == nil {
+				dir = info.IsDir()
+			}
+		}
+		nodes = append(nodes, &treeNode{
+			path:  filepath.Join(parent.path, entry.Name()),
+			name:  entry.Name(),
+			dir:   dir,
+			depth: parent.depth + 1,
+		})
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].dir != nodes[j].dir {
+			return nodes[i].dir
+		}
+		return strings.ToLower(nodes[i].name) < strings.ToLower(nodes[j].name)
+	})
+	return nodes, nil
+}
+
+// expand opens a directory node, reading it the first time.
+func (t *fileTree) expand(node *treeNode) error {
+	if !node.dir {
+		return nil
+	}
+	if !node.loaded {
+		children, err := readDir(node)
+		if err != nil {
+			return err
+		}
+		node.children, node.loaded = children, true
+	}
+	node.expanded = true
+	return nil
+}
+
+// rebuild lists the visible nodes after expanding or collapsing.
+func (t *fileTree) rebuild() {
+	t.rows = t.rows[:0]
+	var walk func(node *treeNode)
+	walk = func(node *treeNode) {
+		for _, child := range node.children {
+			t.rows = append(t.rows, child)
+			if child.expanded {
+				walk(child)
+			}
+		}
+	}
+	walk(t.root)
+	t.selected = max(0, min(t.selected, len(t.rows)-1))
+}
+
+// refresh rereads the expanded directories, keeping what is expanded and
+// selected where the files still exist.
+func (t *fileTree) refresh() {
+	expanded := make(map[string]bool)
+	var collect func(node *treeNode)
+	collect = func(node *treeNode) {
+		if node.expanded {
+			expanded[node.path] = true
+			for _, child := range node.children {
+				collect(child)
+			}
+		}
+	}
+	collect(t.root)
+	selected := t.selectedPath()
+
+	var reload func(node *treeNode)
+	reload = func(node *treeNode) {
+		node.loaded = false
+		if t.expand(node) != nil {
+			node.expanded, node.children = false, nil
+			return
+		}
+		for _, child := range node.children {
+			if expanded[child.path] {
+				reload(child)
+			}
+		}
+	}
+	reload(t.root)
+	t.rebuild()
+	t.selectPath(selected)
+}
+
+// selectedNode returns the selected node, or nil for an empty tree.
+func (t *fileTree) selectedNode() *treeNode {
+	if t.selected < len(t.rows) {
+		return t.rows[t.selected]
+	}
+	return nil
+}
+
+// selectedPath returns the path of the selected node, or "".
+func (t *fileTree) selectedPath() string {
+	if node := t.selectedNode(); node != nil {
+		return node.path
+	}
+	return ""
+}
+
+// selectPath expands the directories leading to path and selects it.
+// Paths outside the tree are ignored.
+func (t *fileTree) selectPath(path string) bool {
+	rel, err := filepath.Rel(t.root.path, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	node := t.root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if t.expand(node) != nil {
+			return false
+		}
+		var next *treeNode
+		for _, child := range node.children {
+			if child.name == part {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return false
+		}
+		node = next
+	}
+	t.rebuild()
+	for i, row := range t.rows {
+		if row == node {
+			t.selected = i
+			return true
+		}
+	}
+	return false
+}
+
+// parentIndex returns the row of a node's parent directory, or -1.
+func (t *fileTree) parentIndex(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if t.rows[j].depth < t.rows[i].depth {
+			return j
+		}
+	}
+	return -1
+}
+
+// relPath returns path relative to the tree root, for prompts.
+func (t *fileTree) relPath(path string) string {
+	if rel, err := filepath.Rel(t.root.path, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// resolve returns the path a name typed in a tree prompt names: relative
+// names are below the root.
+func (t *fileTree) resolve(name string) string {
+	name = expandHome(name)
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(t.root.path, filepath.FromSlash(name))
+}
+
+// OpenDirectory shows the file tree rooted at dir and focuses it, for
+// starting the editor on a directory.
+func (m *Model) OpenDirectory(dir string) {
+	m.tree = newFileTree(dir)
+	m.tree.visible = true
+	m.mode = ModeFileTree
+}
+
+// treeVisible reports whether the file tree sidebar is shown.
+func (m *Model) treeVisible() bool {
+	return m.tree != nil && m.tree.visible
+}
+
+// ensureFileTree creates the file tree at the project of the active file.
+func (m *Model) ensureFileTree() {
+	if m.tree != nil {
+		return
+	}
+	dir := "."
+	if m.filepath != "" {
+		dir = filepath.Dir(m.filepath)
+	}
+	m.tree = newFileTree(findProjectRoot(dir))
+}
+
+// toggleFileTree shows and focuses the file tree, focuses it when it is
+// shown but not focused, and hides it when it is focused.
+func (m *Model) toggleFileTree() {
+	m.ensureFileTree()
+	switch {
+	case !m.tree.visible:
+		m.tree.visible = true
+		m.focusFileTree()
+	case m.mode != ModeFileTree:
+		m.focusFileTree()
+	default:
+		m.tree.visible = false
+		m.mode = ModeNormal
+	}
+}
+
+// focusFileTree moves the focus to the tree, selecting the active file.
+func (m *Model) focusFileTree() {
+	m.tree.refresh()
+	if m.filepath != "" {
+		if abs, err := filepath.Abs(m.filepath); err == nil {
+			m.tree.selectPath(abs)
+		}
+	}
+	m.mode = ModeFileTree
+}
+
+// browseForPrompt shows the file tree to pick a file for the current
+// prompt (Ctrl+T in the open and save prompts, as in nano).
+func (m *Model) browseForPrompt() {
+	m.ensureFileTree()
+	m.tree.prompt, m.tree.promptText = m.mode, m.inputPrompt
+	m.tree.hideAfter = !m.tree.visible
+	m.tree.visible = true
+	m.tree.refresh()
+	if m.inputBuffer != "" {
+		if abs, err := filepath.Abs(m.inputBuffer); err == nil {
+			m.tree.selectPath(abs)
+		}
+	}
+	m.mode = ModeFileTree
+}
+
+// endBrowse returns from browsing to the prompt, filling in path if set.
+func (m *Model) endBrowse(path string) {
+	t := m.tree
+	m.mode, m.inputPrompt = t.prompt, t.promptText
+	if path != "" {
+		m.inputBuffer = displayPath(path)
+	}
+	if t.hideAfter {
+		t.visible = false
+	}
+	t.prompt, t.hideAfter = ModeNormal, false
+}
+
+// handleFileTreeInput handles input while the file tree is focused.
+func (m *Model) handleFileTreeInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := m.tree
+	if t == nil {
+		m.mode = ModeNormal
+		return m, nil
+	}
+	browsing := t.prompt != ModeNormal
+	node := t.selectedNode()
+
+	switch msg.String() {
+	case "esc", "ctrl+c", "ctrl+g":
+		if browsing {
+			m.endBrowse("")
+		} else {
+			m.mode = ModeNormal
+		}
+		return m, nil
+
+	case "f9":
+		if browsing {
+			m.endBrowse("")
+		} else {
+			m.toggleFileTree()
+		}
+		return m, nil
+
+	case "up", "ctrl+p", "k":
+		t.selected--
+	case "down", "ctrl+n", "j":
+		t.selected++
+	case "pgup":
+		t.selected -= max(1, m.pickerHeight()-1)
+	case "pgdown":
+		t.selected += max(1, m.pickerHeight()-1)
+	case "home":
+		t.selected = 0
+	case "end":
+		t.selected = len(t.rows) - 1
+
+	case "enter", " ":
+		if node == nil {
+			return m, nil
+		}
+		if node.dir {
+			m.toggleTreeNode(node)
+			return m, nil
+		}
+		if browsing {
+			m.endBrowse(node.path)
+			return m, nil
+		}
+		if m.selectFileTab(node.path) || m.openFileTab(node.path) {
+			m.mode = ModeNormal
+		}
+		return m, nil
+
+	case "right", "l":
+		if node != nil && node.dir {
+			if !node.expanded {
+				m.toggleTreeNode(node)
+			} else if len(node.children) > 0 {
+				t.selected++
+			}
+		}
+	case "left", "h":
+		if node != nil && node.dir && node.expanded {
+			m.toggleTreeNode(node)
+		} else if parent := t.parentIndex(t.selected); parent >= 0 {
+			t.selected = parent
+		}
+
+	case "<":
+		t.width = max(minTreeWidth, t.width-treeWidthStep)
+	case ">":
+		t.width = min(max(minTreeWidth, m.width-minEditorWidth-1), t.width+treeWidthStep)
+
+	case "a":
+		if !browsing {
+			m.openTreePrompt(ModeTreeCreate, "New file (end with / for a directory): ", m.treeCreateDir())
+		}
+	case "r":
+		if !browsing && node != nil {
+			m.openTreePrompt(ModeTreeRename, "Rename to: ", t.relPath(node.path))
+		}
+	case "d", "delete":
+		if !browsing && node != nil {
+			m.mode = ModeTreeDelete
+			if node.dir {
+				m.SetStatusMessage(fmt.Sprintf("Delete %s/ and everything in it? (Y)es, (N)o", node.name))
+			} else {
+				m.SetStatusMessage(fmt.Sprintf("Delete %s? (Y)es, (N)o", node.name))
+			}
+		}
+	}
+	t.selected = max(0, min(t.selected, len(t.rows)-1))
+	return m, nil
+}
+
+// toggleTreeNode expands or collapses a directory.
+func (m *Model) toggleTreeNode(node *treeNode) {
+	if node.expanded {
+		node.expanded = false
+	} else if err := m.tree.expand(node); err != nil {
+		m.SetStatusMessage("Error reading directory: " + err.Error())
+		return
+	}
+	m.tree.rebuild()
+}
+
+// treeCreateDir returns the directory new files are created in: the
+// selected directory, or the directory of the selected file, relative to
+// the root and ending with a separator.
+func (m *Model) treeCreateDir() string {
+	t := m.tree
+	node := t.selectedNode()
+	if node == nil {
+		return ""
+	}
+	dir := node.path
+	if !node.dir {
+		dir = filepath.Dir(dir)
+	}
+	if dir == t.root.path {
+		return ""
+	}
+	return t.relPath(dir) + string(filepath.Separator)
+}
+
+// openTreePrompt asks for a path for a file tree operation.
+func (m *Model) openTreePrompt(mode Mode, prompt, text string) {
+	m.mode = mode
+	m.inputPrompt = prompt
+	m.inputBuffer = text
+}
+
+// handleTreePromptInput handles input in the create and rename prompts of
+// the file tree.
+func (m *Model) handleTreePromptInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Completion offers directories: the name itself is new
+	if m.handlePathCompletion(msg, m.tree.root.path, true) {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "enter":
+		name := strings.TrimSpace(m.inputBuffer)
+		mode := m.mode
+		m.mode = ModeFileTree
+		m.inputBuffer = ""
+		if name == "" {
+			return m, nil
+		}
+		if mode == ModeTreeCreate {
+			m.treeCreate(name)
+		} else {
+			m.treeRename(name)
+		}
+		return m, nil
+
+	case "esc", "ctrl+c":
+		m.mode = ModeFileTree
+		m.inputBuffer = ""
+		m.SetStatusMessage("")
+		return m, nil
+
+	case "backspace":
+		if len(m.inputBuffer) > 0 {
+			runes := []rune(m.inputBuffer)
+			m.inputBuffer = string(runes[:len(runes)-1])
+		}
+		return m, nil
+
+	default:
+		if len(msg.Runes) > 0 {
+			m.inputBuffer += string(msg.Runes)
+		}
+		return m, nil
+	}
+}
+
+// treeCreate creates a file, or a directory when name ends with a
+// separator, relative to the tree root. New files are opened in a tab.
+func (m *Model) treeCreate(name string) {
+	t := m.tree
+	isDir := strings.HasSuffix(name, "/") || strings.HasSuffix(name, string(filepath.Separator))
+	path := t.resolve(name)
+
+	var err error
+	if isDir {
+		err = os.MkdirAll(path, 0755)
+	} else if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644); err == nil {
+			err = f.Close()
+		}
+	}
+	if err != nil {
+		m.SetStatusMessage("Error creating file: " + err.Error())
+		return
+	}
+
+	t.refresh()
+	t.selectPath(path)
+	if isDir {
+		m.SetStatusMessage("Created: " + t.relPath(path))
+		return
+	}
+	if m.openFileTab(path) {
+		m.mode = ModeNormal
+		m.SetStatusMessage("Created: " + t.relPath(path))
+	}
+}
+
+// treeRename renames the selected file or directory. Tabs showing the
+// renamed files follow them.
+func (m *Model) treeRename(name string) {
+	t := m.tree
+	node := t.selectedNode()
+	if node == nil {
+		return
+	}
+	path := t.resolve(name)
+	if path == node.path {
+		return
+	}
+	if _, err := os.Lstat(path); err == nil {
+		m.SetStatusMessage("Error renaming: " + t.relPath(path) + " already exists")
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		m.SetStatusMessage("Error renaming: " + err.Error())
+		return
+	}
+	if err := os.Rename(node.path, path); err != nil {
+		m.SetStatusMessage("Error renaming: " + err.Error())
+		return
+	}
+
+	m.syncToActiveTab()
+	for _, tab := range m.tabs.Tabs() {
+		if newPath, ok := renamedPath(tab.filepath, node.path, path); ok {
+			tab.filepath, tab.filename = newPath, filepath.Base(newPath)
+		}
+	}
+	m.syncFromActiveTab()
+
+	t.refresh()
+	t.selectPath(path)
+	m.SetStatusMessage("Renamed to: " + t.relPath(path))
+}
+
+// renamedPath returns where file is after renaming oldPath to newPath,
+// which may be a directory containing it.
+func renamedPath(file, oldPath, newPath string) (string, bool) {
+	abs, err := filepath.Abs(file)
+	if file == "" || err != nil {
+		return "", false
+	}
+	if abs == oldPath {
+		return newPath, true
+	}
+	if rel, err := filepath.Rel(oldPath, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join(newPath, rel), true
+	}
+	return "", false
+}
+
+// handleTreeDeleteInput handles the confirmation of a file tree delete.
+func (m *Model) handleTreeDeleteInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.mode = ModeFileTree
+		node := m.tree.selectedNode()
+		if node == nil {
+			return m, nil
+		}
+		if err := os.RemoveAll(node.path); err != nil {
+			m.SetStatusMessage("Error deleting: " + err.Error())
+			return m, nil
+		}
+		m.tree.refresh()
+		m.SetStatusMessage("Deleted: " + m.tree.relPath(node.path))
+	case "n", "N", "esc", "ctrl+c":
+		m.mode = ModeFileTree
+		m.SetStatusMessage("")
+	}
+	return m, nil
+}
+
+// treeWidth returns the width of the sidebar for the current window,
+// leaving the editor at least minEditorWidth columns.
+func (m *Model) treeWidth() int {
+	return max(1, min(m.tree.width, m.width-minEditorWidth-1))
+}
+
+// renderWithFileTree renders the editor area with the file tree on its
+// left. The editor, split or not, is laid out in the remaining width.
+func (m *Model) renderWithFileTree() string {
+	t := m.tree
+	treeWidth := m.treeWidth()
+	editorWidth := m.width - treeWidth - 1
+
+	width := m.width
+	m.width = editorWidth
+	var editor string
+	if m.IsSplit() {
+		editor = m.renderSplitEditor()
+	} else {
+		editor = m.renderEditor()
+	}
+	m.width = width
+	lines := strings.Split(strings.TrimSuffix(editor, "\n"), "\n")
+
+	// The first row names the root directory
+	rows := len(lines) - 1
+	if t.selected < t.top {
+		t.top = t.selected
+	}
+	if t.selected >= t.top+rows {
+		t.top = t.selected - rows + 1
+	}
+	focused := m.mode == ModeFileTree
+
+	separator := styles.TabInactiveStyle.Render("│")
+	if focused {
+		separator = styles.TabActiveStyle.Render("│")
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i == 0 {
+			b.WriteString(gutterMarkerStyle.Render(padOrTruncate(" "+t.root.name+"/", treeWidth)))
+		} else if idx := t.top + i - 1; idx < len(t.rows) {
+			b.WriteString(renderTreeRow(t.rows[idx], idx == t.selected && focused, treeWidth))
+		} else {
+			b.WriteString(strings.Repeat(" ", treeWidth))
+		}
+		b.WriteString(separator)
+		b.WriteString(ansi.Truncate(line, editorWidth, ""))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderTreeRow renders a node of the tree, indented by its depth.
+func renderTreeRow(node *treeNode, selected bool, width int) string {
+	marker := "  "
+	name := node.name
+	if node.dir {
+		marker = "▸ "
+		if node.expanded {
+			marker = "▾ "
+		}
+		name += "/"
+	}
+	text := padOrTruncate(" "+strings.Repeat("  ", node.depth)+marker+name, width)
+	switch {
+	case selected:
+		return selectionStyle.Render(text)
+	case node.dir:
+		return gutterMarkerStyle.Render(text)
+	}
+	return editorStyle.Render(text)
+}
+
+// handleTreeClick selects the tree row under a mouse click and focuses the
+// tree. It reports whether the click was on the tree.
+func (m *Model) handleTreeClick(msg tea.MouseMsg) bool {
+	if msg.X >= m.treeWidth() {
+		return false
+	}
+	if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress {
+		// Rows start below the header and the root directory row
+		first := 2
+		if m.showTabs && m.TabCount() > 1 {
+			first++
+		}
+		if idx := m.tree.top + msg.Y - first; msg.Y >= first && idx < len(m.tree.rows) {
+			m.tree.selected = idx
+		}
+		m.mode = ModeFileTree
+	}
+	return true
+}
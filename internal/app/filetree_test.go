@@ -0,0 +1,152 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// treeNames returns the indented names of the visible tree rows.
+func treeNames(t *fileTree) []string {
+	names := make([]string, len(t.rows))
+	for i, node := range t.rows {
+		names[i] = strings.Repeat(" ", node.depth) + node.name
+	}
+	return names
+}
+
+func TestFileTree(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":        "package main\n",
+		"README.md":      "# readme\n",
+		"cmd/tool.go":    "package main\n",
+		".git/HEAD":      "ref: refs/heads/main\n",
+		"cmd/sub/x.txt":  "x\n",
+		"docs/guide.md":  "guide\n",
+		"docs/.keep":     "",
+		"assets/logo.sv": "<svg/>\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := New()
+	m.SetSize(100, 20)
+	m.OpenDirectory(dir)
+	tree := m.tree
+
+	want := "assets cmd docs main.go README.md"
+	if got := strings.Join(treeNames(tree), " "); got != want {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+
+	// Expand cmd and open its file
+	tree.selected = 1
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	want = "assets cmd  sub  tool.go docs main.go README.md"
+	if got := strings.Join(treeNames(tree), " "); got != want {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeNormal || m.filename != "tool.go" {
+		t.Errorf("mode = %v, file %q, want tool.go open in the editor", m.mode, m.filename)
+	}
+	view := m.View()
+	if !strings.Contains(view, "▾ cmd/") || !strings.Contains(view, "package main") {
+		t.Errorf("view does not show the tree and the file:\n%s", view)
+	}
+
+	// F9 focuses the tree on the active file; Left goes to its directory
+	m.Update(tea.KeyMsg{Type: tea.KeyF9})
+	if m.mode != ModeFileTree || tree.selectedNode().name != "tool.go" {
+		t.Fatalf("mode = %v, want the tree focused on tool.go", m.mode)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if got := strings.Join(treeNames(tree), " "); got != "assets cmd docs main.go README.md" {
+		t.Errorf("rows after collapsing = %q", got)
+	}
+
+	// Rename the directory; the open tab follows its file
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	if m.mode != ModeTreeRename || m.inputBuffer != "cmd" {
+		t.Fatalf("mode = %v, input %q, want the rename prompt", m.mode, m.inputBuffer)
+	}
+	m.inputBuffer = ""
+	typeText(m, "tools")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, err := os.Stat(filepath.Join(dir, "tools", "tool.go")); err != nil {
+		t.Errorf("rename: %v (%s)", err, m.statusMessage)
+	}
+	if want := filepath.Join(dir, "tools", "tool.go"); m.tabs.Tabs()[1].filepath != want {
+		t.Errorf("tab path = %q, want %q", m.tabs.Tabs()[1].filepath, want)
+	}
+
+	// Create a file in the selected directory; it opens in a tab
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if m.inputBuffer != "tools"+string(filepath.Separator) {
+		t.Errorf("create prompt = %q", m.inputBuffer)
+	}
+	typeText(m, "new.go")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, err := os.Stat(filepath.Join(dir, "tools", "new.go")); err != nil || m.filename != "new.go" {
+		t.Errorf("create: %v, active %q (%s)", err, m.filename, m.statusMessage)
+	}
+
+	// Delete asks first
+	m.Update(tea.KeyMsg{Type: tea.KeyF9})
+	if tree.selectedNode().name != "new.go" {
+		t.Fatalf("selected %q, want new.go", tree.selectedNode().name)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if _, err := os.Stat(filepath.Join(dir, "tools", "new.go")); err != nil {
+		t.Fatal("deleted without confirmation")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if _, err := os.Stat(filepath.Join(dir, "tools", "new.go")); !os.IsNotExist(err) {
+		t.Errorf("new.go still exists: %v", err)
+	}
+	if m.mode != ModeFileTree {
+		t.Errorf("mode = %v, want the tree focused", m.mode)
+	}
+
+	// F9 on the focused tree hides it
+	m.Update(tea.KeyMsg{Type: tea.KeyF9})
+	if m.treeVisible() || m.mode != ModeNormal {
+		t.Error("F9 did not hide the tree")
+	}
+}
+
+func TestFileTreeBrowse(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewFromFile(filepath.Join(dir, "main.go"), "main.go", "")
+	m.SetSize(100, 20)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if m.mode != ModeFileTree || !m.treeVisible() {
+		t.Fatalf("mode = %v, want the tree for browsing", m.mode)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeOpen || !strings.HasSuffix(m.inputBuffer, "notes.txt") {
+		t.Errorf("mode = %v, input %q, want the prompt filled in", m.mode, m.inputBuffer)
+	}
+	if m.treeVisible() {
+		t.Error("the tree stayed open after browsing")
+	}
+}
@@ -0,0 +1,383 @@
+// Package app provides the fuzzy file finder with a preview.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/file"
+	"github.com/KilimcininKorOglu/gesh/internal/fuzzy"
+	"github.com/KilimcininKorOglu/gesh/internal/project"
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+// maxFinderFiles limits the files indexed by the file finder.
+const maxFinderFiles = 100000
+
+// finderPreviewSize is how much of a file the finder reads for its preview.
+const finderPreviewSize = 64 * 1024
+
+// errTooManyFiles stops indexing at maxFinderFiles.
+var errTooManyFiles = errors.New("too many files")
+
+// fileFinder is the fuzzy file finder overlay.
+type fileFinder struct {
+	root      string
+	files     []string // slash-separated paths relative to root
+	indexing  bool
+	truncated bool               // indexing stopped at maxFinderFiles
+	cancel    context.CancelFunc // stops indexing
+
+	query    string
+	results  []fuzzy.Result
+	selected int // index into results
+	top      int // first visible result
+
+	previewPath  string   // file the preview is for, possibly still loading
+	previewLines []string // first lines of the file
+	previewNote  string   // shown instead of lines, e.g. for binary files
+}
+
+// fileIndexMsg delivers the files of a project to the finder.
+type fileIndexMsg struct {
+	finder    *fileFinder
+	files     []string
+	truncated bool
+	err       error
+}
+
+// finderPreviewMsg delivers the preview of a file to the finder.
+type finderPreviewMsg struct {
+	finder *fileFinder
+	path   string
+	lines  []string
+	note   string
+}
+
+// openFileFinder shows the file finder and indexes the project of the
+// active file.
+func (m *Model) openFileFinder() tea.Cmd {
+	dir := "."
+	if m.filepath != "" {
+		dir = filepath.Dir(m.filepath)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &fileFinder{root: findProjectRoot(dir), indexing: true, cancel: cancel}
+	m.finder = f
+	m.mode = ModeFileFinder
+	return indexFiles(ctx, f)
+}
+
+// indexFiles lists the files of the finder's project in the background,
+// until ctx is cancelled.
+func indexFiles(ctx context.Context, f *fileFinder) tea.Cmd {
+	root := f.root
+	return func() tea.Msg {
+		var files []string
+		err := project.Walk(ctx, root, func(path string) error {
+			if len(files) == maxFinderFiles {
+				return errTooManyFiles
+			}
+			if rel, err := filepath.Rel(root, path); err == nil {
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if errors.Is(err, errTooManyFiles) {
+			return fileIndexMsg{finder: f, files: files, truncated: true}
+		}
+		return fileIndexMsg{finder: f, files: files, err: err}
+	}
+}
+
+// finishFileIndex fills the finder with the indexed files.
+func (m *Model) finishFileIndex(msg fileIndexMsg) tea.Cmd {
+	f := msg.finder
+	if f != m.finder {
+		return nil // the finder was closed
+	}
+	f.cancel()
+	f.files, f.truncated, f.indexing = msg.files, msg.truncated, false
+	if msg.err != nil {
+		m.SetStatusMessage("Error listing files: " + msg.err.Error())
+	}
+	f.filter()
+	return f.loadPreview()
+}
+
+// filter ranks the files for the current query. Without a query all
+// files are listed in path order.
+func (f *fileFinder) filter() {
+	if f.query == "" {
+		f.results = make([]fuzzy.Result, len(f.files))
+		for i := range f.files {
+			f.results[i] = fuzzy.Result{Index: i}
+		}
+	} else {
+		f.results = fuzzy.Filter(f.query, f.files)
+	}
+	f.selected = 0
+	f.top = 0
+}
+
+// selectedPath returns the absolute path of the selected file, if any.
+func (f *fileFinder) selectedPath() (string, bool) {
+	if f.selected >= len(f.results) {
+		return "", false
+	}
+	return filepath.Join(f.root, filepath.FromSlash(f.files[f.results[f.selected].Index])), true
+}
+
+// handleFileFinderInput handles input while the file finder is shown.
+func (m *Model) handleFileFinderInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := m.finder
+	if f == nil {
+		m.mode = ModeNormal
+		return m, nil
+	}
+	pageSize := m.pickerHeight() - 1
+
+	switch msg.String() {
+	case "esc", "ctrl+c", "ctrl+g":
+		m.closeFileFinder()
+		m.SetStatusMessage("Cancelled")
+		return m, nil
+
+	case "enter", "alt+enter":
+		path, ok := f.selectedPath()
+		if !ok {
+			return m, nil
+		}
+		m.closeFileFinder()
+		if msg.String() == "enter" {
+			if !m.selectFileTab(path) {
+				m.openFileTab(path)
+			}
+		} else {
+			m.openFileHere(path)
+		}
+		return m, nil
+
+	case "up", "ctrl+p", "shift+tab":
+		f.selected--
+	case "down", "ctrl+n", "tab":
+		f.selected++
+	case "pgup", "ctrl+y":
+		f.selected -= pageSize
+	case "pgdown", "ctrl+v":
+		f.selected += pageSize
+
+	case "backspace", "ctrl+h":
+		if len(f.query) > 0 {
+			runes := []rune(f.query)
+			f.query = string(runes[:len(runes)-1])
+			f.filter()
+		}
+
+	default:
+		if len(msg.Runes) > 0 && !msg.Alt {
+			f.query += string(msg.Runes)
+			f.filter()
+		}
+	}
+	f.selected = max(0, min(f.selected, len(f.results)-1))
+	return m, f.loadPreview()
+}
+
+// closeFileFinder hides the file finder, stopping indexing if it has not
+// finished.
+func (m *Model) closeFileFinder() {
+	if m.finder != nil {
+		m.finder.cancel()
+	}
+	m.finder = nil
+	m.mode = ModeNormal
+}
+
+// openFileHere opens a file in the current pane. With a split view the
+// active pane shows the file's tab; otherwise the file replaces the
+// active buffer unless it has unsaved changes.
+func (m *Model) openFileHere(path string) {
+	if m.IsSplit() {
+		if m.selectFileTab(path) || m.openFileTab(path) {
+			m.SetPaneTab(m.tabs.ActiveIndex())
+		}
+		return
+	}
+	if m.selectFileTab(path) {
+		return
+	}
+	if m.modified {
+		m.SetStatusMessage("Buffer has unsaved changes; press Enter to open in a new tab")
+		return
+	}
+	info, err := file.LoadWithInfo(path)
+	if err != nil {
+		m.SetStatusMessage("Error opening file: " + err.Error())
+		return
+	}
+	if err := m.ReplaceTabWithFile(path, filepath.Base(path), info.Content, string(info.Encoding), string(info.LineEnding)); err != nil {
+		m.SetStatusMessage("Error saving bookmarks: " + err.Error())
+		return
+	}
+	m.SetStatusMessage("Opened: " + m.filename)
+}
+
+// loadPreview returns a command reading the selected file for the preview
+// when the selection changed, or nil.
+func (f *fileFinder) loadPreview() tea.Cmd {
+	path, ok := f.selectedPath()
+	if !ok || path == f.previewPath {
+		return nil
+	}
+	f.previewPath, f.previewLines, f.previewNote = path, nil, ""
+	return func() tea.Msg {
+		lines, note := readPreview(path)
+		return finderPreviewMsg{finder: f, path: path, lines: lines, note: note}
+	}
+}
+
+// finishPreview shows a loaded preview if its file is still selected.
+func (m *Model) finishPreview(msg finderPreviewMsg) {
+	f := msg.finder
+	if f != m.finder || msg.path != f.previewPath {
+		return // the finder was closed or the selection moved on
+	}
+	f.previewLines, f.previewNote = msg.lines, msg.note
+}
+
+// readPreview reads the first lines of a file, or a note to show instead.
+func readPreview(path string) (lines []string, note string) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err.Error()
+	}
+	defer fh.Close()
+	data, err := io.ReadAll(io.LimitReader(fh, finderPreviewSize))
+	switch {
+	case err != nil:
+		return nil, err.Error()
+	case project.IsBinary(data):
+		return nil, "Binary file"
+	case len(data) == 0:
+		return nil, "Empty file"
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(text, "\n"), ""
+}
+
+// renderFileFinder renders the finder in place of the editor area: the
+// ranked files on the left and a preview of the selected one on the right.
+func (m *Model) renderFileFinder() string {
+	f := m.finder
+	height := m.pickerHeight()
+	var b strings.Builder
+
+	title := fmt.Sprintf(" Find file in %s (%d/%d)", displayPath(f.root), len(f.results), len(f.files))
+	switch {
+	case f.indexing:
+		title += " indexing..."
+	case f.truncated:
+		title += fmt.Sprintf(" (first %d files)", maxFinderFiles)
+	}
+	b.WriteString(headerStyle.Width(m.width).Render(title))
+	b.WriteString("\n")
+
+	rows := height - 1
+	if f.selected < f.top {
+		f.top = f.selected
+	}
+	if f.selected >= f.top+rows {
+		f.top = f.selected - rows + 1
+	}
+
+	// The preview takes the right half when there is room for it
+	listWidth, previewWidth := m.width, 0
+	if m.width >= 60 {
+		listWidth = m.width * 2 / 5
+		previewWidth = m.width - listWidth - 1
+	}
+	var highlighter *syntax.Highlighter
+	if previewWidth > 0 && f.previewLines != nil {
+		if lang := syntax.DetectLanguage(filepath.Base(f.previewPath)); lang != nil {
+			highlighter = syntax.New(lang)
+		}
+	}
+
+	for i := 0; i < rows; i++ {
+		idx := f.top + i
+		if idx < len(f.results) {
+			b.WriteString(m.renderFinderResult(f, f.results[idx], idx == f.selected, listWidth))
+		} else {
+			b.WriteString(editorStyle.Render(strings.Repeat(" ", listWidth)))
+		}
+		if previewWidth > 0 {
+			b.WriteString(helpStyle.Render("│"))
+			b.WriteString(renderPreviewLine(f, i, previewWidth, highlighter))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderFinderResult renders a file of the list with its matched
+// characters highlighted.
+func (m *Model) renderFinderResult(f *fileFinder, result fuzzy.Result, selected bool, width int) string {
+	runes := []rune(" " + f.files[result.Index])
+	if len(runes) > width {
+		runes = runes[:width]
+	}
+	if selected {
+		return selectionStyle.Render(padOrTruncate(string(runes), width))
+	}
+
+	matched := make(map[int]bool, len(result.Positions))
+	for _, pos := range result.Positions {
+		matched[pos+1] = true // after the leading space
+	}
+	var b strings.Builder
+	for i, r := range runes {
+		if matched[i] {
+			b.WriteString(searchMatchStyle.Render(string(r)))
+		} else {
+			b.WriteString(editorStyle.Render(string(r)))
+		}
+	}
+	b.WriteString(editorStyle.Render(strings.Repeat(" ", width-len(runes))))
+	return b.String()
+}
+
+// renderPreviewLine renders one line of the preview, highlighted when the
+// file's language is known.
+func renderPreviewLine(f *fileFinder, row, width int, highlighter *syntax.Highlighter) string {
+	if f.previewLines == nil {
+		if row == 0 && f.previewNote != "" {
+			return helpStyle.Render(padOrTruncate(" "+f.previewNote, width))
+		}
+		return strings.Repeat(" ", width)
+	}
+	if row >= len(f.previewLines) {
+		return strings.Repeat(" ", width)
+	}
+
+	line := strings.ReplaceAll(f.previewLines[row], "\t", "    ")
+	if runes := []rune(line); len(runes) > width-1 {
+		line = string(runes[:width-1])
+	}
+	rendered := editorStyle.Render(line)
+	if highlighter != nil {
+		var b strings.Builder
+		for _, token := range highlighter.Highlight(line) {
+			b.WriteString(getSyntaxStyle(token.Type).Render(token.Text))
+		}
+		rendered = b.String()
+	}
+	return " " + rendered + strings.Repeat(" ", width-1-len([]rune(line)))
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFileFinder(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                 "module example\n",
+		".gitignore":             "build/\n",
+		"main.go":                "package main\n",
+		"internal/app/model.go":  "package app\n\ntype Model struct{}\n",
+		"internal/app/modes.txt": "normal\n",
+		"build/model.go":         "package build\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewFromFile(filepath.Join(dir, "main.go"), "main.go", files["main.go"])
+	m.SetSize(100, 20)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}, Alt: true})
+	if m.mode != ModeFileFinder || cmd == nil {
+		t.Fatalf("mode = %v, want the file finder", m.mode)
+	}
+	m.Update(cmd())
+	if got := len(m.finder.files); got != 5 {
+		t.Errorf("indexed %d files, want 5 (build/ is ignored): %v", got, m.finder.files)
+	}
+
+	// The preview is read by a command whenever the selection changes
+	var previews []tea.Cmd
+	for _, r := range "appmodel" {
+		if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}); cmd != nil {
+			previews = append(previews, cmd)
+		}
+	}
+	f := m.finder
+	if len(f.results) != 1 || f.files[f.results[0].Index] != "internal/app/model.go" {
+		t.Fatalf("results for %q = %v", f.query, f.results)
+	}
+	if len(previews) == 0 {
+		t.Fatal("no preview was loaded")
+	}
+	if strings.Contains(m.renderFileFinder(), "type Model struct{}") {
+		t.Error("the preview was read while rendering")
+	}
+	for _, cmd := range previews {
+		m.Update(cmd()) // earlier selections are dropped
+	}
+	view := m.renderFileFinder()
+	if !strings.Contains(view, "type Model struct{}") {
+		t.Errorf("preview does not show the file:\n%s", view)
+	}
+
+	// Enter opens the file in a new tab
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeNormal || m.finder != nil {
+		t.Errorf("mode = %v, want the finder closed", m.mode)
+	}
+	if m.tabs.Count() != 2 || m.filename != "model.go" {
+		t.Errorf("%d tabs, active %q, want model.go in a new tab", m.tabs.Count(), m.filename)
+	}
+
+	// Alt+Enter replaces the unmodified buffer, saving its bookmarks
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m.moveToLineColumn(2, 0)
+	m.toggleBookmark()
+	replaced := m.tabs.ActiveTab()
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}, Alt: true})
+	m.Update(cmd())
+	typeText(m, "modes")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	if m.tabs.Count() != 2 || m.filename != "modes.txt" {
+		t.Errorf("%d tabs, active %q, want modes.txt in place of model.go", m.tabs.Count(), m.filename)
+	}
+	if replaced.bookmarksChanged {
+		t.Error("bookmarks of the replaced file were not saved")
+	}
+	reopened := NewFromFile(replaced.filepath, "model.go", files["internal/app/model.go"])
+	if got := reopened.tabBookmarks(reopened.tabs.ActiveTab()); len(got) != 1 || got[0] != 2 {
+		t.Errorf("saved bookmarks of model.go = %v, want [2]", got)
+	}
+}
+
+func TestFileFinderCancel(t *testing.T) {
+	m := New()
+	m.SetSize(80, 20)
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}, Alt: true})
+	finder := m.finder
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != ModeNormal || m.finder != nil {
+		t.Fatalf("mode = %v, want the finder closed", m.mode)
+	}
+	// Closing stops indexing
+	if msg := cmd().(fileIndexMsg); !errors.Is(msg.err, context.Canceled) {
+		t.Errorf("indexing after closing = %v, want it cancelled", msg.err)
+	}
+	// Files indexed after closing are dropped
+	m.finishFileIndex(fileIndexMsg{finder: finder, files: []string{"a"}})
+	if m.finder != nil {
+		t.Error("a closed finder was reopened")
+	}
+}
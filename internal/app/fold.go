@@ -0,0 +1,276 @@
+// Package app provides code folding by indentation and bracket structure.
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// hiddenRange is an inclusive range of lines hidden by folds.
+type hiddenRange struct {
+	start, end int
+}
+
+// activeFolds returns the folded regions of the active tab.
+func (m *Model) activeFolds() map[int]int {
+	tab := m.tabs.ActiveTab()
+	if tab == nil {
+		return nil
+	}
+	if tab.folds == nil {
+		tab.folds = make(map[int]int)
+	}
+	return tab.folds
+}
+
+// hiddenRanges returns the lines hidden by folds of the active tab,
+// sorted and merged.
+func (m *Model) hiddenRanges() []hiddenRange {
+	return mergeFolds(m.activeFolds())
+}
+
+// mergeFolds converts folded regions into sorted, non-overlapping hidden ranges.
+func mergeFolds(folds map[int]int) []hiddenRange {
+	if len(folds) == 0 {
+		return nil
+	}
+	ranges := make([]hiddenRange, 0, len(folds))
+	for start, end := range folds {
+		ranges = append(ranges, hiddenRange{start + 1, end})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.start <= last.end+1 {
+			if r.end > last.end {
+				last.end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// isHidden reports whether line is inside one of the hidden ranges.
+func isHidden(ranges []hiddenRange, line int) bool {
+	for _, r := range ranges {
+		if line < r.start {
+			return false
+		}
+		if line <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleRow returns the number of visible lines before line.
+func visibleRow(ranges []hiddenRange, line int) int {
+	row := line
+	for _, r := range ranges {
+		if r.start > line {
+			break
+		}
+		if r.end < line {
+			row -= r.end - r.start + 1
+		} else {
+			row -= line - r.start
+			break
+		}
+	}
+	return row
+}
+
+// lineAtRow returns the line displayed at the given visible row.
+func lineAtRow(ranges []hiddenRange, row int) int {
+	line := row
+	for _, r := range ranges {
+		if r.start > line {
+			break
+		}
+		line += r.end - r.start + 1
+	}
+	return line
+}
+
+// visibleLine returns line, or the fold start line if line is hidden.
+func visibleLine(ranges []hiddenRange, line int) int {
+	for _, r := range ranges {
+		if line >= r.start && line <= r.end {
+			return r.start - 1
+		}
+	}
+	return line
+}
+
+// foldRegion returns the last line hidden when folding at line. Lines ending
+// with an opening bracket fold up to the line before the matching bracket,
+// other lines fold the following block of deeper indented lines.
+func (bt *bracketText) foldRegion(line, tabSize int) (int, bool) {
+	text := strings.TrimRight(bt.lines[line], " \t")
+	if text == "" {
+		return 0, false
+	}
+
+	// Bracket structure
+	last, _ := utf8.DecodeLastRuneInString(text)
+	if isOpenBracket(last) {
+		col := utf8.RuneCountInString(text) - 1
+		if bt.isCode(line, col) {
+			if match, ok := bt.matchChar(bt.lineStarts[line] + col); ok {
+				if end := bt.lineOf(match.partnerStart) - 1; end > line {
+					return end, true
+				}
+			}
+		}
+	}
+
+	// Indentation
+	base := indentWidth(text, tabSize)
+	end := line
+	for ln := line + 1; ln < len(bt.lines); ln++ {
+		next := bt.lines[ln]
+		if strings.TrimSpace(next) == "" {
+			continue
+		}
+		if indentWidth(next, tabSize) <= base {
+			break
+		}
+		end = ln
+	}
+	return end, end > line
+}
+
+// indentWidth returns the display width of the leading whitespace of line.
+func indentWidth(line string, tabSize int) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += tabSize - width%tabSize
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// foldTabSize returns the tab width used to measure indentation for folding.
+func (m *Model) foldTabSize() int {
+	if m.tabSize < 1 {
+		return 4
+	}
+	return m.tabSize
+}
+
+// foldAtCursor folds the region starting at the cursor line, or the innermost
+// region containing it.
+func (m *Model) foldAtCursor() {
+	if m.highlighter == nil {
+		m.updateHighlighter()
+	}
+	bt := m.newBracketText()
+	folds := m.activeFolds()
+	line := m.buffer.CurrentLine()
+	tabSize := m.foldTabSize()
+
+	for start := line; start >= 0; start-- {
+		if _, folded := folds[start]; folded {
+			continue
+		}
+		end, ok := bt.foldRegion(start, tabSize)
+		if !ok || end < line {
+			continue
+		}
+		folds[start] = end
+		if start != line {
+			m.moveToLineColumn(start, 0)
+		}
+		m.clearSelection()
+		m.SetStatusMessage(fmt.Sprintf("Folded %d lines", end-start))
+		return
+	}
+	m.SetStatusMessage("Nothing to fold")
+}
+
+// unfoldAtCursor unfolds the region starting at the cursor line.
+func (m *Model) unfoldAtCursor() bool {
+	folds := m.activeFolds()
+	line := m.buffer.CurrentLine()
+	if _, ok := folds[line]; !ok {
+		return false
+	}
+	delete(folds, line)
+	m.SetStatusMessage("Unfolded")
+	return true
+}
+
+// toggleFold unfolds the region at the cursor line, or folds it.
+func (m *Model) toggleFold() {
+	if !m.unfoldAtCursor() {
+		m.foldAtCursor()
+	}
+}
+
+// toggleAllFolds unfolds everything if anything is folded, otherwise folds
+// all top-level regions.
+func (m *Model) toggleAllFolds() {
+	tab := m.tabs.ActiveTab()
+	if tab == nil {
+		return
+	}
+	if len(tab.folds) > 0 {
+		tab.folds = make(map[int]int)
+		m.SetStatusMessage("Unfolded all")
+		return
+	}
+
+	if m.highlighter == nil {
+		m.updateHighlighter()
+	}
+	bt := m.newBracketText()
+	tabSize := m.foldTabSize()
+	folds := m.activeFolds()
+	for line := 0; line < len(bt.lines); line++ {
+		if end, ok := bt.foldRegion(line, tabSize); ok {
+			folds[line] = end
+			line = end
+		}
+	}
+	if len(folds) == 0 {
+		m.SetStatusMessage("Nothing to fold")
+		return
+	}
+
+	// Keep the cursor on a visible line
+	cursorLine := m.buffer.CurrentLine()
+	if start := visibleLine(m.hiddenRanges(), cursorLine); start != cursorLine {
+		m.moveToLineColumn(start, 0)
+	}
+	m.clearSelection()
+	m.SetStatusMessage(fmt.Sprintf("Folded %d regions", len(folds)))
+}
+
+// revealCursor unfolds all regions hiding the cursor line.
+func (m *Model) revealCursor() {
+	if len(m.activeFolds()) > 0 {
+		m.revealLine(m.buffer.CurrentLine())
+	}
+}
+
+// revealLine unfolds all regions hiding line.
+func (m *Model) revealLine(line int) {
+	folds := m.activeFolds()
+	for start, end := range folds {
+		if line > start && line <= end {
+			delete(folds, start)
+		}
+	}
+}
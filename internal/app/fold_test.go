@@ -0,0 +1,157 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const foldGoSource = `package main
+
+func main() {
+	x := []int{
+		1,
+	}
+	_ = x
+}
+`
+
+func TestFoldRegion(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		line     int
+		wantEnd  int
+		wantOK   bool
+	}{
+		{"go function", "a.go", foldGoSource, 2, 6, true},
+		{"go literal", "a.go", foldGoSource, 3, 4, true},
+		{"go plain line", "a.go", foldGoSource, 6, 0, false},
+		{"yaml block", "a.yaml", "a:\n  b: 1\n\n  c: 2\nd: 3\n", 0, 3, true},
+		{"yaml leaf", "a.yaml", "a:\n  b: 1\nd: 3\n", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewFromFile(tt.filename, tt.filename, tt.content)
+			m.updateHighlighter()
+			end, ok := m.newBracketText().foldRegion(tt.line, 4)
+			if ok != tt.wantOK || (ok && end != tt.wantEnd) {
+				t.Errorf("foldRegion(%d) = %d, %v, want %d, %v", tt.line, end, ok, tt.wantEnd, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestVisibleRows(t *testing.T) {
+	ranges := mergeFolds(map[int]int{2: 4, 3: 4, 7: 8})
+
+	tests := []struct {
+		line int
+		row  int
+	}{
+		{0, 0}, {2, 2}, {5, 3}, {7, 5}, {9, 6},
+	}
+	for _, tt := range tests {
+		if got := visibleRow(ranges, tt.line); got != tt.row {
+			t.Errorf("visibleRow(%d) = %d, want %d", tt.line, got, tt.row)
+		}
+		if got := lineAtRow(ranges, tt.row); got != tt.line {
+			t.Errorf("lineAtRow(%d) = %d, want %d", tt.row, got, tt.line)
+		}
+	}
+	if !isHidden(ranges, 4) || isHidden(ranges, 5) {
+		t.Error("isHidden() disagrees with folded ranges")
+	}
+}
+
+func TestFoldCursorMovement(t *testing.T) {
+	m := NewFromFile("a.go", "a.go", foldGoSource)
+	m.moveToLineColumn(2, 0)
+	m.toggleFold()
+
+	m.moveCursorDown()
+	if got := m.buffer.CurrentLine(); got != 7 {
+		t.Errorf("after moving down cursor line = %d, want 7", got)
+	}
+	m.moveCursorUp()
+	if got := m.buffer.CurrentLine(); got != 2 {
+		t.Errorf("after moving up cursor line = %d, want 2", got)
+	}
+}
+
+func TestFoldSearchReveals(t *testing.T) {
+	m := NewFromFile("a.go", "a.go", foldGoSource)
+	m.toggleAllFolds()
+	if len(m.activeFolds()) == 0 {
+		t.Fatal("toggleAllFolds() folded nothing")
+	}
+
+	m.searchQuery = "_ = x"
+	m.findMatches()
+	m.goToMatch(0)
+	if len(m.activeFolds()) != 0 {
+		t.Errorf("folds = %v, want the match revealed", m.activeFolds())
+	}
+}
+
+func TestFoldCursorReveals(t *testing.T) {
+	m := NewFromFile("a.go", "a.go", foldGoSource)
+	m.SetSize(80, 20)
+	m.toggleAllFolds()
+	folds := len(m.activeFolds())
+
+	// Rendering does not change the folds
+	m.buffer.MoveTo(m.buffer.LineStart(4))
+	m.View()
+	if got := len(m.activeFolds()); got != folds {
+		t.Errorf("View() changed the folds: %d, want %d", got, folds)
+	}
+
+	// Moving the cursor into a fold unfolds it
+	m.buffer.MoveTo(0)
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlUnderscore})
+	typeText(m, "5")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.activeFolds()) != 0 {
+		t.Errorf("folds = %v, want the cursor line revealed", m.activeFolds())
+	}
+}
+
+func TestFoldsShiftWithEdits(t *testing.T) {
+	m := NewFromFile("a.go", "a.go", foldGoSource)
+	m.moveToLineColumn(2, 0)
+	m.toggleFold()
+
+	// Insert a line above the fold
+	m.moveToLineColumn(1, 0)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	folds := m.activeFolds()
+	if end, ok := folds[3]; !ok || end != 7 {
+		t.Errorf("folds = %v, want map[3:7]", folds)
+	}
+}
+
+func TestFoldsClearedOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "b.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewFromFile("a.go", "a.go", foldGoSource)
+	m.toggleAllFolds()
+
+	// Opening another file into the tab does not keep the folds
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	typeText(m, path)
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filepath != path {
+		t.Fatalf("filepath = %q, want %q", m.filepath, path)
+	}
+	if folds := m.activeFolds(); len(folds) != 0 {
+		t.Errorf("folds = %v, want none", folds)
+	}
+}
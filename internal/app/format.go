@@ -0,0 +1,187 @@
+// Package app provides formatting of the buffer with external formatters.
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/KilimcininKorOglu/gesh/internal/diff"
+	"github.com/KilimcininKorOglu/gesh/internal/lint"
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+// formatTimeout bounds how long saving waits for a formatter.
+const formatTimeout = 10 * time.Second
+
+// formatCommand returns the formatter configured for the current file's
+// language, or "" if there is none.
+func (m *Model) formatCommand() string {
+	lang := syntax.DetectLanguage(m.filename)
+	if lang == nil {
+		return ""
+	}
+	return m.formatCommands[strings.ToLower(lang.Name)]
+}
+
+// formatBuffer pipes the buffer through the configured formatter and
+// applies the result as a single undo step, changing only the lines that
+// differ so the cursor stays in place. Nothing is changed if the formatter
+// fails.
+func (m *Model) formatBuffer() error {
+	template := m.formatCommand()
+	if template == "" || m.readonly {
+		return nil
+	}
+
+	path := m.filepath
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	command := lint.Expand(template, path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), formatTimeout)
+	defer cancel()
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Stdin = strings.NewReader(m.buffer.String())
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s timed out", firstWord(template))
+	case errors.As(err, &exitErr):
+		message := firstLine(stderr.String())
+		if message == "" {
+			message = fmt.Sprintf("exit %d", exitErr.ExitCode())
+		}
+		return fmt.Errorf("%s: %s", firstWord(template), message)
+	case err != nil:
+		return err
+	}
+
+	output := stdout.String()
+	if m.lineEnding == "CRLF" {
+		output = strings.ReplaceAll(output, "\r\n", "\n")
+	}
+	m.applyFormatted(output)
+	return nil
+}
+
+// applyFormatted replaces the buffer content with text, editing only the
+// changed lines. The cursor keeps its line and column, moving with the
+// lines inserted or removed above it.
+func (m *Model) applyFormatted(text string) {
+	oldLines := strings.Split(m.buffer.String(), "\n")
+	newLines := strings.Split(text, "\n")
+	hunks := diff.Lines(oldLines, newLines)
+	if len(hunks) == 0 {
+		return
+	}
+
+	cursor := mapLinePosition(m.linePositionOf(m.buffer.CursorPos()), hunks, oldLines, newLines)
+	m.clearSelection()
+	m.history.BeginGroup()
+	defer m.history.EndGroup()
+
+	// Later hunks first, so earlier line numbers stay valid
+	for i := len(hunks) - 1; i >= 0; i-- {
+		h := hunks[i]
+		replacement := strings.Join(newLines[h.NewStart:h.NewEnd], "\n")
+		switch {
+		case h.OldStart < h.OldEnd && h.NewStart < h.NewEnd:
+			m.replaceRange(m.buffer.LineStart(h.OldStart), m.buffer.LineEnd(h.OldEnd-1), replacement)
+		case h.NewStart < h.NewEnd && h.OldStart < len(oldLines):
+			m.replaceRange(m.buffer.LineStart(h.OldStart), m.buffer.LineStart(h.OldStart), replacement+"\n")
+		case h.NewStart < h.NewEnd:
+			m.replaceRange(m.buffer.Len(), m.buffer.Len(), "\n"+replacement)
+		case h.OldEnd < len(oldLines):
+			m.replaceRange(m.buffer.LineStart(h.OldStart), m.buffer.LineStart(h.OldEnd), "")
+		case h.OldStart > 0:
+			m.replaceRange(m.buffer.LineEnd(h.OldStart-1), m.buffer.Len(), "")
+		default:
+			m.replaceRange(0, m.buffer.Len(), "")
+		}
+	}
+	m.buffer.MoveTo(m.positionOf(cursor))
+}
+
+// mapLinePosition returns where a position ends up after the hunks are
+// applied. A position inside a changed region moves to the new line with
+// the same text apart from white space, as formatters mostly only change
+// spacing; otherwise it keeps its offset into the region.
+func mapLinePosition(p linePosition, hunks []diff.Hunk, oldLines, newLines []string) linePosition {
+	shift := 0
+	for _, h := range hunks {
+		if p.line < h.OldStart {
+			break
+		}
+		if p.line < h.OldEnd {
+			old := oldLines[p.line]
+			for j := h.NewStart; j < h.NewEnd; j++ {
+				if withoutSpace(newLines[j]) == withoutSpace(old) {
+					return linePosition{j, columnAfter(newLines[j], nonSpaceBefore(old, p.col))}
+				}
+			}
+			offset := min(p.line-h.OldStart, max(h.NewEnd-h.NewStart-1, 0))
+			return linePosition{h.NewStart + offset, p.col}
+		}
+		shift = h.NewEnd - h.OldEnd
+	}
+	return linePosition{p.line + shift, p.col}
+}
+
+// withoutSpace returns s with all white space removed.
+func withoutSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+// nonSpaceBefore counts the non-space runes of line before column col.
+func nonSpaceBefore(line string, col int) int {
+	n := 0
+	for i, r := range []rune(line) {
+		if i >= col {
+			break
+		}
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// columnAfter returns the column of line that follows its first n non-space
+// runes, skipping the white space after them.
+func columnAfter(line string, n int) int {
+	runes := []rune(line)
+	col := 0
+	for ; col < len(runes); col++ {
+		if unicode.IsSpace(runes[col]) {
+			continue
+		}
+		if n == 0 {
+			break
+		}
+		n--
+	}
+	return col
+}
+
+// firstWord returns the first word of a command line, the program name.
+func firstWord(command string) string {
+	if fields := strings.Fields(command); len(fields) > 0 {
+		return fields[0]
+	}
+	return command
+}
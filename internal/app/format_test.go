@@ -0,0 +1,105 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/KilimcininKorOglu/gesh/internal/diff"
+)
+
+// newFormatModel opens a modified Go file in a temporary directory with the
+// given formatter command.
+func newFormatModel(t *testing.T, content, formatter string) (*Model, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("format tests use POSIX shell commands")
+	}
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("original\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewFromFile(path, "main.go", content)
+	m.SetFormatters(map[string]string{"go": formatter})
+	m.modified = true
+	return m, path
+}
+
+func TestApplyFormattedKeepsCursor(t *testing.T) {
+	m := NewWithContent("package main\nfunc  main(){\nx:=1\n}\n")
+	m.GotoLine(3, 3)
+
+	m.applyFormatted("package main\n\nfunc main() {\n\tx := 1\n}\n")
+	if got := m.buffer.String(); got != "package main\n\nfunc main() {\n\tx := 1\n}\n" {
+		t.Fatalf("buffer = %q", got)
+	}
+	// The cursor stays before "=1" on the re-indented line
+	if line, col := m.buffer.CurrentLine(), m.buffer.CurrentColumn(); line != 3 || col != 4 {
+		t.Errorf("cursor = %d:%d, want 3:4", line, col)
+	}
+
+	// Formatting is a single undo step
+	m.undo()
+	if got := m.buffer.String(); got != "package main\nfunc  main(){\nx:=1\n}\n" {
+		t.Errorf("after undo = %q", got)
+	}
+}
+
+func TestMapLinePosition(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    int
+		want    int
+		wantCol int
+	}{
+		{"before changes", 0, 0, 1},
+		{"inside changed region", 3, 4, 1},
+		{"respaced line", 4, 5, 2},
+		{"after inserted lines", 6, 8, 1},
+	}
+
+	old := strings.Split("a\nb\nc\ndd\nx:=1\nf\ng", "\n")
+	updated := strings.Split("a\nb\nnew\nc\nDD\nx := 1\nf\nextra\ng", "\n")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks := diff.Lines(old, updated)
+			got := mapLinePosition(linePosition{tt.line, 1}, hunks, old, updated)
+			if got.line != tt.want || got.col != tt.wantCol {
+				t.Errorf("mapLinePosition(%d) = %+v, want %d:%d", tt.line, got, tt.want, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestSaveRunsFormatter(t *testing.T) {
+	m, path := newFormatModel(t, "package main\n", "tr a-z A-Z")
+
+	m.saveFile()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "PACKAGE MAIN\n" {
+		t.Errorf("saved = %q, want the formatted buffer", data)
+	}
+	if m.modified {
+		t.Error("buffer still modified after saving")
+	}
+}
+
+func TestSaveAbortsOnFormatterError(t *testing.T) {
+	m, path := newFormatModel(t, "package main\n", "echo 'syntax error at line 1' >&2; exit 2")
+
+	m.saveFile()
+	if !strings.Contains(m.statusMessage, "syntax error at line 1") {
+		t.Errorf("status = %q, want the formatter error", m.statusMessage)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "original\n" {
+		t.Errorf("file was written: %q", data)
+	}
+	if m.buffer.String() != "package main\n" || !m.modified {
+		t.Error("buffer changed by a failing formatter")
+	}
+}
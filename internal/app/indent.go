@@ -0,0 +1,87 @@
+// Package app provides block indentation of lines.
+package app
+
+import (
+	"strings"
+)
+
+// indentUnit returns the text of one indentation level.
+func (m *Model) indentUnit() string {
+	if !m.insertSpaces {
+		return "\t"
+	}
+	size := m.tabSize
+	if size < 1 {
+		size = 4
+	}
+	return strings.Repeat(" ", size)
+}
+
+// indentLines shifts the current line or the selected lines right by one level.
+func (m *Model) indentLines() {
+	m.shiftLines(true)
+}
+
+// unindentLines shifts the current line or the selected lines left by one level.
+func (m *Model) unindentLines() {
+	m.shiftLines(false)
+}
+
+// shiftLines indents or unindents lines as a single undo step, keeping the
+// cursor and selection on the same text.
+func (m *Model) shiftLines(indent bool) {
+	first, last := m.selectedLines()
+	lines := m.linesInRange(first, last)
+	unit := m.indentUnit()
+
+	newLines := make([]string, len(lines))
+	changed := false
+	for i, line := range lines {
+		if indent {
+			if line != "" {
+				newLines[i] = unit + line
+			}
+		} else {
+			newLines[i] = unindentLine(line, len(unit))
+		}
+		if newLines[i] != line {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	// Shift the cursor and selection along with the text they are on
+	shifted := func(p linePosition) int {
+		if p.line >= first && p.line <= last && p.col > 0 {
+			p.col += len([]rune(newLines[p.line-first])) - len([]rune(lines[p.line-first]))
+		}
+		return m.positionOf(p)
+	}
+
+	cursor := m.linePositionOf(m.buffer.CursorPos())
+	selStart := m.linePositionOf(m.selectionStart)
+	selEnd := m.linePositionOf(m.selectionEnd)
+
+	m.replaceLines(first, last, newLines)
+
+	if m.selecting {
+		m.selectionStart = shifted(selStart)
+		m.selectionEnd = shifted(selEnd)
+	}
+	m.buffer.MoveTo(shifted(cursor))
+}
+
+// unindentLine removes one indentation level (a tab or up to width spaces)
+// from the start of line.
+func unindentLine(line string, width int) string {
+	if strings.HasPrefix(line, "\t") {
+		return line[1:]
+	}
+	n := 0
+	for n < width && n < len(line) && line[n] == ' ' {
+		n++
+	}
+	return line[n:]
+}
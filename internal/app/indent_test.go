@@ -0,0 +1,63 @@
+package app
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestIndentSelectedLines(t *testing.T) {
+	m := NewWithContent("a:\nb: 1\n\nc: 2\nd")
+	m.SetTabSize(2)
+	m.selecting = true
+	m.selectionStart = m.buffer.LineStart(1)
+	m.selectionEnd = m.buffer.LineEnd(3)
+	m.buffer.MoveTo(m.selectionEnd)
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	want := "a:\n  b: 1\n\n  c: 2\nd"
+	if got := m.buffer.String(); got != want {
+		t.Fatalf("buffer = %q, want %q", got, want)
+	}
+	if got := m.buffer.Slice(m.getSelectionBounds()); got != "  b: 1\n\n  c: 2" {
+		t.Errorf("selection = %q, want the indented lines", got)
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if got := m.buffer.String(); got != "a:\nb: 1\n\nc: 2\nd" {
+		t.Errorf("after unindent buffer = %q", got)
+	}
+
+	m.undo()
+	if got := m.buffer.String(); got != want {
+		t.Errorf("after undo buffer = %q, want %q", got, want)
+	}
+}
+
+func TestUnindentLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"\t\tx", "\tx"},
+		{"      x", "  x"},
+		{"  x", "x"},
+		{"x", "x"},
+	}
+
+	for _, tt := range tests {
+		if got := unindentLine(tt.line, 4); got != tt.want {
+			t.Errorf("unindentLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTabUsesIndentSettings(t *testing.T) {
+	m := NewWithContent("")
+	m.SetInsertSpaces(false)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+
+	if got := m.buffer.String(); got != "\t" {
+		t.Errorf("buffer = %q, want a tab", got)
+	}
+}
@@ -0,0 +1,142 @@
+// Package app provides the user-configurable key bindings of the editor.
+package app
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/keymap"
+)
+
+// DefaultKeymap returns the default key bindings of the registered
+// actions.
+func DefaultKeymap() *keymap.Keymap {
+	defaults := make(map[string]map[string][]string)
+	for _, mode := range keymap.Modes {
+		defaults[mode] = make(map[string][]string)
+	}
+	for name, action := range actions {
+		mode := action.Mode
+		if mode == "" {
+			mode = keymap.Normal
+		}
+		defaults[mode][name] = action.Keys
+	}
+	return keymap.New(defaults)
+}
+
+// SetKeymap sets the key bindings.
+func (m *Model) SetKeymap(km *keymap.Keymap) {
+	m.keymap = km
+	m.keySeq = nil
+}
+
+// getKeymap returns the key bindings, the defaults unless set.
+func (m *Model) getKeymap() *keymap.Keymap {
+	if m.keymap == nil {
+		m.keymap = DefaultKeymap()
+	}
+	return m.keymap
+}
+
+// keymapMode returns the key map mode of an editor mode, or "" for modes
+// whose keys are fixed.
+func keymapMode(mode Mode) string {
+	switch mode {
+	case ModeNormal:
+		return keymap.Normal
+	case ModeSearch, ModeReplace, ModeReplaceAll, ModeProjectSearch, ModeProjectReplace:
+		return keymap.Search
+	case ModeSaveAs, ModeGoto, ModeReplaceConfirm, ModeReplaceAllConfirm, ModeOpen, ModeSaveMacro, ModeLoadMacro,
+		ModeAlign, ModeExecute, ModeRename, ModeProjectReplaceWith, ModeTreeCreate, ModeTreeRename, ModeViCommand:
+		return keymap.Prompt
+	}
+	return ""
+}
+
+// applyKeymap looks a key up in the bindings of the mode and reports
+// whether that handled it: a chord waiting for its next key, a key that
+// is not bound, or an action with its own function. Keys bound to other
+// actions continue as the action's default key; unbound keys, such as
+// typed text, continue unchanged.
+func (m *Model) applyKeymap(msg tea.KeyMsg) (tea.KeyMsg, tea.Cmd, bool) {
+	mode := keymapMode(m.mode)
+	if mode == "" || msg.Paste {
+		m.keySeq = nil
+		return msg, nil, false
+	}
+	km := m.getKeymap()
+	key := keymap.Name(msg)
+	seq := strings.Join(append(m.keySeq, key), " ")
+	name, prefix := km.Lookup(mode, seq)
+	switch {
+	case prefix:
+		m.keySeq = append(m.keySeq, key)
+		m.SetStatusMessage(seq + " ...")
+		return msg, nil, true
+	case name == "" && len(m.keySeq) > 0:
+		m.keySeq = nil
+		m.SetStatusMessage(seq + " is not bound")
+		return msg, nil, true
+	case name == "" && km.Reserved(mode, key):
+		m.SetStatusMessage(key + " is not bound")
+		return msg, nil, true
+	case name == "":
+		return msg, nil, false
+	}
+	m.keySeq = nil
+	action := actions[name]
+	if action == nil {
+		return msg, nil, false
+	}
+	if m.macro != nil && (name == "macro.record" || name == "macro.play") {
+		// Keep the macro keys themselves out of the macro
+		for range strings.Fields(seq) {
+			m.macro.DropLastKey()
+		}
+	}
+	if action.Run != nil {
+		return msg, action.Run(m), true
+	}
+	for _, k := range action.Keys {
+		if k == msg.String() {
+			return msg, nil, false
+		}
+	}
+	return keymap.Msg(action.Keys[0]), nil, false
+}
+
+// WriteKeymap writes the key bindings in the keybindings.yaml format,
+// with the description of each action.
+func WriteKeymap(w io.Writer, km *keymap.Keymap) error {
+	if _, err := fmt.Fprintln(w, "# Effective key bindings, in the keybindings.yaml format"); err != nil {
+		return err
+	}
+	for _, mode := range keymap.Modes {
+		names := km.Actions(mode)
+		lines := make([]string, len(names))
+		width := 0
+		for i, name := range names {
+			keys := km.Keys(mode, name)
+			quoted := make([]string, len(keys))
+			for j, key := range keys {
+				quoted[j] = strconv.Quote(key)
+			}
+			lines[i] = fmt.Sprintf("%s: [%s]", name, strings.Join(quoted, ", "))
+			width = max(width, len(lines[i]))
+		}
+		if _, err := fmt.Fprintf(w, "\n%s:\n", mode); err != nil {
+			return err
+		}
+		for i, name := range names {
+			if _, err := fmt.Fprintf(w, "  %-*s  # %s\n", width, lines[i], actions[name].Description); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/keymap"
+)
+
+// testKeymap returns the default key bindings with the given user file
+// applied.
+func testKeymap(t *testing.T, data string) *keymap.Keymap {
+	t.Helper()
+	km := DefaultKeymap()
+	warnings, err := km.Parse("keybindings.yaml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range warnings {
+		t.Log(w)
+	}
+	return km
+}
+
+func TestKeymapBindings(t *testing.T) {
+	m := NewFromFile("main.go", "main.go", "one\ntwo\n")
+	m.SetSize(80, 20)
+	m.SetKeymap(testKeymap(t, `
+normal:
+  edit.undo: ctrl+z
+  edit.comment: ["ctrl+k ctrl+c"]
+  tab.close: ctrl+t
+prompt:
+  prompt.accept: ctrl+j
+`))
+
+	// A rebound action moves to its new key; its old key does nothing
+	typeText(m, "x")
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}, Alt: true})
+	if m.Content() != "xone\ntwo\n" || m.statusMessage != "alt+u is not bound" {
+		t.Errorf("alt+u: content %q, status %q", m.Content(), m.statusMessage)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if m.Content() != "one\ntwo\n" {
+		t.Errorf("ctrl+z did not undo: %q", m.Content())
+	}
+
+	// Chords wait for their next key
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	if m.Content() != "one\ntwo\n" || m.statusMessage != "ctrl+k ..." {
+		t.Errorf("ctrl+k: content %q, status %q", m.Content(), m.statusMessage)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if m.Content() != "// one\ntwo\n" {
+		t.Errorf("ctrl+k ctrl+c did not comment: %q", m.Content())
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	if m.statusMessage != "ctrl+k ctrl+x is not bound" || m.quitting {
+		t.Errorf("ctrl+k ctrl+x: status %q", m.statusMessage)
+	}
+
+	// Actions without a key of their own can be bound too
+	m.NewTab()
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if m.TabCount() != 1 {
+		t.Errorf("ctrl+t left %d tabs, want 1", m.TabCount())
+	}
+
+	// Prompts have their own bindings
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlUnderscore})
+	typeText(m, "2")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeGoto {
+		t.Fatalf("enter accepted the prompt")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	if m.mode != ModeNormal || m.buffer.CurrentLine() != 1 {
+		t.Errorf("ctrl+j: mode %v, line %d, want line 2", m.mode, m.buffer.CurrentLine()+1)
+	}
+}
+
+func TestKeymapMacro(t *testing.T) {
+	m := NewFromFile("test.txt", "test.txt", "")
+	m.SetSize(80, 20)
+	m.SetKeymap(testKeymap(t, `
+normal:
+  macro.record: f10
+  macro.play: "ctrl+x m"
+`))
+
+	m.Update(tea.KeyMsg{Type: tea.KeyF10})
+	typeText(m, "ab")
+	m.Update(tea.KeyMsg{Type: tea.KeyF10})
+	if m.macro.KeyCount() != 2 {
+		t.Fatalf("recorded %d keys, want 2", m.macro.KeyCount())
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	if m.Content() != "abab" {
+		t.Errorf("content = %q, want the macro played", m.Content())
+	}
+}
+
+func TestWriteKeymap(t *testing.T) {
+	km := testKeymap(t, `
+normal:
+  edit.comment: [alt+3, "ctrl+k ctrl+c"]
+  goto.start: ctrl+home
+`)
+	var b bytes.Buffer
+	if err := WriteKeymap(&b, km); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `  edit.comment: ["alt+3", "ctrl+k ctrl+c"]`) {
+		t.Errorf("output does not list the chord:\n%s", b.String())
+	}
+
+	// The output is a keybindings file giving the same bindings
+	again := DefaultKeymap()
+	warnings, err := again.Parse("keys", b.Bytes())
+	if err != nil || len(warnings) > 0 {
+		t.Fatalf("parsing the output: %v, %q", err, warnings)
+	}
+	for _, mode := range keymap.Modes {
+		for _, name := range km.Actions(mode) {
+			want := strings.Join(km.Keys(mode, name), ", ")
+			if got := strings.Join(again.Keys(mode, name), ", "); got != want {
+				t.Errorf("%s %s = %q, want %q", mode, name, got, want)
+			}
+		}
+	}
+}
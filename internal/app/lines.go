@@ -0,0 +1,180 @@
+// Package app provides line manipulation commands.
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/KilimcininKorOglu/gesh/internal/lint"
+)
+
+// moveLinesUp moves the current line or the selected lines up by one line.
+func (m *Model) moveLinesUp() {
+	first, last := m.selectedLines()
+	if first == 0 {
+		return
+	}
+	lines := m.linesInRange(first-1, last)
+	newLines := append(append([]string{}, lines[1:]...), lines[0])
+	m.moveLines(first-1, last, newLines, -1)
+}
+
+// moveLinesDown moves the current line or the selected lines down by one line.
+func (m *Model) moveLinesDown() {
+	first, last := m.selectedLines()
+	if last >= m.buffer.LineCount()-1 {
+		return
+	}
+	lines := m.linesInRange(first, last+1)
+	newLines := append([]string{lines[len(lines)-1]}, lines[:len(lines)-1]...)
+	m.moveLines(first, last+1, newLines, 1)
+}
+
+// moveLines replaces lines first through last with newLines and shifts the
+// cursor and selection by delta lines so they stay on the moved text.
+// Bookmarks, folds and diagnostics move with their lines.
+func (m *Model) moveLines(first, last int, newLines []string, delta int) {
+	cursor := m.linePositionOf(m.buffer.CursorPos())
+	selStart := m.linePositionOf(m.selectionStart)
+	selEnd := m.linePositionOf(m.selectionEnd)
+
+	tab := m.tabs.ActiveTab()
+	bookmarks, folds := tab.bookmarks, tab.folds
+	diagnostics := append([]lint.Diagnostic(nil), tab.diagnostics...)
+	lspDiagnostics := append([]lint.Diagnostic(nil), tab.lspDiagnostics...)
+
+	m.replaceLines(first, last, newLines)
+
+	// The edit replaced the lines as text, which drops the state on them
+	moved := func(line int) int {
+		return movedLine(line, first, last, delta)
+	}
+	tab.bookmarks = make([]int, len(bookmarks))
+	for i, line := range bookmarks {
+		tab.bookmarks[i] = moved(line)
+	}
+	sort.Ints(tab.bookmarks)
+	tab.folds = make(map[int]int, len(folds))
+	for start, end := range folds {
+		// Folds split by the move are dropped
+		if newStart, newEnd := moved(start), moved(end); newEnd-newStart == end-start {
+			tab.folds[newStart] = newEnd
+		}
+	}
+	for _, list := range [][]lint.Diagnostic{diagnostics, lspDiagnostics} {
+		for i := range list {
+			list[i].Line = moved(list[i].Line-1) + 1
+		}
+		sortDiagnostics(list)
+	}
+	tab.diagnostics, tab.lspDiagnostics = diagnostics, lspDiagnostics
+	tab.diagnosticIndex = nil
+
+	if m.selecting {
+		selStart.line += delta
+		selEnd.line += delta
+		m.selectionStart = m.positionOf(selStart)
+		m.selectionEnd = m.positionOf(selEnd)
+	}
+	cursor.line += delta
+	m.buffer.MoveTo(m.positionOf(cursor))
+}
+
+// movedLine returns where a line ends up when moveLines swaps lines first
+// through last, moving the line at one end past the others by -delta.
+func movedLine(line, first, last, delta int) int {
+	switch {
+	case line < first || line > last:
+		return line
+	case delta < 0 && line == first:
+		return last
+	case delta > 0 && line == last:
+		return first
+	}
+	return line + delta
+}
+
+// duplicateLines inserts a copy of the current line or the selected lines
+// below them. The cursor and selection move to the copy.
+func (m *Model) duplicateLines() {
+	first, last := m.selectedLines()
+	text := strings.Join(m.linesInRange(first, last), "\n")
+
+	cursor := m.linePositionOf(m.buffer.CursorPos())
+	selStart := m.linePositionOf(m.selectionStart)
+	selEnd := m.linePositionOf(m.selectionEnd)
+
+	end := m.buffer.LineEnd(last)
+	m.replaceRange(end, end, "\n"+text)
+
+	delta := last - first + 1
+	if m.selecting {
+		selStart.line += delta
+		selEnd.line += delta
+		m.selectionStart = m.positionOf(selStart)
+		m.selectionEnd = m.positionOf(selEnd)
+	}
+	cursor.line += delta
+	m.buffer.MoveTo(m.positionOf(cursor))
+
+	if delta == 1 {
+		m.SetStatusMessage("Duplicated 1 line")
+	} else {
+		m.SetStatusMessage(fmt.Sprintf("Duplicated %d lines", delta))
+	}
+}
+
+// joinLines joins the next line onto the current line, or joins all selected
+// lines. Indentation of joined lines is replaced by a single space.
+func (m *Model) joinLines() {
+	first, last := m.selectedLines()
+	if last == first {
+		last++
+	}
+	if last >= m.buffer.LineCount() {
+		return
+	}
+
+	lines := m.linesInRange(first, last)
+	joined := lines[0]
+	for _, line := range lines[1:] {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			continue
+		}
+		joined = strings.TrimRight(joined, " \t")
+		if joined != "" {
+			joined += " "
+		}
+		joined += line
+	}
+	joinCol := len([]rune(strings.TrimRight(lines[0], " \t")))
+
+	m.clearSelection()
+	m.replaceLines(first, last, []string{joined})
+	m.buffer.MoveTo(m.positionOf(linePosition{first, joinCol}))
+}
+
+// cutToEndOfLine cuts from the cursor to the end of the line to the clipboard
+// (nano "cut till end"). At the end of a line the newline is cut instead.
+func (m *Model) cutToEndOfLine() {
+	if m.selecting {
+		m.cutSelection()
+		return
+	}
+
+	pos := m.buffer.CursorPos()
+	end := m.buffer.LineEnd(m.buffer.CurrentLine())
+	if pos == end {
+		if end >= m.buffer.Len() {
+			return
+		}
+		end++
+	}
+
+	m.clipboard = m.buffer.Slice(pos, end)
+	m.replaceRange(pos, end, "")
+	m.buffer.MoveTo(pos)
+	m.SetStatusMessage("Cut to end of line")
+}
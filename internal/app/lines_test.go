@@ -0,0 +1,144 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoveLines(t *testing.T) {
+	m := NewWithContent("a\nb\nc\nd")
+	m.buffer.MoveTo(m.buffer.LineStart(1) + 1)
+
+	m.moveLinesDown()
+	if got := m.buffer.String(); got != "a\nc\nb\nd" {
+		t.Fatalf("after move down buffer = %q", got)
+	}
+	if line, col := m.buffer.CurrentLine(), m.buffer.CurrentColumn(); line != 2 || col != 1 {
+		t.Errorf("cursor = %d:%d, want 2:1", line, col)
+	}
+
+	m.moveLinesUp()
+	m.moveLinesUp()
+	if got := m.buffer.String(); got != "b\na\nc\nd" {
+		t.Fatalf("after move up buffer = %q", got)
+	}
+
+	m.undo()
+	if got := m.buffer.String(); got != "a\nb\nc\nd" {
+		t.Errorf("after undo buffer = %q", got)
+	}
+}
+
+func TestMoveLinesKeepsLineState(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m := NewWithContent("a\nb\nc\nd\ne\nf")
+	tab := m.tabs.ActiveTab()
+	m.moveToLineColumn(3, 0)
+	m.toggleBookmark()
+	m.moveToLineColumn(4, 0)
+	m.toggleBookmark()
+	tab.folds = map[int]int{0: 1, 2: 3}
+
+	m.moveLinesUp()
+	if got := m.buffer.String(); got != "a\nb\nc\ne\nd\nf" {
+		t.Fatalf("buffer = %q", got)
+	}
+	if !reflect.DeepEqual(tab.bookmarks, []int{3, 4}) {
+		t.Errorf("bookmarks = %v, want [3 4]", tab.bookmarks)
+	}
+	// The fold the moved line left is dropped; the one above is kept
+	if !reflect.DeepEqual(tab.folds, map[int]int{0: 1}) {
+		t.Errorf("folds = %v, want map[0:1]", tab.folds)
+	}
+
+	m.moveToLineColumn(0, 0)
+	m.toggleBookmark()
+	m.moveLinesDown()
+	if !reflect.DeepEqual(tab.bookmarks, []int{1, 3, 4}) {
+		t.Errorf("after move down bookmarks = %v, want [1 3 4]", tab.bookmarks)
+	}
+	if !reflect.DeepEqual(tab.folds, map[int]int{}) {
+		t.Errorf("after move down folds = %v, want none", tab.folds)
+	}
+
+	m.selecting = true
+	m.selectionStart = m.buffer.LineStart(0)
+	m.selectionEnd = m.buffer.LineEnd(1)
+	tab.folds = map[int]int{0: 1}
+	m.moveLinesDown()
+	if !reflect.DeepEqual(tab.folds, map[int]int{1: 2}) {
+		t.Errorf("after moving a block folds = %v, want map[1:2]", tab.folds)
+	}
+	if !reflect.DeepEqual(tab.bookmarks, []int{2, 3, 4}) {
+		t.Errorf("after moving a block bookmarks = %v, want [2 3 4]", tab.bookmarks)
+	}
+}
+
+func TestMoveSelectedLinesDown(t *testing.T) {
+	m := NewWithContent("a\nb\nc\nd")
+	m.selecting = true
+	m.selectionStart = m.buffer.LineStart(1)
+	m.selectionEnd = m.buffer.LineStart(3)
+	m.buffer.MoveTo(m.selectionEnd)
+
+	m.moveLinesDown()
+	if got := m.buffer.String(); got != "a\nd\nb\nc" {
+		t.Fatalf("buffer = %q", got)
+	}
+	if got := m.buffer.Slice(m.getSelectionBounds()); got != "b\nc" {
+		t.Errorf("selection = %q, want %q", got, "b\nc")
+	}
+}
+
+func TestDuplicateLines(t *testing.T) {
+	m := NewWithContent("one\ntwo")
+	m.buffer.MoveTo(2)
+
+	m.duplicateLines()
+	if got := m.buffer.String(); got != "one\none\ntwo" {
+		t.Fatalf("buffer = %q", got)
+	}
+	if got := m.buffer.CursorPos(); got != 6 {
+		t.Errorf("cursor = %d, want 6", got)
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"indented", "if x {  \n    y()\n}", "if x { y()\n}"},
+		{"empty next line", "a\n\nb", "a\nb"},
+		{"last line", "a", "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewWithContent(tt.content)
+			m.joinLines()
+			if got := m.buffer.String(); got != tt.want {
+				t.Errorf("buffer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutToEndOfLine(t *testing.T) {
+	m := NewWithContent("hello world\nnext")
+	m.buffer.MoveTo(5)
+
+	m.cutToEndOfLine()
+	if got := m.buffer.String(); got != "hello\nnext" {
+		t.Fatalf("buffer = %q", got)
+	}
+	if m.clipboard != " world" {
+		t.Errorf("clipboard = %q, want %q", m.clipboard, " world")
+	}
+
+	m.cutToEndOfLine()
+	if got := m.buffer.String(); got != "hellonext" {
+		t.Errorf("at end of line buffer = %q, want %q", got, "hellonext")
+	}
+}
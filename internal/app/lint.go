@@ -0,0 +1,375 @@
+// Package app provides linter integration with diagnostics in the gutter.
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/KilimcininKorOglu/gesh/internal/lint"
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+// lintRun is a linter started for a saved file.
+type lintRun struct {
+	id     int
+	tab    *Tab   // tab the diagnostics belong to
+	path   string // file being linted
+	cancel context.CancelFunc
+}
+
+// lintDoneMsg reports the output of a finished linter.
+type lintDoneMsg struct {
+	id       int
+	output   string // stdout and stderr combined
+	exitCode int
+	err      error // failure to run, or cancellation
+}
+
+// lintCommand returns the lint command configured for the current file's
+// language, or "" if there is none.
+func (m *Model) lintCommand() string {
+	lang := syntax.DetectLanguage(m.filename)
+	if lang == nil {
+		return ""
+	}
+	return m.lintCommands[strings.ToLower(lang.Name)]
+}
+
+// lintFile lints the current file on demand, saving it first if modified.
+func (m *Model) lintFile() tea.Cmd {
+	if m.lintCommand() == "" {
+		m.SetStatusMessage("No linter configured for " + detectLanguage(m.filename))
+		return nil
+	}
+	if m.modified || m.filepath == "" {
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			return nil
+		}
+		// Linting on save already happens as part of saving
+		_, cmd := m.saveFile()
+		if m.modified || m.lintOnSave {
+			return cmd
+		}
+	}
+	return m.startLint()
+}
+
+// startLint runs the configured linter on the saved file in the background.
+// A linter still running for an earlier save is cancelled. The result
+// arrives as a lintDoneMsg.
+func (m *Model) startLint() tea.Cmd {
+	template := m.lintCommand()
+	if template == "" || m.filepath == "" {
+		return nil
+	}
+	if m.lint != nil {
+		m.lint.cancel()
+	}
+
+	path := m.filepath
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	command := lint.Command(template, path)
+	dir := filepath.Dir(path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.lintSeq++
+	m.lint = &lintRun{
+		id:     m.lintSeq,
+		tab:    m.tabs.ActiveTab(),
+		path:   path,
+		cancel: cancel,
+	}
+
+	id := m.lintSeq
+	return func() tea.Msg {
+		return runLint(ctx, id, command, dir)
+	}
+}
+
+// runLint runs a lint command in dir and collects its combined output.
+func runLint(ctx context.Context, id int, command, dir string) lintDoneMsg {
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	msg := lintDoneMsg{id: id, output: output.String()}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		msg.err = ctx.Err()
+	case errors.As(err, &exitErr):
+		msg.exitCode = exitErr.ExitCode()
+	case err != nil:
+		msg.err = err
+	}
+	return msg
+}
+
+// finishLint stores the diagnostics of a finished linter on its tab and
+// summarizes them in the status bar.
+func (m *Model) finishLint(msg lintDoneMsg) {
+	run := m.lint
+	if run == nil || run.id != msg.id {
+		return
+	}
+	m.lint = nil
+	run.cancel()
+
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		return
+	case msg.err != nil:
+		m.SetStatusMessage("Lint failed: " + msg.err.Error())
+		return
+	}
+
+	diagnostics := lint.Parse(msg.output, run.path, filepath.Dir(run.path))
+	run.tab.diagnostics = diagnostics
+	run.tab.diagnosticIndex = nil
+	m.diagnosticLine = -1
+	if len(diagnostics) == 0 && msg.exitCode != 0 {
+		status := fmt.Sprintf("Lint failed (exit %d)", msg.exitCode)
+		if line := firstLine(msg.output); line != "" {
+			status += ": " + line
+		}
+		m.SetStatusMessage(status)
+		return
+	}
+	m.SetStatusMessage("Lint: " + summarizeDiagnostics(diagnostics))
+}
+
+// summarizeDiagnostics counts diagnostics by severity ("2 errors, 1 warning").
+func summarizeDiagnostics(diagnostics []lint.Diagnostic) string {
+	if len(diagnostics) == 0 {
+		return "no problems"
+	}
+	counts := make(map[lint.Severity]int)
+	for _, d := range diagnostics {
+		counts[d.Severity]++
+	}
+	var parts []string
+	for _, severity := range []lint.Severity{lint.SeverityError, lint.SeverityWarning, lint.SeverityInfo} {
+		if n := counts[severity]; n > 0 {
+			name := severity.String()
+			if n > 1 {
+				name += "s"
+			}
+			parts = append(parts, fmt.Sprintf("%d %s", n, name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// diagnosticIndex holds the diagnostics of a tab from the linter and the
+// language server, merged once instead of for every rendered line.
+type diagnosticIndex struct {
+	all    []lint.Diagnostic         // sorted by position
+	byLine map[int][]lint.Diagnostic // by line (0-based)
+}
+
+// tabDiagnostics returns the merged diagnostics of a tab, building the
+// index after either list changed.
+func tabDiagnostics(tab *Tab) *diagnosticIndex {
+	if tab.diagnosticIndex != nil {
+		return tab.diagnosticIndex
+	}
+	all := tab.diagnostics
+	switch {
+	case len(tab.diagnostics) == 0:
+		all = tab.lspDiagnostics
+	case len(tab.lspDiagnostics) > 0:
+		all = append(append([]lint.Diagnostic(nil), tab.diagnostics...), tab.lspDiagnostics...)
+		sortDiagnostics(all)
+	}
+	index := &diagnosticIndex{all: all, byLine: make(map[int][]lint.Diagnostic)}
+	for _, d := range all {
+		index.byLine[d.Line-1] = append(index.byLine[d.Line-1], d)
+	}
+	tab.diagnosticIndex = index
+	return index
+}
+
+// diagnostics returns the diagnostics of the active tab, from the linter
+// and the language server, sorted by position.
+func (m *Model) diagnostics() []lint.Diagnostic {
+	tab := m.tabs.ActiveTab()
+	if tab == nil {
+		return nil
+	}
+	return tabDiagnostics(tab).all
+}
+
+// lineDiagnostics returns the diagnostics of a line (0-based) of the active
+// tab.
+func (m *Model) lineDiagnostics(lineNum int) []lint.Diagnostic {
+	tab := m.tabs.ActiveTab()
+	if tab == nil {
+		return nil
+	}
+	return tabDiagnostics(tab).byLine[lineNum]
+}
+
+// lineDiagnostic returns the most severe diagnostic of a line (0-based).
+func (m *Model) lineDiagnostic(lineNum int) (lint.Diagnostic, bool) {
+	var worst lint.Diagnostic
+	found := false
+	for _, d := range m.lineDiagnostics(lineNum) {
+		if !found || d.Severity < worst.Severity {
+			worst = d
+			found = true
+		}
+	}
+	return worst, found
+}
+
+// diagnosticStyle returns the color of a diagnostic severity.
+func diagnosticStyle(severity lint.Severity) lipgloss.Style {
+	switch severity {
+	case lint.SeverityWarning:
+		return diagnosticWarningStyle
+	case lint.SeverityInfo:
+		return diagnosticInfoStyle
+	default:
+		return diagnosticErrorStyle
+	}
+}
+
+// diagnosticSpans returns underline spans for the diagnostics of a line.
+// A diagnostic with a column underlines the word starting there; one
+// without a column underlines the text of the whole line.
+func (m *Model) diagnosticSpans(lineNum int, line string) []underlineSpan {
+	var spans []underlineSpan
+	for _, d := range m.lineDiagnostics(lineNum) {
+		start, end := diagnosticRange(d, line)
+		if start < end {
+			style := diagnosticStyle(d.Severity).Underline(true)
+			spans = append(spans, underlineSpan{start: start, end: end, style: style})
+		}
+	}
+	return spans
+}
+
+// diagnosticRange returns the rune columns of line a diagnostic refers to.
+func diagnosticRange(d lint.Diagnostic, line string) (start, end int) {
+	runes := []rune(line)
+	if d.Col <= 0 {
+		trimmed := strings.TrimLeft(line, " \t")
+		start = len(runes) - utf8.RuneCountInString(trimmed)
+		return start, len([]rune(strings.TrimRight(line, " \t")))
+	}
+
+	// Columns are byte offsets in the line
+	byteCol := min(d.Col-1, len(line))
+	start = utf8.RuneCountInString(line[:byteCol])
+	if start >= len(runes) {
+		return max(len(runes)-1, 0), len(runes)
+	}
+	end = start
+	for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+		end++
+	}
+	if end == start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// shiftDiagnostics moves diagnostics below an edit by delta lines, like
+// bookmarks; diagnostics on changed lines move to the first changed line.
+func shiftDiagnostics(diagnostics []lint.Diagnostic, editStart, editEnd, delta int) {
+	for i := range diagnostics {
+		line := diagnostics[i].Line - 1
+		switch {
+		case line > editEnd:
+			line += delta
+		case line > editStart:
+			line = editStart
+		}
+		diagnostics[i].Line = line + 1
+	}
+}
+
+// formatDiagnostic formats a diagnostic for the status bar.
+func formatDiagnostic(d lint.Diagnostic) string {
+	return fmt.Sprintf("Line %d: %s: %s", d.Line, d.Severity, d.Message)
+}
+
+// showLineDiagnostic shows the diagnostic of the cursor line in the status
+// bar when the cursor moves onto a line with diagnostics.
+func (m *Model) showLineDiagnostic() {
+	if m.mode != ModeNormal {
+		return
+	}
+	line := m.buffer.CurrentLine()
+	if line == m.diagnosticLine {
+		return
+	}
+	m.diagnosticLine = line
+	if d, ok := m.lineDiagnostic(line); ok {
+		m.SetStatusMessage(formatDiagnostic(d))
+	}
+}
+
+// gotoDiagnostic moves to the next (or previous) line with diagnostics,
+// wrapping around, and shows its message.
+func (m *Model) gotoDiagnostic(forward bool) {
+	diagnostics := m.diagnostics()
+	if len(diagnostics) == 0 {
+		m.SetStatusMessage("No diagnostics")
+		return
+	}
+
+	line := m.buffer.CurrentLine() + 1
+	index := -1
+	if forward {
+		for i, d := range diagnostics {
+			if d.Line > line {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			index = 0
+		}
+	} else {
+		for i := len(diagnostics) - 1; i >= 0; i-- {
+			if diagnostics[i].Line < line {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			index = len(diagnostics) - 1
+		}
+	}
+
+	d := diagnostics[index]
+	lineNum := min(d.Line-1, m.buffer.LineCount()-1)
+	start, _ := diagnosticRange(d, m.buffer.Line(lineNum))
+	if d.Col <= 0 {
+		start = 0
+	}
+	m.clearSelection()
+	m.buffer.MoveTo(m.buffer.LineStart(lineNum) + start)
+	m.ensureCursorVisible()
+	m.diagnosticLine = lineNum
+	m.SetStatusMessage(fmt.Sprintf("[%d/%d] %s", index+1, len(diagnostics), formatDiagnostic(d)))
+}
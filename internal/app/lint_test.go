@@ -0,0 +1,167 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/lint"
+)
+
+// newLintModel opens a Go file in a temporary directory with a fake linter
+// that reports the given output lines, with %s standing for the file path.
+func newLintModel(t *testing.T, content string, output ...string) *Model {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("lint tests use POSIX shell commands")
+	}
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewFromFile(path, "main.go", content)
+	format := strings.Join(output, `\n`)
+	args := strings.Repeat(" {file}", strings.Count(format, "%s"))
+	m.SetLint(map[string]string{"go": "printf '" + format + `\n'` + args + "; exit 1"}, true)
+	return m
+}
+
+// runLintCmd runs a lint command synchronously and delivers its result.
+func runLintCmd(t *testing.T, m *Model, cmd tea.Cmd) {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("no lint command started")
+	}
+	m.Update(cmd())
+}
+
+func TestLintOnDemand(t *testing.T) {
+	m := newLintModel(t, "package main\n\nfunc main() {\n\tx := 1\n}\n",
+		"%s:4:2: declared and not used: x",
+		"note: in package main",
+		"%s:2:1: warning: blank line")
+
+	runLintCmd(t, m, m.lintFile())
+	if m.statusMessage != "Lint: 1 error, 1 warning" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+	d, ok := m.lineDiagnostic(3)
+	if !ok || d.Message != "declared and not used: x" {
+		t.Fatalf("line 4 diagnostic = %+v, %v", d, ok)
+	}
+
+	// The diagnostic underlines the identifier at its column
+	spans := m.diagnosticSpans(3, m.buffer.Line(3))
+	if len(spans) != 1 || spans[0].start != 1 || spans[0].end != 2 {
+		t.Errorf("spans = %+v, want columns 1..2", spans)
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF8})
+	if line := m.buffer.CurrentLine(); line != 1 {
+		t.Errorf("after F8 cursor line = %d, want 1", line)
+	}
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF8})
+	if line, col := m.buffer.CurrentLine(), m.buffer.CurrentColumn(); line != 3 || col != 1 {
+		t.Errorf("after second F8 cursor = %d:%d, want 3:1", line, col)
+	}
+	if !strings.Contains(m.statusMessage, "[2/2]") {
+		t.Errorf("status = %q, want position 2/2", m.statusMessage)
+	}
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF8})
+	if line := m.buffer.CurrentLine(); line != 1 {
+		t.Errorf("F8 did not wrap around, cursor line = %d", line)
+	}
+}
+
+func TestDiagnosticsClearedOnOpen(t *testing.T) {
+	m := newLintModel(t, "package main\n\nfunc main() {\n\tx := 1\n}\n",
+		"%s:1:1: error at the top")
+	runLintCmd(t, m, m.lintFile())
+	if _, ok := m.lineDiagnostic(0); !ok {
+		t.Fatal("no diagnostic on line 1")
+	}
+
+	// Opening another file into the tab drops the diagnostics
+	path := filepath.Join(t.TempDir(), "other.go")
+	if err := os.WriteFile(path, []byte("package other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	typeText(m, path)
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.diagnostics(); len(got) != 0 {
+		t.Errorf("diagnostics = %+v, want none", got)
+	}
+	if m.statusMessage != "Opened: other.go" {
+		t.Errorf("status = %q, want %q", m.statusMessage, "Opened: other.go")
+	}
+}
+
+func TestDiagnosticsMerged(t *testing.T) {
+	m := NewWithContent("a\nb\nc")
+	tab := m.tabs.ActiveTab()
+	tab.diagnostics = []lint.Diagnostic{{Line: 2, Severity: lint.SeverityWarning, Message: "lint"}}
+	tab.lspDiagnostics = []lint.Diagnostic{
+		{Line: 1, Severity: lint.SeverityInfo, Message: "first"},
+		{Line: 2, Severity: lint.SeverityError, Message: "server"},
+	}
+
+	if got := len(m.diagnostics()); got != 3 {
+		t.Fatalf("merged %d diagnostics, want 3", got)
+	}
+	if d, ok := m.lineDiagnostic(1); !ok || d.Message != "server" {
+		t.Errorf("line 2 diagnostic = %+v, want the server error", d)
+	}
+
+	// The merged diagnostics follow edits
+	m.moveToLineColumn(0, 0)
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := m.lineDiagnostic(1); !ok {
+		t.Error("no diagnostic on line 2 after inserting a line above")
+	}
+	if d, ok := m.lineDiagnostic(2); !ok || d.Message != "server" {
+		t.Errorf("line 3 diagnostic = %+v, want the server error", d)
+	}
+	if got := m.diagnostics()[0].Line; got != 2 {
+		t.Errorf("first diagnostic on line %d, want 2", got)
+	}
+}
+
+func TestLintOnSave(t *testing.T) {
+	m := newLintModel(t, "package main\n\nfunc main() {}", "%s:3:1: error: bad func")
+
+	_, cmd := m.saveFile()
+	runLintCmd(t, m, cmd)
+	if _, ok := m.lineDiagnostic(2); !ok {
+		t.Fatal("no diagnostic after saving")
+	}
+
+	// Moving onto the line shows its message
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if m.statusMessage != "Line 3: error: bad func" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+
+	// Diagnostics follow inserted lines
+	m.buffer.MoveTo(0)
+	typeText(m, "// header")
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := m.lineDiagnostic(3); !ok {
+		t.Error("diagnostic did not move down with its line")
+	}
+}
+
+func TestLintNotConfigured(t *testing.T) {
+	m := NewFromFile("notes.txt", "notes.txt", "text")
+	if cmd := m.lintFile(); cmd != nil {
+		t.Error("lint started without a configured command")
+	}
+	if _, cmd := m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF8}); cmd != nil || m.statusMessage != "No diagnostics" {
+		t.Errorf("F8 status = %q, want No diagnostics", m.statusMessage)
+	}
+}
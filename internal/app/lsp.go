@@ -0,0 +1,829 @@
+// Package app provides language server integration: diagnostics, hover,
+// go to definition, completion, rename and formatting.
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/file"
+	"github.com/KilimcininKorOglu/gesh/internal/lint"
+	"github.com/KilimcininKorOglu/gesh/internal/lsp"
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+const (
+	// lspStartTimeout bounds the initialize handshake of a new server.
+	lspStartTimeout = 30 * time.Second
+	// lspRequestTimeout bounds how long a request waits for its response.
+	lspRequestTimeout = 10 * time.Second
+)
+
+// projectMarkers are files marking the root directory of a project, the
+// workspace a language server is started in.
+var projectMarkers = []string{".git", "go.mod", "Cargo.toml", "pyproject.toml", "setup.py", "package.json"}
+
+// lspServer is the language server of one language, started on first use.
+type lspServer struct {
+	language string      // lowercase language name
+	command  string      // command line from the configuration
+	client   *lsp.Client // nil while starting or after failing
+	docs     map[*Tab]*lspDocument
+}
+
+// lspDocument is a tab's file as last sent to the server.
+type lspDocument struct {
+	uri           string
+	version       int    // document version of the last change sent
+	bufferVersion int    // buffer version text was taken at
+	text          string // buffer content known to the server
+}
+
+// lspStartedMsg reports the result of starting a server.
+type lspStartedMsg struct {
+	server *lspServer
+	client *lsp.Client
+	err    error
+}
+
+// lspDiagnosticsMsg delivers diagnostics published by a server; ok is false
+// once the server has exited.
+type lspDiagnosticsMsg struct {
+	server    *lspServer
+	published []lsp.PublishDiagnosticsParams
+	ok        bool
+}
+
+// lspHoverMsg delivers hover text.
+type lspHoverMsg struct {
+	text string
+	err  error
+}
+
+// lspDefinitionMsg delivers definition locations.
+type lspDefinitionMsg struct {
+	locations []lsp.Location
+	err       error
+}
+
+// lspCompletionMsg delivers completion items for the cursor position pos
+// of a tab at buffer version version.
+type lspCompletionMsg struct {
+	tab          *Tab
+	version, pos int
+	items        []lsp.CompletionItem
+	err          error
+}
+
+// lspRenameMsg delivers the edits of a rename.
+type lspRenameMsg struct {
+	name string
+	edit *lsp.WorkspaceEdit
+	err  error
+}
+
+// lspFormatMsg delivers formatting edits for a tab at buffer version version.
+type lspFormatMsg struct {
+	tab     *Tab
+	version int
+	edits   []lsp.TextEdit
+	err     error
+}
+
+// tabFile returns the path and name of a tab's file. The active tab's are
+// kept on the model.
+func (m *Model) tabFile(tab *Tab) (path, name string) {
+	if tab == m.tabs.ActiveTab() {
+		return m.filepath, m.filename
+	}
+	return tab.filepath, tab.filename
+}
+
+// tabLanguage returns the lowercase language name of a tab's file, or "".
+func (m *Model) tabLanguage(tab *Tab) string {
+	_, name := m.tabFile(tab)
+	if lang := syntax.DetectLanguage(name); lang != nil {
+		return strings.ToLower(lang.Name)
+	}
+	return ""
+}
+
+// syncLSP brings the language servers up to date with the open tabs: new
+// files are opened (starting their server if needed), edits are sent as
+// incremental changes and closed tabs are closed.
+func (m *Model) syncLSP() tea.Cmd {
+	if len(m.lspCommands) == 0 {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	open := make(map[*Tab]bool)
+	for _, tab := range m.tabs.Tabs() {
+		open[tab] = true
+		path, _ := m.tabFile(tab)
+		language := m.tabLanguage(tab)
+		if path == "" || m.lspCommands[language] == "" {
+			continue
+		}
+
+		server := m.lspServers[language]
+		if server == nil {
+			server = &lspServer{
+				language: language,
+				command:  m.lspCommands[language],
+				docs:     make(map[*Tab]*lspDocument),
+			}
+			if m.lspServers == nil {
+				m.lspServers = make(map[string]*lspServer)
+			}
+			m.lspServers[language] = server
+			cmds = append(cmds, startLSP(server, findProjectRoot(filepath.Dir(path))))
+		}
+		if server.client != nil {
+			server.sync(tab, lsp.FileURI(path))
+		}
+	}
+
+	for _, server := range m.lspServers {
+		for tab, doc := range server.docs {
+			if !open[tab] || m.tabLanguage(tab) != server.language {
+				server.close(tab, doc)
+			}
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// sync opens a tab's document on the server or sends its changes. A tab
+// whose file changed (Save As, opening another file) is reopened.
+func (s *lspServer) sync(tab *Tab, uri string) {
+	doc := s.docs[tab]
+	if doc != nil && doc.uri != uri {
+		s.close(tab, doc)
+		doc = nil
+	}
+
+	version := tab.buffer.Version()
+	if doc == nil {
+		doc = &lspDocument{uri: uri, version: 1, bufferVersion: version, text: tab.buffer.String()}
+		s.docs[tab] = doc
+		_ = s.client.DidOpen(uri, lsp.LanguageID(s.language), doc.version, doc.text)
+		return
+	}
+	if doc.bufferVersion == version {
+		return
+	}
+
+	text := tab.buffer.String()
+	doc.bufferVersion = version
+	if text == doc.text {
+		return
+	}
+	doc.version++
+	_ = s.client.DidChange(uri, doc.version, doc.text, text)
+	doc.text = text
+}
+
+// close closes a tab's document and drops its diagnostics.
+func (s *lspServer) close(tab *Tab, doc *lspDocument) {
+	if s.client != nil {
+		_ = s.client.DidClose(doc.uri)
+	}
+	delete(s.docs, tab)
+	tab.lspDiagnostics = nil
+	tab.diagnosticIndex = nil
+}
+
+// startLSP starts a server in rootDir in the background.
+func startLSP(server *lspServer, rootDir string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lspStartTimeout)
+		defer cancel()
+		client, err := lsp.Start(ctx, strings.Fields(server.command), rootDir)
+		return lspStartedMsg{server: server, client: client, err: err}
+	}
+}
+
+// finishLSPStart stores a started client and opens the documents of its
+// language. A server that failed to start stays registered so it is not
+// restarted on every key press.
+func (m *Model) finishLSPStart(msg lspStartedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.SetStatusMessage(fmt.Sprintf("Language server %s failed: %v", firstWord(msg.server.command), msg.err))
+		return nil
+	}
+	if m.lspServers[msg.server.language] != msg.server {
+		msg.client.Close()
+		return nil
+	}
+	msg.server.client = msg.client
+	return tea.Batch(m.syncLSP(), waitLSP(msg.server))
+}
+
+// waitLSP waits for the next diagnostics of a server.
+func waitLSP(server *lspServer) tea.Cmd {
+	client := server.client
+	return func() tea.Msg {
+		published, ok := client.WaitDiagnostics()
+		return lspDiagnosticsMsg{server: server, published: published, ok: ok}
+	}
+}
+
+// finishLSPDiagnostics stores published diagnostics on their tabs and keeps
+// waiting for more. A server that exited stays registered without a client,
+// like one that failed to start, so it is not restarted in a loop.
+func (m *Model) finishLSPDiagnostics(msg lspDiagnosticsMsg) tea.Cmd {
+	server := msg.server
+	if !msg.ok {
+		if server.client != nil && m.lspServers[server.language] == server {
+			m.SetStatusMessage(fmt.Sprintf("Language server %s exited", firstWord(server.command)))
+		}
+		for tab, doc := range server.docs {
+			server.close(tab, doc)
+		}
+		if server.client != nil {
+			server.client.Close()
+			server.client = nil
+		}
+		return nil
+	}
+
+	for _, published := range msg.published {
+		for tab, doc := range server.docs {
+			if doc.uri == published.URI {
+				tab.lspDiagnostics = convertDiagnostics(published.Diagnostics, tab)
+				tab.diagnosticIndex = nil
+			}
+		}
+	}
+	m.diagnosticLine = -1
+	return waitLSP(server)
+}
+
+// convertDiagnostics converts protocol diagnostics to the editor's, with
+// 1-based lines and byte columns, sorted by position.
+func convertDiagnostics(diagnostics []lsp.Diagnostic, tab *Tab) []lint.Diagnostic {
+	converted := make([]lint.Diagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		line := min(d.Range.Start.Line, tab.buffer.LineCount()-1)
+		text := tab.buffer.Line(line)
+		runes := []rune(text)
+		col := min(lsp.RuneColumn(text, d.Range.Start.Character), len(runes))
+
+		severity := lint.SeverityError
+		switch d.Severity {
+		case lsp.SeverityWarning:
+			severity = lint.SeverityWarning
+		case lsp.SeverityInformation, lsp.SeverityHint:
+			severity = lint.SeverityInfo
+		}
+		converted = append(converted, lint.Diagnostic{
+			Line:     line + 1,
+			Col:      len(string(runes[:col])) + 1,
+			Severity: severity,
+			Message:  d.Message,
+		})
+	}
+	sortDiagnostics(converted)
+	return converted
+}
+
+// sortDiagnostics sorts diagnostics by line and column.
+func sortDiagnostics(diagnostics []lint.Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Col < diagnostics[j].Col
+	})
+}
+
+// activeDocument returns the server and document of the active tab, or
+// false with a status message if no server handles it.
+func (m *Model) activeDocument() (*lspServer, *lspDocument, bool) {
+	language := m.tabLanguage(m.tabs.ActiveTab())
+	if m.lspCommands[language] == "" {
+		m.SetStatusMessage("No language server configured for " + detectLanguage(m.filename))
+		return nil, nil, false
+	}
+	if m.filepath == "" {
+		m.SetStatusMessage("Save the file first")
+		return nil, nil, false
+	}
+	server := m.lspServers[language]
+	if server == nil || server.client == nil {
+		m.SetStatusMessage("Language server is not running yet")
+		return nil, nil, false
+	}
+	tab := m.tabs.ActiveTab()
+	server.sync(tab, lsp.FileURI(m.filepath))
+	return server, server.docs[tab], true
+}
+
+// lspPosition returns the cursor position in protocol coordinates.
+func (m *Model) lspPosition() lsp.Position {
+	p := m.linePositionOf(m.buffer.CursorPos())
+	return lsp.Position{Line: p.line, Character: lsp.UTF16Column(m.buffer.Line(p.line), p.col)}
+}
+
+// lspOffset returns the buffer offset of a protocol position.
+func (m *Model) lspOffset(p lsp.Position) int {
+	if p.Line >= m.buffer.LineCount() {
+		return m.buffer.Len()
+	}
+	line := m.buffer.Line(p.Line)
+	return m.buffer.LineStart(p.Line) + min(lsp.RuneColumn(line, p.Character), len([]rune(line)))
+}
+
+// lspHover shows the hover text of the symbol under the cursor.
+func (m *Model) lspHover() tea.Cmd {
+	server, doc, ok := m.activeDocument()
+	if !ok {
+		return nil
+	}
+	client, uri, pos := server.client, doc.uri, m.lspPosition()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lspRequestTimeout)
+		defer cancel()
+		text, err := client.Hover(ctx, uri, pos)
+		return lspHoverMsg{text: text, err: err}
+	}
+}
+
+// finishLSPHover shows hover text in the status bar.
+func (m *Model) finishLSPHover(msg lspHoverMsg) {
+	switch summary := hoverSummary(msg.text); {
+	case msg.err != nil:
+		m.SetStatusMessage("Hover failed: " + msg.err.Error())
+	case summary == "":
+		m.SetStatusMessage("No information")
+	default:
+		m.SetStatusMessage(summary)
+	}
+}
+
+// hoverSummary condenses hover text to one line: the signature and the
+// first line of documentation, without markdown code fences.
+func hoverSummary(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "```") {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) == 2 {
+			break
+		}
+	}
+	return strings.Join(lines, " — ")
+}
+
+// lspDefinition jumps to the definition of the symbol under the cursor.
+func (m *Model) lspDefinition() tea.Cmd {
+	server, doc, ok := m.activeDocument()
+	if !ok {
+		return nil
+	}
+	client, uri, pos := server.client, doc.uri, m.lspPosition()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lspRequestTimeout)
+		defer cancel()
+		locations, err := client.Definition(ctx, uri, pos)
+		return lspDefinitionMsg{locations: locations, err: err}
+	}
+}
+
+// finishLSPDefinition opens the definition, or lets the user pick one when
+// there are several.
+func (m *Model) finishLSPDefinition(msg lspDefinitionMsg) {
+	switch {
+	case msg.err != nil:
+		m.SetStatusMessage("Definition failed: " + msg.err.Error())
+		return
+	case len(msg.locations) == 0:
+		m.SetStatusMessage("No definition found")
+		return
+	case len(msg.locations) == 1:
+		m.openLocation(msg.locations[0])
+		return
+	}
+
+	items := make([]pickerItem, 0, len(msg.locations))
+	for _, loc := range msg.locations {
+		path := lsp.URIPath(loc.URI)
+		items = append(items, pickerItem{
+			label:    fmt.Sprintf("%s:%d", displayPath(path), loc.Range.Start.Line+1),
+			detail:   strings.TrimSpace(m.fileLine(path, loc.Range.Start.Line)),
+			onSelect: func() { m.openLocation(loc) },
+		})
+	}
+	m.openPicker("Definitions", items)
+}
+
+// openLocation moves to a location, switching to the tab of its file or
+// opening the file in a new tab.
+func (m *Model) openLocation(loc lsp.Location) {
+	path := lsp.URIPath(loc.URI)
+	if path == "" {
+		m.SetStatusMessage("Cannot open " + loc.URI)
+		return
+	}
+	if !m.jumpToFile(path) {
+		return
+	}
+	line := min(loc.Range.Start.Line, m.buffer.LineCount()-1)
+	m.jumpToLine(line, lsp.RuneColumn(m.buffer.Line(line), loc.Range.Start.Character))
+}
+
+// selectFileTab switches to the tab showing path, if there is one.
+func (m *Model) selectFileTab(path string) bool {
+	for i, tab := range m.tabs.Tabs() {
+		if tabPath, _ := m.tabFile(tab); tabPath != "" && sameFile(tabPath, path) {
+			m.SelectTab(i)
+			return true
+		}
+	}
+	return false
+}
+
+// openFileTab opens a file in a new tab, reporting errors in the status bar.
+func (m *Model) openFileTab(path string) bool {
+	info, err := file.LoadWithInfo(path)
+	if err != nil {
+		m.SetStatusMessage("Error opening file: " + err.Error())
+		return false
+	}
+	m.OpenFileInNewTab(path, filepath.Base(path), info.Content, string(info.Encoding), string(info.LineEnding))
+	return true
+}
+
+// sameFile reports whether two paths name the same file.
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// fileLine returns a line (0-based) of a file, from its tab if it is open.
+func (m *Model) fileLine(path string, line int) string {
+	for _, tab := range m.tabs.Tabs() {
+		if tabPath, _ := m.tabFile(tab); tabPath != "" && sameFile(tabPath, path) {
+			return tab.buffer.Line(line)
+		}
+	}
+	content, err := file.Load(path)
+	if err != nil {
+		return ""
+	}
+	if lines := strings.Split(content, "\n"); line < len(lines) {
+		return lines[line]
+	}
+	return ""
+}
+
+// displayPath returns path relative to the working directory when it is
+// below it.
+func displayPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// lspComplete requests completions at the cursor. It returns nil if no
+// server handles the file, so word completion can be used instead.
+func (m *Model) lspComplete() tea.Cmd {
+	language := m.tabLanguage(m.tabs.ActiveTab())
+	if server := m.lspServers[language]; server == nil || server.client == nil || m.filepath == "" {
+		return nil
+	}
+	server, doc, ok := m.activeDocument()
+	if !ok {
+		return nil
+	}
+	client, uri, lspPos := server.client, doc.uri, m.lspPosition()
+	tab, version, pos := m.tabs.ActiveTab(), m.buffer.Version(), m.buffer.CursorPos()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lspRequestTimeout)
+		defer cancel()
+		items, err := client.Completion(ctx, uri, lspPos)
+		return lspCompletionMsg{tab: tab, version: version, pos: pos, items: items, err: err}
+	}
+}
+
+// finishLSPCompletion shows the completion popup with the server's items,
+// falling back to word completion if there are none. Results for a buffer
+// that changed in the meantime are dropped.
+func (m *Model) finishLSPCompletion(msg lspCompletionMsg) {
+	if msg.tab != m.tabs.ActiveTab() || msg.version != m.buffer.Version() ||
+		msg.pos != m.buffer.CursorPos() || m.mode != ModeNormal || m.completion != nil {
+		return
+	}
+	if msg.err != nil {
+		m.SetStatusMessage("Completion failed: " + msg.err.Error())
+		return
+	}
+
+	start := msg.pos
+	for start > 0 && isWordRune(m.buffer.RuneAt(start-1)) {
+		start--
+	}
+	prefix := m.buffer.Slice(start, msg.pos)
+
+	sort.SliceStable(msg.items, func(i, j int) bool {
+		return sortKey(msg.items[i]) < sortKey(msg.items[j])
+	})
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, item := range msg.items {
+		text := item.Text()
+		if text != "" && text != prefix && !seen[text] {
+			seen[text] = true
+			candidates = append(candidates, text)
+		}
+	}
+	if len(candidates) == 0 {
+		if prefix == "" {
+			m.SetStatusMessage("No completions")
+			return
+		}
+		m.startCompletion()
+		return
+	}
+
+	m.completion = &completionState{
+		start:      start,
+		prefix:     prefix,
+		candidates: candidates,
+		index:      -1,
+	}
+	m.cycleCompletion(1)
+}
+
+// sortKey returns the key completion items are ordered by.
+func sortKey(item lsp.CompletionItem) string {
+	if item.SortText != "" {
+		return item.SortText
+	}
+	return item.Label
+}
+
+// openRenamePrompt asks for the new name of the symbol under the cursor.
+func (m *Model) openRenamePrompt() {
+	if _, _, ok := m.activeDocument(); !ok {
+		return
+	}
+	m.mode = ModeRename
+	m.inputBuffer = m.wordAtCursor()
+	m.inputPrompt = "Rename to: "
+}
+
+// wordAtCursor returns the word the cursor is in or after.
+func (m *Model) wordAtCursor() string {
+	pos := m.buffer.CursorPos()
+	start, end := pos, pos
+	for start > 0 && isWordRune(m.buffer.RuneAt(start-1)) {
+		start--
+	}
+	for end < m.buffer.Len() && isWordRune(m.buffer.RuneAt(end)) {
+		end++
+	}
+	return m.buffer.Slice(start, end)
+}
+
+// handleRenameInput handles input in rename mode.
+func (m *Model) handleRenameInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		name := strings.TrimSpace(m.inputBuffer)
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		if name == "" {
+			return m, nil
+		}
+		return m, m.lspRename(name)
+
+	case "esc", "ctrl+c":
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		m.SetStatusMessage("")
+		return m, nil
+
+	case "backspace":
+		if len(m.inputBuffer) > 0 {
+			runes := []rune(m.inputBuffer)
+			m.inputBuffer = string(runes[:len(runes)-1])
+		}
+		return m, nil
+
+	default:
+		if len(msg.Runes) > 0 {
+			m.inputBuffer += string(msg.Runes)
+		}
+		return m, nil
+	}
+}
+
+// lspRename renames the symbol under the cursor to name.
+func (m *Model) lspRename(name string) tea.Cmd {
+	server, doc, ok := m.activeDocument()
+	if !ok {
+		return nil
+	}
+	client, uri, pos := server.client, doc.uri, m.lspPosition()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lspRequestTimeout)
+		defer cancel()
+		edit, err := client.Rename(ctx, uri, pos, name)
+		return lspRenameMsg{name: name, edit: edit, err: err}
+	}
+}
+
+// finishLSPRename applies the edits of a rename. Files that are not open
+// are opened in new tabs and left unsaved for review.
+func (m *Model) finishLSPRename(msg lspRenameMsg) {
+	if msg.err != nil {
+		m.SetStatusMessage("Rename failed: " + msg.err.Error())
+		return
+	}
+	edits := msg.edit.FileEdits()
+	if len(edits) == 0 {
+		m.SetStatusMessage("Nothing to rename")
+		return
+	}
+
+	uris := make([]string, 0, len(edits))
+	for uri := range edits {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	active := m.tabs.ActiveTab()
+	count, opened := 0, 0
+	for _, uri := range uris {
+		path := lsp.URIPath(uri)
+		if path == "" {
+			continue
+		}
+		if !m.selectFileTab(path) {
+			if !m.openFileTab(path) {
+				continue
+			}
+			opened++
+		}
+		count += m.applyTextEdits(edits[uri])
+	}
+	for i, tab := range m.tabs.Tabs() {
+		if tab == active {
+			m.SelectTab(i)
+		}
+	}
+
+	status := fmt.Sprintf("Renamed %d occurrences in %d files to %s", count, len(uris), msg.name)
+	if opened > 0 {
+		status += fmt.Sprintf(" (%d opened, unsaved)", opened)
+	}
+	m.SetStatusMessage(status)
+}
+
+// applyTextEdits applies protocol edits to the active buffer as a single
+// undo step, keeping the cursor on the same text. It returns the number
+// of edits applied.
+func (m *Model) applyTextEdits(edits []lsp.TextEdit) int {
+	type replacement struct {
+		start, end, index int
+		text              string
+	}
+	replacements := make([]replacement, 0, len(edits))
+	for i, edit := range edits {
+		start, end := m.lspOffset(edit.Range.Start), m.lspOffset(edit.Range.End)
+		replacements = append(replacements, replacement{start, max(start, end), i, edit.NewText})
+	}
+	// Later edits first, so earlier offsets stay valid; edits at the same
+	// position are inserted in their original order
+	sort.Slice(replacements, func(i, j int) bool {
+		if replacements[i].start != replacements[j].start {
+			return replacements[i].start > replacements[j].start
+		}
+		return replacements[i].index > replacements[j].index
+	})
+
+	cursor := m.buffer.CursorPos()
+	m.clearSelection()
+	m.history.BeginGroup()
+	for _, r := range replacements {
+		if r.end <= cursor {
+			cursor += len([]rune(r.text)) - (r.end - r.start)
+		}
+		m.replaceRange(r.start, r.end, r.text)
+	}
+	m.history.EndGroup()
+	m.buffer.MoveTo(min(max(cursor, 0), m.buffer.Len()))
+	return len(replacements)
+}
+
+// lspFormat formats the buffer with the language server.
+func (m *Model) lspFormat() tea.Cmd {
+	if m.readonly {
+		m.SetStatusMessage("File is read-only")
+		return nil
+	}
+	server, doc, ok := m.activeDocument()
+	if !ok {
+		return nil
+	}
+	client, uri := server.client, doc.uri
+	tab, version, tabSize, spaces := m.tabs.ActiveTab(), m.buffer.Version(), m.tabSize, m.insertSpaces
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lspRequestTimeout)
+		defer cancel()
+		edits, err := client.Format(ctx, uri, tabSize, spaces)
+		return lspFormatMsg{tab: tab, version: version, edits: edits, err: err}
+	}
+}
+
+// finishLSPFormat applies formatting edits through applyFormatted, so the
+// cursor stays on its line. Edits for a buffer that changed in the
+// meantime are dropped.
+func (m *Model) finishLSPFormat(msg lspFormatMsg) {
+	switch {
+	case msg.err != nil:
+		m.SetStatusMessage("Format failed: " + msg.err.Error())
+		return
+	case msg.tab != m.tabs.ActiveTab() || msg.version != m.buffer.Version():
+		m.SetStatusMessage("Buffer changed, formatting discarded")
+		return
+	case len(msg.edits) == 0:
+		m.SetStatusMessage("Already formatted")
+		return
+	}
+
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	replacements := make([]replacement, 0, len(msg.edits))
+	for _, edit := range msg.edits {
+		start, end := m.lspOffset(edit.Range.Start), m.lspOffset(edit.Range.End)
+		replacements = append(replacements, replacement{start, max(start, end), edit.NewText})
+	}
+	sort.SliceStable(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	runes := []rune(m.buffer.String())
+	var b strings.Builder
+	pos := 0
+	for _, r := range replacements {
+		if r.start < pos {
+			continue // overlapping edits are invalid
+		}
+		b.WriteString(string(runes[pos:r.start]))
+		b.WriteString(r.text)
+		pos = r.end
+	}
+	b.WriteString(string(runes[pos:]))
+
+	m.applyFormatted(b.String())
+	m.SetStatusMessage("Formatted")
+}
+
+// findProjectRoot returns the nearest directory at or above dir containing
+// a project marker, or dir itself if there is none.
+func findProjectRoot(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	for d := dir; ; {
+		for _, marker := range projectMarkers {
+			if _, err := os.Stat(filepath.Join(d, marker)); err == nil {
+				return d
+			}
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// CloseLanguageServers shuts down the running language servers.
+func (m *Model) CloseLanguageServers() {
+	for language, server := range m.lspServers {
+		if server.client != nil {
+			server.client.Close()
+		}
+		delete(m.lspServers, language)
+	}
+}
@@ -0,0 +1,263 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/lsp/lsptest"
+)
+
+func TestMain(m *testing.M) {
+	lsptest.Main()
+
+	// Keep prompt histories and other state out of the user's directories
+	stateDir, err := os.MkdirTemp("", "gesh-state")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_STATE_HOME", stateDir)
+	os.Setenv("LOCALAPPDATA", stateDir)
+	code := m.Run()
+	os.RemoveAll(stateDir)
+	os.Exit(code)
+}
+
+// newLSPModel writes files to a temporary project, opens main.go and
+// starts the fake language server for it. It returns the model, the
+// project directory and the command waiting for diagnostics.
+func newLSPModel(t *testing.T, files map[string]string) (*Model, string, tea.Cmd) {
+	t.Helper()
+	t.Setenv(lsptest.EnvVar, "1")
+	dir := t.TempDir()
+	files["go.mod"] = "module example\n"
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "main.go")
+	m := NewFromFile(path, "main.go", files["main.go"])
+	m.SetLanguageServers(map[string]string{"go": lsptest.Command()})
+	t.Cleanup(m.CloseLanguageServers)
+
+	start := m.syncLSP()
+	if start == nil {
+		t.Fatal("no language server started")
+	}
+	_, wait := m.Update(start())
+	if wait == nil {
+		t.Fatalf("server did not start: %s", m.statusMessage)
+	}
+	return m, dir, wait
+}
+
+// runLSPCmd runs a command synchronously and delivers its message,
+// returning the command that follows.
+func runLSPCmd(t *testing.T, m *Model, cmd tea.Cmd) tea.Cmd {
+	t.Helper()
+	if cmd == nil {
+		t.Fatalf("no command started: %s", m.statusMessage)
+	}
+	_, next := m.Update(cmd())
+	return next
+}
+
+func TestLSPDiagnostics(t *testing.T) {
+	m, _, wait := newLSPModel(t, map[string]string{
+		"main.go": "package main\n\nfunc main() {\n\t// TODO: work\n}\n",
+	})
+
+	wait = runLSPCmd(t, m, wait)
+	d, ok := m.lineDiagnostic(3)
+	if !ok || d.Message != "TODO found" || d.Col != 5 {
+		t.Fatalf("line 4 diagnostic = %+v, %v", d, ok)
+	}
+
+	// Edits are sent incrementally and diagnostics follow the server
+	for _, r := range "// TODO" {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := m.lineDiagnostic(4); !ok {
+		t.Error("diagnostic did not move down with its line")
+	}
+	for len(m.diagnostics()) != 2 {
+		wait = runLSPCmd(t, m, wait)
+	}
+	if _, ok := m.lineDiagnostic(0); !ok {
+		t.Error("no diagnostic for the typed TODO")
+	}
+}
+
+func TestLSPDefinitionAndHover(t *testing.T) {
+	m, dir, _ := newLSPModel(t, map[string]string{
+		"main.go": "package main\n\nfunc main() {\n\thelper()\n}\n",
+		"util.go": "package main\n\nfunc helper() {}\n",
+	})
+	m.GotoLine(4, 3)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}, Alt: true})
+	runLSPCmd(t, m, cmd)
+	if m.statusMessage != "func helper() — Hover for helper" {
+		t.Errorf("hover status = %q", m.statusMessage)
+	}
+
+	// A single definition in a closed file opens it in a new tab
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyF12})
+	runLSPCmd(t, m, cmd)
+	if m.filename != "util.go" || m.tabs.Count() != 2 {
+		t.Fatalf("active file = %q with %d tabs, want util.go in a new tab", m.filename, m.tabs.Count())
+	}
+	if line, col := m.buffer.CurrentLine(), m.buffer.CurrentColumn(); line != 2 || col != 5 {
+		t.Errorf("cursor = %d:%d, want 2:5", line, col)
+	}
+	if server := m.lspServers["go"]; len(server.docs) != 2 {
+		t.Errorf("server has %d open documents, want 2", len(server.docs))
+	}
+
+	// Several definitions are offered in a picker
+	if err := os.WriteFile(filepath.Join(dir, "other.go"), []byte("package main\n\nfunc helper() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.SelectTab(0)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyF12})
+	runLSPCmd(t, m, cmd)
+	if m.mode != ModePicker || len(m.picker.items) != 2 {
+		t.Fatalf("mode = %v, want a picker with two definitions", m.mode)
+	}
+	m.picker.query = "other"
+	m.picker.filter()
+	m.handlePickerInput(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filename != "other.go" || m.tabs.Count() != 3 {
+		t.Errorf("active file = %q with %d tabs, want other.go", m.filename, m.tabs.Count())
+	}
+
+	// Closing a tab closes its document
+	m.CloseTab()
+	m.syncLSP()
+	if server := m.lspServers["go"]; len(server.docs) != 2 {
+		t.Errorf("server has %d open documents after closing a tab, want 2", len(server.docs))
+	}
+}
+
+func TestLSPCompletion(t *testing.T) {
+	m, _, _ := newLSPModel(t, map[string]string{
+		"main.go": "package main\n\nfunc helperOne() {}\nfunc helperTwo() {}\n\nfunc main() {\n\thel\n}\n",
+	})
+	m.GotoLine(7, 5)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlCloseBracket})
+	runLSPCmd(t, m, cmd)
+	if m.completion == nil || len(m.completion.candidates) != 2 {
+		t.Fatalf("completion = %+v, want two candidates", m.completion)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.buffer.Line(6); got != "\thelperTwo" {
+		t.Errorf("line after completion = %q", got)
+	}
+}
+
+func TestLSPRename(t *testing.T) {
+	content := "package main\n\nfunc helper() {}\n\nfunc main() {\n\thelper()\n}\n"
+	m, dir, _ := newLSPModel(t, map[string]string{
+		"main.go": content,
+		"util.go": "package main\n\nfunc use() { helper() }\n",
+	})
+	m.GotoLine(6, 3)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyF2})
+	if m.mode != ModeRename || m.inputBuffer != "helper" {
+		t.Fatalf("mode = %v with input %q, want the rename prompt", m.mode, m.inputBuffer)
+	}
+	m.inputBuffer = "assist"
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	runLSPCmd(t, m, cmd)
+
+	if m.filename != "main.go" {
+		t.Errorf("active file = %q, want main.go", m.filename)
+	}
+	if got := m.buffer.String(); strings.Count(got, "assist") != 2 || strings.Contains(got, "helper") {
+		t.Errorf("main.go = %q", got)
+	}
+	if line, col := m.buffer.CurrentLine(), m.buffer.CurrentColumn(); line != 5 || col != 2 {
+		t.Errorf("cursor = %d:%d, want 5:2", line, col)
+	}
+
+	// Files that were not open are opened and left unsaved
+	tab := m.tabs.Tabs()[1]
+	if tab.filename != "util.go" || !tab.modified || !strings.Contains(tab.buffer.String(), "assist()") {
+		t.Errorf("util.go tab = %q modified=%v", tab.buffer.String(), tab.modified)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "util.go")); strings.Contains(string(data), "assist") {
+		t.Error("util.go was written to disk")
+	}
+
+	// The rename is a single undo step per file
+	m.undo()
+	if got := m.buffer.String(); got != content {
+		t.Errorf("after undo = %q", got)
+	}
+}
+
+func TestLSPFormat(t *testing.T) {
+	m, _, _ := newLSPModel(t, map[string]string{
+		"main.go": "package main  \n\nfunc main() {\t\n}\n",
+	})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}, Alt: true})
+	runLSPCmd(t, m, cmd)
+	if got := m.buffer.String(); got != "package main\n\nfunc main() {\n}\n" {
+		t.Errorf("formatted = %q", got)
+	}
+	m.undo()
+	if got := m.buffer.String(); got != "package main  \n\nfunc main() {\t\n}\n" {
+		t.Errorf("after undo = %q", got)
+	}
+
+	// Edits for an outdated buffer are discarded
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}, Alt: true})
+	msg := cmd()
+	typeText(m, "x")
+	m.Update(msg)
+	if !strings.Contains(m.buffer.String(), "main  ") {
+		t.Error("outdated formatting edits were applied")
+	}
+}
+
+func TestLSPServerFailure(t *testing.T) {
+	m := NewFromFile(filepath.Join(t.TempDir(), "main.go"), "main.go", "package main\n")
+	m.SetLanguageServers(map[string]string{"go": filepath.Join(t.TempDir(), "missing-server")})
+
+	runLSPCmd(t, m, m.syncLSP())
+	if !strings.Contains(m.statusMessage, "failed") {
+		t.Errorf("status = %q, want a start failure", m.statusMessage)
+	}
+	if cmd := m.syncLSP(); cmd != nil {
+		t.Error("failed server was started again")
+	}
+	if cmd := m.lspHover(); cmd != nil || m.statusMessage != "Language server is not running yet" {
+		t.Errorf("hover status = %q", m.statusMessage)
+	}
+}
+
+func TestHoverSummary(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"```go\nfunc Println(a ...any)\n```\n\nPrintln formats.\n\nMore.", "func Println(a ...any) — Println formats."},
+		{"plain", "plain"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := hoverSummary(tt.text); got != tt.want {
+			t.Errorf("hoverSummary(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
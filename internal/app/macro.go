@@ -14,15 +14,22 @@ import (
 // MacroRecorder handles macro recording and playback.
 type MacroRecorder struct {
 	recording  bool
-	keys       []tea.KeyMsg
+	keys       []MacroStep
 	playing    bool
 	playIndex  int
 }
 
+// MacroStep is a recorded key press, or a named action run from the
+// command palette.
+type MacroStep struct {
+	Key    tea.KeyMsg
+	Action string // action name; the key is unused when set
+}
+
 // NewMacroRecorder creates a new macro recorder.
 func NewMacroRecorder() *MacroRecorder {
 	return &MacroRecorder{
-		keys: make([]tea.KeyMsg, 0),
+		keys: make([]MacroStep, 0),
 	}
 }
 
@@ -39,7 +46,7 @@ func (mr *MacroRecorder) IsPlaying() bool {
 // StartRecording begins macro recording.
 func (mr *MacroRecorder) StartRecording() {
 	mr.recording = true
-	mr.keys = make([]tea.KeyMsg, 0)
+	mr.keys = make([]MacroStep, 0)
 }
 
 // StopRecording ends macro recording.
@@ -60,12 +67,22 @@ func (mr *MacroRecorder) ToggleRecording() bool {
 // RecordKey records a key press.
 func (mr *MacroRecorder) RecordKey(key tea.KeyMsg) {
 	if mr.recording && !mr.playing {
-		// Don't record macro control keys (F4=toggle recording, F5=play)
-		keyStr := key.String()
-		if keyStr == "ctrl+m" || keyStr == "ctrl+shift+m" || keyStr == "f4" || keyStr == "f5" {
-			return
-		}
-		mr.keys = append(mr.keys, key)
+		mr.keys = append(mr.keys, MacroStep{Key: key})
+	}
+}
+
+// RecordAction records a named action.
+func (mr *MacroRecorder) RecordAction(name string) {
+	if mr.recording && !mr.playing {
+		mr.keys = append(mr.keys, MacroStep{Action: name})
+	}
+}
+
+// DropLastKey removes the last recorded step, such as the key that opened
+// the command palette, whose chosen action is recorded instead.
+func (mr *MacroRecorder) DropLastKey() {
+	if mr.recording && !mr.playing && len(mr.keys) > 0 {
+		mr.keys = mr.keys[:len(mr.keys)-1]
 	}
 }
 
@@ -79,8 +96,8 @@ func (mr *MacroRecorder) Play() bool {
 	return true
 }
 
-// NextKey returns the next key in the macro, or nil if done.
-func (mr *MacroRecorder) NextKey() *tea.KeyMsg {
+// NextKey returns the next step of the macro, or nil if done.
+func (mr *MacroRecorder) NextKey() *MacroStep {
 	if !mr.playing || mr.playIndex >= len(mr.keys) {
 		mr.playing = false
 		return nil
@@ -92,7 +109,7 @@ func (mr *MacroRecorder) NextKey() *tea.KeyMsg {
 
 // Clear clears the recorded macro.
 func (mr *MacroRecorder) Clear() {
-	mr.keys = make([]tea.KeyMsg, 0)
+	mr.keys = make([]MacroStep, 0)
 	mr.recording = false
 	mr.playing = false
 }
@@ -102,12 +119,14 @@ func (mr *MacroRecorder) KeyCount() int {
 	return len(mr.keys)
 }
 
-// SerializedKey represents a key for JSON serialization.
+// SerializedKey represents a key, or a named action, for JSON
+// serialization.
 type SerializedKey struct {
-	Type  string   `json:"type"`
-	Runes []rune   `json:"runes,omitempty"`
-	Alt   bool     `json:"alt,omitempty"`
-	Paste bool     `json:"paste,omitempty"`
+	Type   string   `json:"type,omitempty"`
+	Runes  []rune   `json:"runes,omitempty"`
+	Alt    bool     `json:"alt,omitempty"`
+	Paste  bool     `json:"paste,omitempty"`
+	Action string   `json:"action,omitempty"`
 }
 
 // MacroFile represents the macro file format.
@@ -138,8 +157,12 @@ func getMacrosFilePath() string {
 	return filepath.Join(getMacrosDir(), "macros.json")
 }
 
-// serializeKey converts a tea.KeyMsg to SerializedKey.
-func serializeKey(key tea.KeyMsg) SerializedKey {
+// serializeKey converts a macro step to SerializedKey.
+func serializeKey(step MacroStep) SerializedKey {
+	if step.Action != "" {
+		return SerializedKey{Action: step.Action}
+	}
+	key := step.Key
 	return SerializedKey{
 		Type:  key.String(),
 		Runes: key.Runes,
@@ -148,14 +171,17 @@ func serializeKey(key tea.KeyMsg) SerializedKey {
 	}
 }
 
-// deserializeKey converts a SerializedKey to tea.KeyMsg.
-func deserializeKey(sk SerializedKey) tea.KeyMsg {
-	return tea.KeyMsg{
+// deserializeKey converts a SerializedKey to a macro step.
+func deserializeKey(sk SerializedKey) MacroStep {
+	if sk.Action != "" {
+		return MacroStep{Action: sk.Action}
+	}
+	return MacroStep{Key: tea.KeyMsg{
 		Type:  parseKeyType(sk.Type),
 		Runes: sk.Runes,
 		Alt:   sk.Alt,
 		Paste: sk.Paste,
-	}
+	}}
 }
 
 // parseKeyType parses a key string to tea.KeyType.
@@ -285,8 +311,8 @@ func (mr *MacroRecorder) LoadMacro(name string) error {
 		return os.ErrNotExist
 	}
 
-	// Convert to tea.KeyMsg
-	mr.keys = make([]tea.KeyMsg, len(serialized))
+	// Convert to macro steps
+	mr.keys = make([]MacroStep, len(serialized))
 	for i, sk := range serialized {
 		mr.keys[i] = deserializeKey(sk)
 	}
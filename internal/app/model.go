@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+	"github.com/KilimcininKorOglu/gesh/internal/keymap"
+	"github.com/KilimcininKorOglu/gesh/internal/snippet"
+	"github.com/KilimcininKorOglu/gesh/internal/spell"
 	"github.com/KilimcininKorOglu/gesh/internal/syntax"
 	"github.com/KilimcininKorOglu/gesh/internal/ui/styles"
 )
@@ -37,6 +40,34 @@ const (
 	ModeSaveMacro
 	// ModeLoadMacro is the "load macro" mode.
 	ModeLoadMacro
+	// ModePicker is the list picker mode (bookmarks, files, commands).
+	ModePicker
+	// ModeAlign is the "align on delimiter" mode.
+	ModeAlign
+	// ModeExecute is the "execute command" mode.
+	ModeExecute
+	// ModeRename is the "rename symbol" mode.
+	ModeRename
+	// ModeProjectSearch is the "search in project" mode.
+	ModeProjectSearch
+	// ModeProjectReplace is the "replace in project" mode.
+	ModeProjectReplace
+	// ModeProjectReplaceWith is the "replace in project with" mode.
+	ModeProjectReplaceWith
+	// ModeReplacePreview shows the changes of a project replace.
+	ModeReplacePreview
+	// ModeFileFinder is the fuzzy file finder mode.
+	ModeFileFinder
+	// ModeFileTree is the mode while the file tree sidebar has the focus.
+	ModeFileTree
+	// ModeTreeCreate is the "new file" mode of the file tree.
+	ModeTreeCreate
+	// ModeTreeRename is the "rename file" mode of the file tree.
+	ModeTreeRename
+	// ModeTreeDelete is the delete confirmation mode of the file tree.
+	ModeTreeDelete
+	// ModeViCommand is the command line of the vi keymap (":").
+	ModeViCommand
 )
 
 // Model is the main Bubble Tea model for the editor.
@@ -67,6 +98,10 @@ type Model struct {
 
 	// Edit mode
 	overwriteMode bool // false = insert, true = overwrite
+	autoPairs     bool // auto-close brackets and quotes
+	autoClosed    autoCloseState
+	tabSize       int  // width of one indentation level
+	insertSpaces  bool // indent with spaces instead of tabs
 
 	// Save options
 	trimTrailingSpaces bool
@@ -115,6 +150,69 @@ type Model struct {
 	// Macro recorder
 	macro *MacroRecorder
 
+	// List picker (active in ModePicker)
+	picker *picker
+
+	// Word completion in progress (nil when not completing)
+	completion *completionState
+
+	// External command (nil when none is running)
+	command        *commandRun
+	commandSeq     int
+	commandReplace bool // execute prompt: output replaces its input
+
+	// Snippets by language name (loaded on first use) and the active snippet
+	snippets      map[string]map[string]snippet.Snippet
+	activeSnippet *snippetSession
+
+	// Spell checking (the dictionary is loaded on first use)
+	spellCheck      bool   // underline misspelled words
+	spellDictionary string // dictionary name or path
+	speller         *spell.Checker
+
+	// Linting (nil lint when no linter is running)
+	lintCommands   map[string]string // language name -> command
+	lintOnSave     bool
+	lint           *lintRun
+	lintSeq        int
+	diagnosticLine int // cursor line whose diagnostic was last shown
+
+	// Formatter commands run before saving, by lowercase language name
+	formatCommands map[string]string
+
+	// Language server commands and servers by lowercase language name
+	lspCommands map[string]string
+	lspServers  map[string]*lspServer
+
+	// Positions to return to after jumping to a definition
+	jumpStack []jumpPosition
+
+	// Last search across the project, possibly still running
+	projectSearch *projectSearch
+	projectRegex  bool // project searches use regular expressions
+
+	// Replacement across the project being prompted for or previewed
+	projectReplace *projectReplace
+
+	// Fuzzy file finder, while it is shown
+	finder *fileFinder
+
+	// File tree sidebar, created when first shown
+	tree *fileTree
+
+	// Tab completion in a path prompt, while its candidates are listed
+	pathComplete *pathCompletion
+
+	// Prompt history being browsed with Up and Down
+	historyNav *historyNav
+
+	// Key bindings, the defaults until set; keys typed so far of a chord
+	keymap *keymap.Keymap
+	keySeq []string
+
+	// Modal vi keymap, nil unless enabled
+	vi *viState
+
 	// Auto-save
 	autoSaveInterval int // seconds, 0 = disabled
 	lastSaveTime     int64
@@ -130,6 +228,9 @@ type Model struct {
 	// Syntax highlighter (cached per model)
 	highlighter *syntax.Highlighter
 
+	// Last bracket match, reused while the buffer and cursor are unchanged
+	brackets bracketCache
+
 	// Status message
 	statusMessage string
 
@@ -154,6 +255,8 @@ func New() *Model {
 		showLineNumbers:    true,
 		syntaxHighlighting: true,
 		showTabs:           true,
+		tabSize:            4,
+		insertSpaces:       true,
 		macro:              NewMacroRecorder(),
 		cachedLines:        make(map[int]string),
 		dirtyLines:         make(map[int]bool),
@@ -178,6 +281,8 @@ func NewWithContent(content string) *Model {
 		showLineNumbers:    true,
 		syntaxHighlighting: true,
 		showTabs:           true,
+		tabSize:            4,
+		insertSpaces:       true,
 		macro:              NewMacroRecorder(),
 		cachedLines:        make(map[int]string),
 		dirtyLines:         make(map[int]bool),
@@ -205,6 +310,8 @@ func NewFromFile(filepath, filename, content string) *Model {
 		showLineNumbers:    true,
 		syntaxHighlighting: true,
 		showTabs:           true,
+		tabSize:            4,
+		insertSpaces:       true,
 		macro:              NewMacroRecorder(),
 		cachedLines:        make(map[int]string),
 		dirtyLines:         make(map[int]bool),
@@ -232,6 +339,8 @@ func NewFromFileWithInfo(filepath, filename, content, encoding, lineEnding strin
 		showLineNumbers:    true,
 		syntaxHighlighting: true,
 		showTabs:           true,
+		tabSize:            4,
+		insertSpaces:       true,
 		macro:              NewMacroRecorder(),
 		cachedLines:        make(map[int]string),
 		dirtyLines:         make(map[int]bool),
@@ -542,12 +651,29 @@ func (m *Model) OpenFileInNewTab(filepath, filename, content, encoding, lineEndi
 	m.syncFromActiveTab()
 }
 
+// ReplaceTabWithFile opens a file in place of the current tab, saving the
+// bookmarks changed in the replaced tab first. Folds and diagnostics of the
+// replaced file are dropped with its tab.
+func (m *Model) ReplaceTabWithFile(filepath, filename, content, encoding, lineEnding string) error {
+	m.syncToActiveTab()
+	err := saveChangedBookmarks(m.tabs.ActiveTab())
+	tab := NewTabFromFile(filepath, filename, content, encoding, lineEnding)
+	m.tabs.ReplaceActiveTab(tab)
+	m.syncFromActiveTab()
+	m.diagnosticLine = -1
+	return err
+}
+
 // CloseTab closes the current tab.
 // Returns true if the tab was closed, false if it's the last tab.
 func (m *Model) CloseTab() bool {
 	if m.tabs.Count() <= 1 {
 		return false
 	}
+	m.syncToActiveTab()
+	if err := saveChangedBookmarks(m.tabs.ActiveTab()); err != nil {
+		m.SetStatusMessage("Error saving bookmarks: " + err.Error())
+	}
 	if m.tabs.CloseActiveTab() {
 		m.syncFromActiveTab()
 		return true
@@ -605,6 +731,48 @@ func (m *Model) ToggleOverwriteMode() {
 	}
 }
 
+// SetAutoPairs sets whether brackets and quotes are auto-closed.
+func (m *Model) SetAutoPairs(enabled bool) {
+	m.autoPairs = enabled
+}
+
+// SetSpellCheck sets whether misspelled words are underlined and which
+// dictionary is used.
+func (m *Model) SetSpellCheck(enabled bool, dictionary string) {
+	m.spellCheck = enabled
+	m.spellDictionary = dictionary
+}
+
+// SetLint sets the lint commands by lowercase language name and whether
+// files are linted after saving.
+func (m *Model) SetLint(commands map[string]string, onSave bool) {
+	m.lintCommands = commands
+	m.lintOnSave = onSave
+}
+
+// SetFormatters sets the formatter commands run before saving, by
+// lowercase language name.
+func (m *Model) SetFormatters(commands map[string]string) {
+	m.formatCommands = commands
+}
+
+// SetLanguageServers sets the language server commands, by lowercase
+// language name. Servers are started when a file of their language is
+// first opened.
+func (m *Model) SetLanguageServers(commands map[string]string) {
+	m.lspCommands = commands
+}
+
+// SetTabSize sets the width of one indentation level.
+func (m *Model) SetTabSize(size int) {
+	m.tabSize = size
+}
+
+// SetInsertSpaces sets whether indentation uses spaces instead of tabs.
+func (m *Model) SetInsertSpaces(spaces bool) {
+	m.insertSpaces = spaces
+}
+
 // SetAutoSaveInterval sets the auto-save interval in seconds.
 func (m *Model) SetAutoSaveInterval(seconds int) {
 	m.autoSaveInterval = seconds
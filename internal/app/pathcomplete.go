@@ -0,0 +1,213 @@
+// Package app provides Tab completion of paths in prompts.
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCandidateRows limits the rows of the completion list above a prompt.
+const maxCandidateRows = 6
+
+// pathCompletion is the state of Tab completion in a path prompt, kept
+// while Tab is pressed repeatedly.
+type pathCompletion struct {
+	dir        string   // directory part of the input, as typed
+	candidates []string // matching names; directories end with "/"
+	index      int      // candidate in the input while cycling, or -1
+	input      string   // the input the completion produced
+}
+
+// handlePathCompletion handles Tab and Shift+Tab in a path prompt and
+// reports whether it did. Other keys end the completion. Relative paths
+// are completed against base, or the working directory when it is "".
+func (m *Model) handlePathCompletion(msg tea.KeyMsg, base string, dirsOnly bool) bool {
+	switch msg.String() {
+	case "tab":
+		m.completePath(base, dirsOnly, 1)
+		return true
+	case "shift+tab":
+		m.completePath(base, dirsOnly, -1)
+		return true
+	}
+	m.pathComplete = nil
+	return false
+}
+
+// completePath completes the prompt input. The first Tab completes the
+// longest common prefix of the candidates and lists them; the next ones
+// cycle through them, in the direction of step.
+func (m *Model) completePath(base string, dirsOnly bool, step int) {
+	if c := m.pathComplete; c != nil && c.input == m.inputBuffer {
+		n := len(c.candidates)
+		if c.index < 0 && step < 0 {
+			c.index = n - 1
+		} else {
+			c.index = (c.index + step + n) % n
+		}
+		m.inputBuffer = c.dir + c.candidates[c.index]
+		c.input = m.inputBuffer
+		return
+	}
+	m.pathComplete = nil
+	if step < 0 {
+		return
+	}
+	if m.inputBuffer == "~" {
+		m.inputBuffer = "~/"
+		return
+	}
+
+	dir, name := splitPathInput(m.inputBuffer)
+	candidates, err := pathCandidates(resolveDir(base, dir), name, dirsOnly)
+	switch {
+	case err != nil:
+		m.SetStatusMessage("Error: " + err.Error())
+	case len(candidates) == 0:
+		m.SetStatusMessage("No matches")
+	case len(candidates) == 1:
+		m.inputBuffer = dir + candidates[0]
+	default:
+		m.inputBuffer = dir + commonPrefix(candidates)
+		m.pathComplete = &pathCompletion{dir: dir, candidates: candidates, index: -1, input: m.inputBuffer}
+	}
+}
+
+// splitPathInput splits a typed path after its last separator.
+func splitPathInput(input string) (dir, name string) {
+	i := strings.LastIndexAny(input, "/"+string(filepath.Separator))
+	return input[:i+1], input[i+1:]
+}
+
+// expandHome replaces a leading "~" with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// resolveDir returns the directory a typed directory part names.
+func resolveDir(base, dir string) string {
+	dir = expandHome(dir)
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsAbs(dir) && base != "" {
+		dir = filepath.Join(base, dir)
+	}
+	return dir
+}
+
+// pathCandidates lists the names in dir starting with prefix, directories
+// with a trailing "/". Hidden names are listed only for a prefix starting
+// with a dot.
+func pathCandidates(dir, prefix string, dirsOnly bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if isDir {
+			name += "/"
+		} else if dirsOnly {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// commonPrefix returns the longest prefix shared by all names.
+func commonPrefix(names []string) string {
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+// overlayPathCandidates draws the completion candidates over the bottom
+// rows of the editor area, just above the prompt.
+func (m *Model) overlayPathCandidates(area string) string {
+	c := m.pathComplete
+	lines := strings.Split(strings.TrimSuffix(area, "\n"), "\n")
+
+	colWidth := 0
+	for _, name := range c.candidates {
+		colWidth = max(colWidth, len([]rune(name))+2)
+	}
+	colWidth = min(colWidth, max(1, m.width-1))
+	cols := max(1, (m.width-1)/colWidth)
+	maxRows := min(maxCandidateRows, len(lines)-1)
+	if maxRows < 1 {
+		return area
+	}
+
+	// Show the page holding the selected candidate; the last cell of a
+	// full page counts the candidates left out
+	n := len(c.candidates)
+	perPage := n
+	if n > maxRows*cols {
+		perPage = maxRows*cols - 1
+	}
+	start := 0
+	if c.index >= 0 && perPage > 0 {
+		start = c.index / perPage * perPage
+	}
+	end := min(n, start+perPage)
+
+	var rows []string
+	var row strings.Builder
+	cells := 0
+	flush := func() {
+		row.WriteString(helpStyle.Render(strings.Repeat(" ", max(0, m.width-1-cells*colWidth))))
+		rows = append(rows, helpStyle.Render(" ")+row.String())
+		row.Reset()
+		cells = 0
+	}
+	for i := start; i < end; i++ {
+		cell := padOrTruncate(c.candidates[i], colWidth)
+		if i == c.index {
+			row.WriteString(selectionStyle.Render(cell))
+		} else {
+			row.WriteString(helpStyle.Render(cell))
+		}
+		if cells++; cells == cols {
+			flush()
+		}
+	}
+	if end-start < n {
+		row.WriteString(helpStyle.Render(padOrTruncate(fmt.Sprintf("(+%d more)", n-(end-start)), colWidth)))
+		cells++
+	}
+	if cells > 0 {
+		flush()
+	}
+
+	copy(lines[len(lines)-len(rows):], rows)
+	return strings.Join(lines, "\n") + "\n"
+}
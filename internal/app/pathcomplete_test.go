@@ -0,0 +1,94 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPathCompletion(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"alpha.txt", "alpine/a.txt", "beta.go", ".alias"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HOME", dir)
+	m := New()
+	m.SetSize(80, 20)
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	typeText(m, "~/al")
+	m.Update(tab)
+	if m.inputBuffer != "~/alp" || m.pathComplete == nil {
+		t.Fatalf("input = %q, want the common prefix with candidates listed", m.inputBuffer)
+	}
+	if view := m.View(); !strings.Contains(view, "alpha.txt") || !strings.Contains(view, "alpine/") {
+		t.Errorf("candidates not listed:\n%s", view)
+	}
+
+	// Further Tabs cycle, Shift+Tab goes back
+	for _, want := range []string{"~/alpha.txt", "~/alpine/", "~/alpha.txt"} {
+		m.Update(tab)
+		if m.inputBuffer != want {
+			t.Errorf("input = %q, want %q", m.inputBuffer, want)
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if m.inputBuffer != "~/alpine/" {
+		t.Errorf("input = %q after Shift+Tab, want ~/alpine/", m.inputBuffer)
+	}
+
+	// Typing ends the completion; a single candidate completes fully
+	typeText(m, "a")
+	if m.pathComplete != nil {
+		t.Error("typing did not end the completion")
+	}
+	m.Update(tab)
+	if m.inputBuffer != "~/alpine/a.txt" {
+		t.Errorf("input = %q, want ~/alpine/a.txt", m.inputBuffer)
+	}
+
+	// Enter expands the home directory
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if want := filepath.Join(dir, "alpine", "a.txt"); m.filepath != want {
+		t.Errorf("opened %q, want %q (%s)", m.filepath, want, m.statusMessage)
+	}
+}
+
+func TestPathCandidates(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/x", "static/y", "setup.py", ".secret/z"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		prefix   string
+		dirsOnly bool
+		want     string
+	}{
+		{"s", false, "setup.py src/ static/"},
+		{"s", true, "src/ static/"},
+		{".", true, ".secret/"},
+		{"x", false, ""},
+	}
+	for _, tt := range tests {
+		got, err := pathCandidates(dir, tt.prefix, tt.dirsOnly)
+		if err != nil || strings.Join(got, " ") != tt.want {
+			t.Errorf("pathCandidates(%q, %v) = %q, %v, want %q", tt.prefix, tt.dirsOnly, got, err, tt.want)
+		}
+	}
+}
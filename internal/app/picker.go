@@ -0,0 +1,219 @@
+// Package app provides a filterable list picker shown in place of the editor.
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/fuzzy"
+)
+
+// pickerItem is a single entry of a picker list.
+type pickerItem struct {
+	label    string // main text
+	detail   string // secondary text shown after the label
+	onSelect func() // called when the item is chosen
+
+	// run is called instead of onSelect for items whose choice produces
+	// a command
+	run func() tea.Cmd
+}
+
+// picker is a list of items the user can filter and choose from.
+type picker struct {
+	title    string
+	items    []pickerItem
+	matches  []int // indices of items matching the query
+	query    string
+	selected int  // index into matches
+	top      int  // first visible match
+	fuzzy    bool // rank items by fuzzy matching instead of word matching
+
+	// onCancel is called when the picker is dismissed without a choice
+	onCancel func()
+}
+
+// openPicker shows a picker with the given items.
+func (m *Model) openPicker(title string, items []pickerItem) {
+	p := &picker{title: title, items: items}
+	p.filter()
+	m.picker = p
+	m.mode = ModePicker
+}
+
+// filter updates the matching items for the current query. Fuzzy
+// pickers list the matches best first.
+func (p *picker) filter() {
+	p.matches = p.matches[:0]
+	if p.fuzzy && strings.TrimSpace(p.query) != "" {
+		texts := make([]string, len(p.items))
+		for i, item := range p.items {
+			texts[i] = item.label + " " + item.detail
+		}
+		for _, r := range fuzzy.Filter(p.query, texts) {
+			p.matches = append(p.matches, r.Index)
+		}
+	} else {
+		for i, item := range p.items {
+			if p.match(item) {
+				p.matches = append(p.matches, i)
+			}
+		}
+	}
+	p.selected = 0
+	p.top = 0
+}
+
+// match reports whether an item matches the query: every word of the
+// query appears in the label or detail, or fuzzily matches for a fuzzy
+// picker.
+func (p *picker) match(item pickerItem) bool {
+	if p.fuzzy {
+		_, _, ok := fuzzy.Match(p.query, item.label+" "+item.detail)
+		return ok
+	}
+	text := strings.ToLower(item.label + " " + item.detail)
+	for _, word := range strings.Fields(strings.ToLower(p.query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// add appends items while the picker is shown, keeping the selection.
+func (p *picker) add(items ...pickerItem) {
+	for _, item := range items {
+		p.items = append(p.items, item)
+		if p.match(item) {
+			p.matches = append(p.matches, len(p.items)-1)
+		}
+	}
+}
+
+// move moves the selection by delta, clamped to the list.
+func (p *picker) move(delta int) {
+	p.selected += delta
+	if p.selected >= len(p.matches) {
+		p.selected = len(p.matches) - 1
+	}
+	if p.selected < 0 {
+		p.selected = 0
+	}
+}
+
+// handlePickerInput handles input while a picker is shown.
+func (m *Model) handlePickerInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.picker
+	if p == nil {
+		m.mode = ModeNormal
+		return m, nil
+	}
+	pageSize := m.pickerHeight() - 1
+
+	switch msg.String() {
+	case "esc", "ctrl+c", "ctrl+g":
+		m.closePicker()
+		m.SetStatusMessage("Cancelled")
+		if p.onCancel != nil {
+			p.onCancel()
+		}
+		return m, nil
+
+	case "enter":
+		if len(p.matches) == 0 {
+			return m, nil
+		}
+		item := p.items[p.matches[p.selected]]
+		m.closePicker()
+		if item.run != nil {
+			return m, item.run()
+		}
+		if item.onSelect != nil {
+			item.onSelect()
+		}
+		return m, nil
+
+	case "up", "ctrl+p", "shift+tab":
+		p.move(-1)
+	case "down", "ctrl+n", "tab":
+		p.move(1)
+	case "pgup", "ctrl+y":
+		p.move(-pageSize)
+	case "pgdown", "ctrl+v":
+		p.move(pageSize)
+
+	case "backspace", "ctrl+h":
+		if len(p.query) > 0 {
+			runes := []rune(p.query)
+			p.query = string(runes[:len(runes)-1])
+			p.filter()
+		}
+
+	default:
+		if len(msg.Runes) > 0 {
+			p.query += string(msg.Runes)
+			p.filter()
+		}
+	}
+	return m, nil
+}
+
+// closePicker hides the picker and returns to normal mode.
+func (m *Model) closePicker() {
+	m.picker = nil
+	m.mode = ModeNormal
+}
+
+// pickerHeight returns the number of rows available to the picker.
+func (m *Model) pickerHeight() int {
+	height := m.height - 4 // header(1) + status(1) + help(2)
+	if m.showTabs && m.TabCount() > 1 {
+		height--
+	}
+	if height < 2 {
+		height = 2
+	}
+	return height
+}
+
+// renderPicker renders the picker in place of the editor area.
+func (m *Model) renderPicker() string {
+	p := m.picker
+	height := m.pickerHeight()
+	var b strings.Builder
+
+	title := fmt.Sprintf(" %s (%d/%d)", p.title, len(p.matches), len(p.items))
+	b.WriteString(headerStyle.Width(m.width).Render(title))
+	b.WriteString("\n")
+
+	// Keep the selection visible
+	rows := height - 1
+	if p.selected < p.top {
+		p.top = p.selected
+	}
+	if p.selected >= p.top+rows {
+		p.top = p.selected - rows + 1
+	}
+
+	for i := 0; i < rows; i++ {
+		idx := p.top + i
+		if idx < len(p.matches) {
+			item := p.items[p.matches[idx]]
+			line := " " + item.label
+			if item.detail != "" {
+				line += "  " + item.detail
+			}
+			line = padOrTruncate(line, m.width)
+			if idx == p.selected {
+				b.WriteString(selectionStyle.Render(line))
+			} else {
+				b.WriteString(editorStyle.Render(line))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,529 @@
+// Package app provides previewed replacement across the files of a project.
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+	"github.com/KilimcininKorOglu/gesh/internal/file"
+	"github.com/KilimcininKorOglu/gesh/internal/project"
+)
+
+var (
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5555"))
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#50fa7b"))
+)
+
+// projectReplace is a replacement across the files of a project, previewed
+// before it is applied.
+type projectReplace struct {
+	query       string
+	pattern     *regexp.Regexp // compiled from query
+	regex       bool           // query is a regular expression
+	replacement string
+	root        string
+	files       []*replaceFile
+	selected    int // index into rows
+	top         int // first visible row
+}
+
+// replaceFile holds the changes of one file. Files open in a tab are
+// changed in the tab; others are rewritten on disk.
+type replaceFile struct {
+	path string
+	tab  *Tab
+	hits []*replaceHit
+}
+
+// replaceHit is a line of a file in which the text is replaced.
+type replaceHit struct {
+	line     int    // 0-based
+	old, new string // the whole line before and after
+	include  bool
+}
+
+// replaceRow is a row of the preview: a file, or one of its hits.
+type replaceRow struct {
+	file *replaceFile
+	hit  *replaceHit
+}
+
+// openProjectReplacePrompt asks for the text to replace in the project.
+func (m *Model) openProjectReplacePrompt() {
+	m.mode = ModeProjectReplace
+	m.inputBuffer = ""
+	m.inputPrompt = m.projectPrompt("Replace in project")
+}
+
+// handleProjectReplaceInput handles input in the prompts for the searched
+// text and its replacement. An empty search uses the word under the cursor;
+// Alt+R switches between literal text and regular expressions.
+func (m *Model) handleProjectReplaceInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		if m.mode == ModeProjectReplace {
+			query := m.inputBuffer
+			if query == "" {
+				query = m.wordAtCursor()
+			}
+			if query == "" {
+				return m, nil
+			}
+			pattern, ok := m.compileProjectQuery(query)
+			if !ok {
+				return m, nil
+			}
+			m.projectReplace = &projectReplace{query: query, pattern: pattern, regex: m.projectRegex}
+			m.mode = ModeProjectReplaceWith
+			m.inputBuffer = m.replaceText
+			m.inputPrompt = "Replace with: "
+			return m, nil
+		}
+		m.replaceText = m.inputBuffer
+		m.projectReplace.replacement = m.inputBuffer
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		cmd := m.startProjectSearch(m.projectReplace.query, m.projectReplace.pattern)
+		m.projectSearch.replace = m.projectReplace
+		m.picker.title = m.projectSearch.title()
+		return m, cmd
+
+	case "alt+r":
+		if m.mode == ModeProjectReplace {
+			m.projectRegex = !m.projectRegex
+			m.inputPrompt = m.projectPrompt("Replace in project")
+		}
+		return m, nil
+
+	case "esc", "ctrl+c":
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		m.projectReplace = nil
+		m.SetStatusMessage("")
+		return m, nil
+
+	case "backspace":
+		if len(m.inputBuffer) > 0 {
+			runes := []rune(m.inputBuffer)
+			m.inputBuffer = string(runes[:len(runes)-1])
+		}
+		return m, nil
+
+	default:
+		if len(msg.Runes) > 0 {
+			m.inputBuffer += string(msg.Runes)
+		}
+		return m, nil
+	}
+}
+
+// openReplacePreview lists the changes of a replacement once the search
+// for the files containing the text is done. Open tabs are previewed with
+// their current, possibly unsaved, text.
+func (m *Model) openReplacePreview(s *projectSearch) {
+	p := s.replace
+	p.root = s.root
+	m.closePicker()
+
+	paths := make(map[string]bool)
+	for _, match := range s.matches {
+		paths[match.Path] = true
+	}
+	tabs := make(map[string]*Tab)
+	for _, tab := range m.tabs.Tabs() {
+		path, _ := m.tabFile(tab)
+		if path == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(p.root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				tabs[abs] = tab
+				paths[abs] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	p.files = nil
+	for _, path := range sorted {
+		f := &replaceFile{path: path, tab: tabs[path]}
+		var content string
+		if f.tab != nil {
+			content = m.tabBuffer(f.tab).String()
+		} else if data, err := os.ReadFile(path); err == nil && !project.IsBinary(data) {
+			content = string(data)
+		}
+		for i, line := range strings.Split(content, "\n") {
+			// Match without the CR of CRLF lines, as the search does
+			text := strings.TrimSuffix(line, "\r")
+			if p.pattern.MatchString(text) {
+				f.hits = append(f.hits, &replaceHit{
+					line:    i,
+					old:     line,
+					new:     p.replaceLine(text) + line[len(text):],
+					include: true,
+				})
+			}
+		}
+		if len(f.hits) > 0 {
+			p.files = append(p.files, f)
+		}
+	}
+
+	if len(p.files) == 0 {
+		m.projectReplace = nil
+		m.SetStatusMessage("No matches for " + p.query)
+		return
+	}
+	m.mode = ModeReplacePreview
+}
+
+// replaceLine replaces every match in line. In a regular expression
+// replacement, $1 or ${name} stand for the text of a submatch.
+func (p *projectReplace) replaceLine(line string) string {
+	if p.regex {
+		return p.pattern.ReplaceAllString(line, p.replacement)
+	}
+	return p.pattern.ReplaceAllLiteralString(line, p.replacement)
+}
+
+// tabBuffer returns the buffer of a tab; the active tab's is the model's.
+func (m *Model) tabBuffer(tab *Tab) *buffer.GapBuffer {
+	if tab == m.tabs.ActiveTab() {
+		return m.buffer
+	}
+	return tab.buffer
+}
+
+// rows returns the rows of the preview.
+func (p *projectReplace) rows() []replaceRow {
+	var rows []replaceRow
+	for _, f := range p.files {
+		rows = append(rows, replaceRow{file: f})
+		for _, hit := range f.hits {
+			rows = append(rows, replaceRow{file: f, hit: hit})
+		}
+	}
+	return rows
+}
+
+// counts returns the number of included and of all hits.
+func (p *projectReplace) counts() (included, total int) {
+	for _, f := range p.files {
+		for _, hit := range f.hits {
+			total++
+			if hit.include {
+				included++
+			}
+		}
+	}
+	return included, total
+}
+
+// toggle includes or excludes a hit, or all hits of a file.
+func (p *projectReplace) toggle(row replaceRow) {
+	if row.hit != nil {
+		row.hit.include = !row.hit.include
+		return
+	}
+	include := !allIncluded(row.file.hits)
+	for _, hit := range row.file.hits {
+		hit.include = include
+	}
+}
+
+// toggleAll includes every hit, or excludes them all if all are included.
+func (p *projectReplace) toggleAll() {
+	included, total := p.counts()
+	for _, f := range p.files {
+		for _, hit := range f.hits {
+			hit.include = included < total
+		}
+	}
+}
+
+func allIncluded(hits []*replaceHit) bool {
+	for _, hit := range hits {
+		if !hit.include {
+			return false
+		}
+	}
+	return true
+}
+
+// handleReplacePreviewInput handles input while the preview is shown.
+func (m *Model) handleReplacePreviewInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.projectReplace
+	if p == nil {
+		m.mode = ModeNormal
+		return m, nil
+	}
+	rows := p.rows()
+	pageSize := (m.pickerHeight() - 1) / 2
+
+	switch msg.String() {
+	case "esc", "ctrl+c", "ctrl+g":
+		m.mode = ModeNormal
+		m.projectReplace = nil
+		m.SetStatusMessage("Cancelled")
+		return m, nil
+
+	case "enter":
+		m.mode = ModeNormal
+		m.projectReplace = nil
+		m.applyProjectReplace(p)
+		return m, nil
+
+	case " ":
+		p.toggle(rows[p.selected])
+	case "a":
+		p.toggleAll()
+
+	case "up", "ctrl+p":
+		p.selected--
+	case "down", "ctrl+n":
+		p.selected++
+	case "pgup", "ctrl+y":
+		p.selected -= pageSize
+	case "pgdown", "ctrl+v":
+		p.selected += pageSize
+	}
+	p.selected = max(0, min(p.selected, len(rows)-1))
+	return m, nil
+}
+
+// applyProjectReplace applies the included changes. Open tabs are changed
+// as one undo step each and left unsaved; other files are rewritten on
+// disk atomically. Read-only tabs and files changed since the preview are
+// skipped.
+func (m *Model) applyProjectReplace(p *projectReplace) {
+	active := m.tabs.ActiveTab()
+	lines, files := 0, 0
+	stale, readonly, failed := 0, 0, 0
+	var firstErr error
+	for _, f := range p.files {
+		var hits []*replaceHit
+		for _, hit := range f.hits {
+			if hit.include {
+				hits = append(hits, hit)
+			}
+		}
+		if len(hits) == 0 {
+			continue
+		}
+
+		var err error
+		if f.tab != nil {
+			err = m.replaceInTab(f.tab, hits)
+		} else {
+			err = replaceInFile(f.path, hits)
+		}
+		switch {
+		case err == nil:
+			lines += len(hits)
+			files++
+		case errors.Is(err, errStalePreview):
+			stale++
+		case errors.Is(err, errReadOnlyTab):
+			readonly++
+		default:
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if i := m.tabIndex(active); i >= 0 {
+		m.SelectTab(i)
+	}
+
+	status := fmt.Sprintf("Replaced %d lines in %d files", lines, files)
+	var skipped []string
+	if stale > 0 {
+		skipped = append(skipped, fmt.Sprintf("%d changed since the preview", stale))
+	}
+	if readonly > 0 {
+		skipped = append(skipped, fmt.Sprintf("%d read-only", readonly))
+	}
+	if failed > 0 {
+		skipped = append(skipped, fmt.Sprintf("%d failed: %v", failed, firstErr))
+	}
+	if len(skipped) > 0 {
+		status += " (skipped " + strings.Join(skipped, ", ") + ")"
+	}
+	m.SetStatusMessage(status)
+}
+
+// replaceInTab replaces the lines of an open tab as one undo step.
+func (m *Model) replaceInTab(tab *Tab, hits []*replaceHit) error {
+	i := m.tabIndex(tab)
+	if i < 0 {
+		return os.ErrNotExist
+	}
+	m.SelectTab(i)
+	if m.readonly {
+		return errReadOnlyTab
+	}
+	for _, hit := range hits {
+		if hit.line >= m.buffer.LineCount() || m.buffer.Line(hit.line) != hit.old {
+			return errStalePreview
+		}
+	}
+
+	// Later lines first, so earlier offsets stay valid
+	cursor := m.buffer.CursorPos()
+	m.clearSelection()
+	m.history.BeginGroup()
+	for i := len(hits) - 1; i >= 0; i-- {
+		start, end := m.buffer.LineStart(hits[i].line), m.buffer.LineEnd(hits[i].line)
+		if end <= cursor {
+			cursor += len([]rune(hits[i].new)) - (end - start)
+		}
+		m.replaceRange(start, end, hits[i].new)
+	}
+	m.history.EndGroup()
+	m.buffer.MoveTo(min(cursor, m.buffer.Len()))
+	return nil
+}
+
+var (
+	// errStalePreview reports a file that changed after it was previewed.
+	errStalePreview = errors.New("file changed since the preview")
+	// errReadOnlyTab reports a file open in a read-only tab.
+	errReadOnlyTab = errors.New("file is read-only")
+)
+
+// replaceInFile replaces lines of a file on disk.
+func replaceInFile(path string, hits []*replaceHit) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+	for _, hit := range hits {
+		if hit.line >= len(lines) || lines[hit.line] != hit.old {
+			return errStalePreview
+		}
+		lines[hit.line] = hit.new
+	}
+	return file.WriteAtomic(path, []byte(strings.Join(lines, "\n")))
+}
+
+// renderReplacePreview renders the preview in place of the editor area.
+func (m *Model) renderReplacePreview() string {
+	p := m.projectReplace
+	height := m.pickerHeight()
+	var b strings.Builder
+
+	included, total := p.counts()
+	verb := "Replace"
+	if p.regex {
+		verb += " regexp"
+	}
+	title := fmt.Sprintf(" %s %q with %q: %d of %d lines in %d files", verb, p.query, p.replacement, included, total, len(p.files))
+	b.WriteString(headerStyle.Width(m.width).Render(title))
+	b.WriteString("\n")
+
+	// Hits take two lines; keep the selection visible
+	rows := p.rows()
+	space := height - 1
+	if p.selected < p.top {
+		p.top = p.selected
+	}
+	for p.top < p.selected && rowLines(rows[p.top:p.selected+1]) > space {
+		p.top++
+	}
+
+	written := 0
+	for i := p.top; i < len(rows) && written < space; i++ {
+		for _, line := range m.replaceRowLines(p, rows[i]) {
+			if written == space {
+				break
+			}
+			line.text = padOrTruncate(line.text, m.width)
+			switch {
+			case i == p.selected:
+				b.WriteString(selectionStyle.Render(line.text))
+			case line.style != nil:
+				b.WriteString(line.style.Render(line.text))
+			default:
+				b.WriteString(editorStyle.Render(line.text))
+			}
+			b.WriteString("\n")
+			written++
+		}
+	}
+	for ; written < space; written++ {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// styledLine is a line of text with an optional style.
+type styledLine struct {
+	text  string
+	style *lipgloss.Style
+}
+
+// rowLines returns the number of screen lines of rows.
+func rowLines(rows []replaceRow) int {
+	n := 0
+	for _, row := range rows {
+		n++
+		if row.hit != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// replaceRowLines returns the screen lines of a preview row.
+func (m *Model) replaceRowLines(p *projectReplace, row replaceRow) []styledLine {
+	if row.hit == nil {
+		n := 0
+		for _, hit := range row.file.hits {
+			if hit.include {
+				n++
+			}
+		}
+		mark := "[ ]"
+		switch {
+		case n == len(row.file.hits):
+			mark = "[x]"
+		case n > 0:
+			mark = "[-]"
+		}
+		path := row.file.path
+		if rel, err := filepath.Rel(p.root, path); err == nil {
+			path = rel
+		}
+		if row.file.tab != nil {
+			path += " (open)"
+		}
+		return []styledLine{{text: fmt.Sprintf(" %s %s  %d/%d", mark, path, n, len(row.file.hits))}}
+	}
+
+	mark := "[ ]"
+	if row.hit.include {
+		mark = "[x]"
+	}
+	old := strings.TrimSpace(row.hit.old)
+	new := strings.TrimSpace(row.hit.new)
+	return []styledLine{
+		{text: fmt.Sprintf("   %s %5d - %s", mark, row.hit.line+1, old), style: &diffRemovedStyle},
+		{text: fmt.Sprintf("   %s %5s + %s", "   ", "", new), style: &diffAddedStyle},
+	}
+}
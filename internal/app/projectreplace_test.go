@@ -0,0 +1,182 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestProjectReplace(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":        "module example\n",
+		"main.go":       "package main\n\nvar port = 1 // port\n",
+		"config.yaml":   "port: 80\nhost: x\nport_alt: 81\r\n",
+		"docs/notes.md": "the port is 80\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewFromFile(filepath.Join(dir, "main.go"), "main.go", files["main.go"])
+	m.SetSize(100, 30)
+
+	// The open tab is previewed with its unsaved text
+	m.GotoLine(3, 5)
+	typeText(m, "x")
+	m.Update(tea.KeyMsg{Type: tea.KeyF18})
+	if m.inputPrompt != "Replace in project [xport]: " {
+		t.Errorf("prompt = %q", m.inputPrompt)
+	}
+	typeText(m, "port")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeProjectReplaceWith {
+		t.Fatalf("mode = %v, want the replacement prompt", m.mode)
+	}
+	typeText(m, "listen")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	runProjectSearch(t, m, cmd)
+
+	if m.mode != ModeReplacePreview {
+		t.Fatalf("mode = %v, want the preview (%s)", m.mode, m.statusMessage)
+	}
+	p := m.projectReplace
+	if included, total := p.counts(); included != 4 || total != 4 || len(p.files) != 3 {
+		t.Fatalf("preview has %d/%d hits in %d files, want 4 in 3 files", included, total, len(p.files))
+	}
+	view := m.renderReplacePreview()
+	for _, want := range []string{"- var xport = 1 // port", "+ var xlisten = 1 // listen", "[x] config.yaml  2/2", "main.go (open)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("preview does not show %q:\n%s", want, view)
+		}
+	}
+
+	// Exclude the notes file and the second config line
+	rows := p.rows()
+	for i, row := range rows {
+		if row.hit == nil && strings.HasSuffix(row.file.path, "notes.md") {
+			p.selected = i
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeySpace})
+	p.selected = 2
+	m.Update(tea.KeyMsg{Type: tea.KeySpace})
+	if included, _ := p.counts(); included != 2 {
+		t.Fatalf("%d hits included, want 2", included)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.statusMessage != "Replaced 2 lines in 2 files" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+	if got := m.buffer.Line(2); got != "var xlisten = 1 // listen" {
+		t.Errorf("main.go line = %q", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(data) != files["main.go"] {
+		t.Error("the open tab was written to disk")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "config.yaml")); string(data) != "listen: 80\nhost: x\nport_alt: 81\r\n" {
+		t.Errorf("config.yaml = %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "docs", "notes.md")); string(data) != files["docs/notes.md"] {
+		t.Errorf("excluded notes.md = %q", data)
+	}
+
+	// Changes to the tab are one undo step
+	m.undo()
+	if got := m.buffer.Line(2); got != "var xport = 1 // port" {
+		t.Errorf("after undo = %q", got)
+	}
+}
+
+func TestProjectReplaceRegexp(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(config, []byte("port: 80\r\nhost: x\r\nport_alt: 81\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewFromFile(filepath.Join(dir, "main.go"), "main.go", "")
+	m.SetSize(100, 30)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyF18})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}, Alt: true})
+	if m.inputPrompt != "Replace in project (regexp): " {
+		t.Errorf("prompt = %q", m.inputPrompt)
+	}
+
+	// An invalid expression is reported at the prompt
+	typeText(m, "port(")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeProjectReplace || !strings.HasPrefix(m.statusMessage, "Invalid regular expression: ") {
+		t.Fatalf("mode %v, status %q", m.mode, m.statusMessage)
+	}
+
+	m.inputBuffer = `^port: (\d+)$`
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeText(m, "listen: $1")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	runProjectSearch(t, m, cmd)
+	if m.mode != ModeReplacePreview {
+		t.Fatalf("mode = %v, want the preview (%s)", m.mode, m.statusMessage)
+	}
+	if included, total := m.projectReplace.counts(); included != 1 || total != 1 {
+		t.Fatalf("preview has %d/%d hits, want 1", included, total)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if data, _ := os.ReadFile(config); string(data) != "listen: 80\r\nhost: x\r\nport_alt: 81\r\n" {
+		t.Errorf("config.yaml = %q", data)
+	}
+}
+
+func TestProjectReplaceStaleFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hits := []*replaceHit{{line: 0, old: "one", new: "two", include: true}}
+	if err := os.WriteFile(path, []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceInFile(path, hits); err != errStalePreview {
+		t.Errorf("replaceInFile() = %v, want errStalePreview", err)
+	}
+}
+
+func TestProjectReplaceSkipped(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "stale.txt")
+	if err := os.WriteFile(stale, []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.txt")
+
+	m := NewFromFile(filepath.Join(dir, "open.txt"), "open.txt", "one\n")
+	m.SetReadonly(true)
+	hit := func() []*replaceHit {
+		return []*replaceHit{{line: 0, old: "one", new: "two", include: true}}
+	}
+	m.applyProjectReplace(&projectReplace{files: []*replaceFile{
+		{path: filepath.Join(dir, "open.txt"), tab: m.tabs.ActiveTab(), hits: hit()},
+		{path: stale, hits: hit()},
+		{path: missing, hits: hit()},
+	}})
+
+	if m.Content() != "one\n" {
+		t.Errorf("read-only tab changed to %q", m.Content())
+	}
+	want := "Replaced 0 lines in 0 files (skipped 1 changed since the preview, 1 read-only, 1 failed: open " + missing + ": no such file or directory)"
+	if runtime.GOOS != "windows" && m.statusMessage != want {
+		t.Errorf("status = %q, want %q", m.statusMessage, want)
+	}
+}
@@ -0,0 +1,296 @@
+// Package app provides search across the files of a project.
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/project"
+)
+
+// maxProjectMatches stops a project search once this many lines matched.
+const maxProjectMatches = 10000
+
+// projectSearchBatch is the most matches delivered in one message.
+const projectSearchBatch = 500
+
+// projectSearch is a search across the files of a project. Matches are
+// sent by a background search and collected by the model.
+type projectSearch struct {
+	query  string
+	regex  bool // query is a regular expression
+	root   string
+	cancel context.CancelFunc
+	found  chan project.Match
+	err    error // set before found is closed
+
+	matches []project.Match
+	files   map[string]bool
+	done    bool
+	stopped string // why the search ended early, if it did
+	picker  *picker
+
+	// replace is previewed once the search is done, if set
+	replace *projectReplace
+}
+
+// projectSearchMsg delivers matches of a project search.
+type projectSearchMsg struct {
+	search  *projectSearch
+	matches []project.Match
+	done    bool
+}
+
+// openProjectSearchPrompt asks for the text to search the project for.
+func (m *Model) openProjectSearchPrompt() {
+	m.mode = ModeProjectSearch
+	m.inputBuffer = ""
+	m.inputPrompt = m.projectPrompt("Search project")
+}
+
+// projectPrompt returns the prompt for a project search, showing whether it
+// is a regular expression and the word searched for by default.
+func (m *Model) projectPrompt(label string) string {
+	if m.projectRegex {
+		label += " (regexp)"
+	}
+	if word := m.wordAtCursor(); word != "" {
+		return fmt.Sprintf("%s [%s]: ", label, word)
+	}
+	return label + ": "
+}
+
+// compileProjectQuery compiles a project search query, reporting an
+// invalid regular expression in the status bar.
+func (m *Model) compileProjectQuery(query string) (*regexp.Regexp, bool) {
+	pattern, err := project.Compile(query, m.projectRegex)
+	if err != nil {
+		m.SetStatusMessage("Invalid regular expression: " + err.Error())
+		return nil, false
+	}
+	return pattern, true
+}
+
+// handleProjectSearchInput handles input in project search mode. An empty
+// query searches for the word under the cursor; Alt+R switches between
+// literal text and regular expressions.
+func (m *Model) handleProjectSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		query := m.inputBuffer
+		if query == "" {
+			query = m.wordAtCursor()
+		}
+		if query == "" {
+			m.mode = ModeNormal
+			return m, nil
+		}
+		pattern, ok := m.compileProjectQuery(query)
+		if !ok {
+			return m, nil // keep the prompt open to fix the expression
+		}
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		return m, m.startProjectSearch(query, pattern)
+
+	case "alt+r":
+		m.projectRegex = !m.projectRegex
+		m.inputPrompt = m.projectPrompt("Search project")
+		return m, nil
+
+	case "esc", "ctrl+c":
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		m.SetStatusMessage("")
+		return m, nil
+
+	case "backspace":
+		if len(m.inputBuffer) > 0 {
+			runes := []rune(m.inputBuffer)
+			m.inputBuffer = string(runes[:len(runes)-1])
+		}
+		return m, nil
+
+	default:
+		if len(msg.Runes) > 0 {
+			m.inputBuffer += string(msg.Runes)
+		}
+		return m, nil
+	}
+}
+
+// startProjectSearch searches the project of the active file for pattern,
+// showing the matches as they are found. query is the text it was compiled
+// from.
+func (m *Model) startProjectSearch(query string, pattern *regexp.Regexp) tea.Cmd {
+	if m.projectSearch != nil {
+		m.projectSearch.stop("")
+	}
+	dir := "."
+	if m.filepath != "" {
+		dir = filepath.Dir(m.filepath)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &projectSearch{
+		query:  query,
+		regex:  m.projectRegex,
+		root:   findProjectRoot(dir),
+		cancel: cancel,
+		found:  make(chan project.Match, projectSearchBatch),
+		files:  make(map[string]bool),
+	}
+	go func() {
+		s.err = project.Search(ctx, s.root, pattern, s.found)
+		close(s.found)
+	}()
+
+	m.projectSearch = s
+	m.showProjectSearch()
+	return waitProjectSearch(s)
+}
+
+// waitProjectSearch waits for the next matches of a search.
+func waitProjectSearch(s *projectSearch) tea.Cmd {
+	return func() tea.Msg {
+		match, ok := <-s.found
+		if !ok {
+			return projectSearchMsg{search: s, done: true}
+		}
+		matches := []project.Match{match}
+		for len(matches) < projectSearchBatch {
+			select {
+			case match, ok := <-s.found:
+				if !ok {
+					return projectSearchMsg{search: s, matches: matches, done: true}
+				}
+				matches = append(matches, match)
+			default:
+				return projectSearchMsg{search: s, matches: matches}
+			}
+		}
+		return projectSearchMsg{search: s, matches: matches}
+	}
+}
+
+// finishProjectSearch collects delivered matches and waits for more.
+func (m *Model) finishProjectSearch(msg projectSearchMsg) tea.Cmd {
+	s := msg.search
+	if s != m.projectSearch || s.done {
+		return nil // cancelled or replaced by a newer search
+	}
+
+	matches := msg.matches[:min(len(msg.matches), maxProjectMatches-len(s.matches))]
+	s.matches = append(s.matches, matches...)
+	for _, match := range matches {
+		s.files[match.Path] = true
+	}
+	if m.picker != nil && m.picker == s.picker {
+		for _, match := range matches {
+			s.picker.add(m.projectSearchItem(s, match))
+		}
+	}
+
+	switch {
+	case len(s.matches) >= maxProjectMatches:
+		s.stop(fmt.Sprintf("stopped at %d matches", maxProjectMatches))
+	case msg.done:
+		s.stop("")
+		if s.err != nil {
+			s.stopped = s.err.Error()
+		}
+	default:
+		return waitProjectSearch(s)
+	}
+
+	if s.picker != nil {
+		s.picker.title = s.title()
+	}
+	m.SetStatusMessage(s.summary())
+	if s.replace != nil && m.picker == s.picker {
+		m.openReplacePreview(s)
+	}
+	return nil
+}
+
+// stop cancels a running search, recording why it stopped early.
+func (s *projectSearch) stop(reason string) {
+	if !s.done {
+		s.cancel()
+		s.done = true
+		s.stopped = reason
+	}
+}
+
+// title returns the title of the results list.
+func (s *projectSearch) title() string {
+	verb := "Search"
+	if s.replace != nil {
+		verb = "Replace"
+	}
+	if s.regex {
+		verb += " regexp"
+	}
+	title := fmt.Sprintf("%s %q in %s", verb, s.query, displayPath(s.root))
+	if !s.done {
+		title += " (searching...)"
+	}
+	return title
+}
+
+// summary describes the results of a finished search.
+func (s *projectSearch) summary() string {
+	var summary string
+	if len(s.matches) == 0 {
+		summary = "No matches for " + s.query
+	} else {
+		summary = fmt.Sprintf("%d matches in %d files", len(s.matches), len(s.files))
+	}
+	if s.stopped != "" {
+		summary += " (" + s.stopped + ")"
+	}
+	return summary
+}
+
+// showProjectSearch shows the results of the last project search. Leaving
+// the list with Esc cancels a search that is still running.
+func (m *Model) showProjectSearch() {
+	s := m.projectSearch
+	if s == nil {
+		m.SetStatusMessage("No project search yet")
+		return
+	}
+	items := make([]pickerItem, 0, len(s.matches))
+	for _, match := range s.matches {
+		items = append(items, m.projectSearchItem(s, match))
+	}
+	m.openPicker(s.title(), items)
+	s.picker = m.picker
+	s.picker.onCancel = func() {
+		if !s.done {
+			s.stop("cancelled")
+			m.SetStatusMessage(s.summary())
+		}
+	}
+}
+
+// projectSearchItem returns the results list entry of a match.
+func (m *Model) projectSearchItem(s *projectSearch, match project.Match) pickerItem {
+	path := match.Path
+	if rel, err := filepath.Rel(s.root, path); err == nil {
+		path = rel
+	}
+	return pickerItem{
+		label:  fmt.Sprintf("%s:%d", path, match.Line+1),
+		detail: strings.TrimSpace(match.Text),
+		onSelect: func() {
+			if m.jumpToFile(match.Path) {
+				m.jumpToLine(match.Line, match.Col)
+			}
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runProjectSearch delivers the messages of a project search until it is
+// done.
+func runProjectSearch(t *testing.T, m *Model, cmd tea.Cmd) {
+	t.Helper()
+	for cmd != nil {
+		_, cmd = m.Update(cmd())
+	}
+}
+
+func TestProjectSearch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":      "",
+		".gitignore":     "build/\n",
+		"main.go":        "package main\n\nfunc main() {\n\thelper()\n}\n",
+		"pkg/util.go":    "package pkg\n\n// héllo helper\nfunc helper() {}\n",
+		"build/out.go":   "helper\n",
+		"data.bin":       "helper\x00",
+		"docs/readme.md": "no match here\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewFromFile(filepath.Join(dir, "main.go"), "main.go", files["main.go"])
+	m.GotoLine(4, 3)
+
+	// An empty query searches for the word under the cursor
+	m.Update(tea.KeyMsg{Type: tea.KeyF6})
+	if m.mode != ModeProjectSearch || m.inputPrompt != "Search project [helper]: " {
+		t.Fatalf("mode = %v with prompt %q", m.mode, m.inputPrompt)
+	}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModePicker {
+		t.Fatalf("mode = %v, want the results list", m.mode)
+	}
+	runProjectSearch(t, m, cmd)
+
+	if len(m.picker.items) != 3 {
+		t.Fatalf("%d results, want 3: %+v", len(m.picker.items), m.picker.items)
+	}
+	if m.statusMessage != "3 matches in 2 files" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+
+	// Enter opens the hit in a tab at the match
+	m.picker.query = "util.go:3"
+	m.picker.filter()
+	m.handlePickerInput(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filename != "util.go" || m.tabs.Count() != 2 {
+		t.Fatalf("active file = %q with %d tabs, want util.go in a new tab", m.filename, m.tabs.Count())
+	}
+	if line, col := m.buffer.CurrentLine(), m.buffer.CurrentColumn(); line != 2 || col != 9 {
+		t.Errorf("cursor = %d:%d, want 2:9", line, col)
+	}
+
+	// The results can be shown again
+	m.Update(tea.KeyMsg{Type: tea.KeyF6, Alt: true})
+	if m.mode != ModePicker || len(m.picker.items) != 3 {
+		t.Errorf("mode = %v, want the last results", m.mode)
+	}
+}
+
+func TestProjectSearchCancel(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewFromFile(filepath.Join(dir, "a.txt"), "a.txt", "needle\n")
+
+	cmd := m.startProjectSearch("needle", regexp.MustCompile("needle"))
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != ModeNormal || !m.projectSearch.done {
+		t.Fatal("Esc did not cancel the search")
+	}
+	if m.statusMessage != "No matches for needle (cancelled)" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+	// Matches arriving after cancelling are dropped
+	runProjectSearch(t, m, cmd)
+	if len(m.projectSearch.matches) != 0 {
+		t.Errorf("%d matches collected after cancelling", len(m.projectSearch.matches))
+	}
+}
@@ -0,0 +1,154 @@
+// Package app provides persistent history for prompts.
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/config"
+	"github.com/KilimcininKorOglu/gesh/internal/file"
+)
+
+// maxHistoryEntries limits the entries kept per prompt history.
+const maxHistoryEntries = 100
+
+// HistoryFile represents the prompt history file format.
+type HistoryFile struct {
+	Version string              `json:"version"`
+	Prompts map[string][]string `json:"prompts"` // kind -> entries, oldest first
+}
+
+// historyNav is the state of browsing a history with Up and Down.
+type historyNav struct {
+	kind    string
+	entries []string
+	index   int    // entry in the input; len(entries) for the draft
+	draft   string // the input before browsing
+}
+
+// getHistoryFilePath returns the prompt history file path.
+func getHistoryFilePath() string {
+	return filepath.Join(config.GetStateDir(), "history.json")
+}
+
+// readHistoryFile loads the history file, returning an empty one if it is
+// missing or corrupt; the next entry added replaces a corrupt file.
+func readHistoryFile() (*HistoryFile, error) {
+	empty := func() *HistoryFile {
+		return &HistoryFile{
+			Version: "1.0",
+			Prompts: make(map[string][]string),
+		}
+	}
+	historyFile := empty()
+	data, err := os.ReadFile(getHistoryFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return historyFile, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, historyFile); err != nil {
+		return empty(), nil
+	}
+	if historyFile.Prompts == nil {
+		historyFile.Prompts = make(map[string][]string)
+	}
+	return historyFile, nil
+}
+
+// addHistory records an entry as the newest of a history, removing an
+// earlier copy of it and dropping the oldest entries over the limit.
+func addHistory(kind, entry string) error {
+	if strings.TrimSpace(entry) == "" {
+		return nil
+	}
+	historyFile, err := readHistoryFile()
+	if err != nil {
+		return err
+	}
+	entries := historyFile.Prompts[kind]
+	for i, e := range entries {
+		if e == entry {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	entries = append(entries, entry)
+	if len(entries) > maxHistoryEntries {
+		entries = entries[len(entries)-maxHistoryEntries:]
+	}
+	historyFile.Prompts[kind] = entries
+
+	if err := os.MkdirAll(config.GetStateDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(historyFile, "", "  ")
+	if err != nil {
+		return err
+	}
+	return file.WriteAtomic(getHistoryFilePath(), data)
+}
+
+// promptHistoryKind returns the history a prompt mode uses, or "". The
+// searched text of replace prompts shares the search history.
+func promptHistoryKind(mode Mode) string {
+	switch mode {
+	case ModeSearch, ModeReplace, ModeReplaceAll, ModeProjectSearch, ModeProjectReplace:
+		return "search"
+	case ModeReplaceConfirm, ModeReplaceAllConfirm, ModeProjectReplaceWith:
+		return "replace"
+	case ModeGoto:
+		return "goto"
+	case ModeOpen, ModeSaveAs:
+		return "open"
+	case ModeExecute:
+		return "execute"
+	}
+	return ""
+}
+
+// handlePromptHistory handles Up and Down in a prompt with a history and
+// reports whether it did. Enter records the input; other keys end browsing.
+func (m *Model) handlePromptHistory(msg tea.KeyMsg, kind string) bool {
+	switch msg.String() {
+	case "up":
+		nav := m.historyNav
+		if nav == nil || nav.kind != kind {
+			historyFile, err := readHistoryFile()
+			if err != nil {
+				m.SetStatusMessage("Error reading history: " + err.Error())
+				return true
+			}
+			entries := historyFile.Prompts[kind]
+			nav = &historyNav{kind: kind, entries: entries, index: len(entries), draft: m.inputBuffer}
+			m.historyNav = nav
+		}
+		if nav.index > 0 {
+			nav.index--
+			m.inputBuffer = nav.entries[nav.index]
+		}
+		return true
+
+	case "down":
+		if nav := m.historyNav; nav != nil && nav.kind == kind && nav.index < len(nav.entries) {
+			nav.index++
+			if nav.index == len(nav.entries) {
+				m.inputBuffer = nav.draft
+			} else {
+				m.inputBuffer = nav.entries[nav.index]
+			}
+		}
+		return true
+
+	case "enter":
+		// Failing to store the history is not worth interrupting for
+		_ = addHistory(kind, m.inputBuffer)
+	}
+	m.historyNav = nil
+	return false
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPromptHistory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m := NewFromFile("test.txt", "test.txt", "one two three two\n")
+	up := tea.KeyMsg{Type: tea.KeyUp}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+
+	for _, query := range []string{"one", "two", "three", "two"} {
+		m.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+		m.inputBuffer = "" // the prompt starts with the last query
+		typeText(m, query)
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	// A new editor sees the entries, newest first and without duplicates
+	m = NewFromFile("test.txt", "test.txt", "one two three two\n")
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	typeText(m, "dra")
+	for _, want := range []string{"two", "three", "one", "one"} {
+		m.Update(up)
+		if m.inputBuffer != want {
+			t.Errorf("Up: input = %q, want %q", m.inputBuffer, want)
+		}
+	}
+	for _, want := range []string{"three", "two", "dra", "dra"} {
+		m.Update(down)
+		if m.inputBuffer != want {
+			t.Errorf("Down: input = %q, want %q", m.inputBuffer, want)
+		}
+	}
+
+	// Other prompts keep their own histories
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlUnderscore})
+	m.Update(up)
+	if m.inputBuffer != "" {
+		t.Errorf("goto history = %q, want empty", m.inputBuffer)
+	}
+	typeText(m, "1")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlUnderscore})
+	m.Update(up)
+	if m.inputBuffer != "1" {
+		t.Errorf("goto history = %q, want 1", m.inputBuffer)
+	}
+}
+
+func TestPromptHistoryLimit(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	for i := 0; i < maxHistoryEntries+5; i++ {
+		if err := addHistory("execute", fmt.Sprintf("cmd %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	historyFile, err := readHistoryFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := historyFile.Prompts["execute"]
+	if len(entries) != maxHistoryEntries || entries[0] != "cmd 5" {
+		t.Errorf("kept %d entries from %q, want %d from cmd 5", len(entries), entries[0], maxHistoryEntries)
+	}
+}
+
+func TestPromptHistoryCorrupt(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(getHistoryFilePath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(getHistoryFilePath(), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Browsing a corrupt history finds nothing instead of failing
+	m := NewFromFile("test.txt", "test.txt", "one\n")
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	m.inputBuffer = ""
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if m.inputBuffer != "" || m.statusMessage != "" {
+		t.Errorf("Up: input = %q, status = %q, want both empty", m.inputBuffer, m.statusMessage)
+	}
+
+	// The next entry replaces the corrupt file
+	if err := addHistory("search", "one"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(getHistoryFilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var historyFile HistoryFile
+	if err := json.Unmarshal(data, &historyFile); err != nil {
+		t.Fatalf("history file is not valid JSON: %v", err)
+	}
+	if got := historyFile.Prompts["search"]; len(got) != 1 || got[0] != "one" {
+		t.Errorf("search history = %q, want [one]", got)
+	}
+}
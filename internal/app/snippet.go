@@ -0,0 +1,286 @@
+// Package app provides snippet expansion with tab-stop navigation.
+package app
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+	"github.com/KilimcininKorOglu/gesh/internal/config"
+	"github.com/KilimcininKorOglu/gesh/internal/snippet"
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+// snippetField is a placeholder of an expanded snippet in buffer offsets.
+type snippetField struct {
+	stop       int
+	start, end int
+}
+
+// snippetSession tracks tab-stop navigation in an expanded snippet.
+// All edits made during the session are undone as a single step.
+type snippetSession struct {
+	history *buffer.History // history of the buffer the snippet lives in
+	fields  []snippetField  // in text order
+	stops   []int           // tab stops in navigation order, 0 last
+	current int             // index into stops
+	fresh   bool            // placeholder text is selected and replaced by typing
+}
+
+// primary returns the index of the first field of the current tab stop.
+func (s *snippetSession) primary() int {
+	stop := s.stops[s.current]
+	for i, f := range s.fields {
+		if f.stop == stop {
+			return i
+		}
+	}
+	return 0
+}
+
+// languageSnippets returns the snippets for the current file type,
+// loading them on first use.
+func (m *Model) languageSnippets() map[string]snippet.Snippet {
+	name := ""
+	if lang := syntax.DetectLanguage(m.filename); lang != nil {
+		name = lang.Name
+	}
+	if snippets, ok := m.snippets[name]; ok {
+		return snippets
+	}
+
+	snippets, err := snippet.Load(config.GetSnippetsDir(), name)
+	if err != nil {
+		m.SetStatusMessage("Error loading snippets: " + err.Error())
+	}
+	if m.snippets == nil {
+		m.snippets = make(map[string]map[string]snippet.Snippet)
+	}
+	m.snippets[name] = snippets
+	return snippets
+}
+
+// snippetPrefix returns the start offset of the snippet whose prefix ends at
+// the cursor. The whole non-blank text before the cursor is tried first,
+// then the word before the cursor.
+func (m *Model) snippetPrefix(snippets map[string]snippet.Snippet) (int, snippet.Snippet, bool) {
+	pos := m.buffer.CursorPos()
+	for _, inToken := range []func(rune) bool{
+		func(r rune) bool { return !unicode.IsSpace(r) },
+		isWordRune,
+	} {
+		start := pos
+		for start > 0 && inToken(m.buffer.RuneAt(start-1)) {
+			start--
+		}
+		if start == pos {
+			continue
+		}
+		if s, ok := snippets[m.buffer.Slice(start, pos)]; ok {
+			return start, s, true
+		}
+	}
+	return 0, snippet.Snippet{}, false
+}
+
+// expandSnippet expands the snippet whose prefix is before the cursor.
+// Returns false if there is no matching snippet.
+func (m *Model) expandSnippet() bool {
+	snippets := m.languageSnippets()
+	if len(snippets) == 0 {
+		return false
+	}
+	start, s, ok := m.snippetPrefix(snippets)
+	if !ok {
+		return false
+	}
+
+	// Continuation lines keep the indentation of the current line
+	pos := m.buffer.CursorPos()
+	lineText := m.buffer.Slice(m.buffer.LineStart(m.buffer.CurrentLine()), pos)
+	indent := lineText[:len(lineText)-len(strings.TrimLeft(lineText, " \t"))]
+	body := strings.ReplaceAll(s.Body, "\t", m.indentUnit())
+	body = strings.ReplaceAll(body, "\n", "\n"+indent)
+
+	exp, err := snippet.Parse(body)
+	if err != nil {
+		m.SetStatusMessage("Snippet " + s.Prefix + ": " + err.Error())
+		return true
+	}
+
+	m.clearSelection()
+	m.history.BeginGroup()
+	m.replaceRange(start, pos, exp.Text)
+
+	session := &snippetSession{history: m.history, stops: exp.Stops()}
+	for _, f := range exp.Fields {
+		session.fields = append(session.fields, snippetField{
+			stop:  f.Stop,
+			start: start + f.Start,
+			end:   start + f.End,
+		})
+	}
+	m.activeSnippet = session
+	m.gotoSnippetStop(0)
+	return true
+}
+
+// gotoSnippetStop moves to tab stop index of the active snippet, selecting
+// its placeholder text. Reaching the final position ends the snippet.
+func (m *Model) gotoSnippetStop(index int) {
+	s := m.activeSnippet
+	s.current = index
+	f := s.fields[s.primary()]
+
+	m.clearSelection()
+	m.buffer.MoveTo(f.end)
+	s.fresh = f.start != f.end
+	if s.fresh {
+		m.selecting = true
+		m.selectionStart = f.start
+		m.selectionEnd = f.end
+	}
+
+	if s.stops[index] == 0 {
+		m.finishSnippet()
+		return
+	}
+	m.SetStatusMessage(fmt.Sprintf("Snippet field %d/%d  [Tab] Next  [S-Tab] Previous  [Esc] Done",
+		index+1, len(s.stops)-1))
+}
+
+// finishSnippet ends the active snippet session.
+func (m *Model) finishSnippet() {
+	if m.activeSnippet == nil {
+		return
+	}
+	m.activeSnippet.history.EndGroup()
+	m.activeSnippet = nil
+}
+
+// replaceSnippetField replaces the text of field i, shifting later fields.
+func (m *Model) replaceSnippetField(i int, text string) {
+	s := m.activeSnippet
+	f := &s.fields[i]
+	length := len([]rune(text))
+	delta := length - (f.end - f.start)
+	m.replaceRange(f.start, f.end, text)
+	f.end = f.start + length
+	m.shiftSnippetFields(i+1, delta)
+}
+
+// shiftSnippetFields moves the fields from index i onward by delta.
+func (m *Model) shiftSnippetFields(i, delta int) {
+	for ; i < len(m.activeSnippet.fields); i++ {
+		m.activeSnippet.fields[i].start += delta
+		m.activeSnippet.fields[i].end += delta
+	}
+}
+
+// mirrorSnippetField copies the text of field i to the other fields of the
+// same tab stop, keeping the cursor in place.
+func (m *Model) mirrorSnippetField(i int) {
+	s := m.activeSnippet
+	text := m.buffer.Slice(s.fields[i].start, s.fields[i].end)
+	offset := m.buffer.CursorPos() - s.fields[i].start
+
+	for j := range s.fields {
+		if j == i || s.fields[j].stop != s.fields[i].stop {
+			continue
+		}
+		if m.buffer.Slice(s.fields[j].start, s.fields[j].end) != text {
+			m.replaceSnippetField(j, text)
+		}
+	}
+	m.buffer.MoveTo(s.fields[i].start + offset)
+}
+
+// handleSnippetKey handles a key press while a snippet is active. Tab and
+// Shift+Tab move between fields; other keys edit normally and edits inside
+// the current field are mirrored. Leaving the field ends the snippet.
+func (m *Model) handleSnippetKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.activeSnippet
+	key := msg.String()
+
+	// Tab and Esc belong to the completion popup while it is shown
+	if m.completion == nil {
+		switch key {
+		case "tab":
+			m.gotoSnippetStop(s.current + 1)
+			return m, nil
+		case "shift+tab":
+			if s.current > 0 {
+				m.gotoSnippetStop(s.current - 1)
+			}
+			return m, nil
+		case "esc":
+			m.clearSelection()
+			m.finishSnippet()
+			m.SetStatusMessage("")
+			return m, nil
+		}
+	}
+
+	switch key {
+	case "alt+u", "alt+e":
+		// Undo and redo work on the whole snippet
+		m.finishSnippet()
+		return m.dispatchKey(msg)
+	}
+
+	i := s.primary()
+
+	// Typing over a selected placeholder replaces it
+	if s.fresh {
+		s.fresh = false
+		m.clearSelection()
+		switch {
+		case key == "backspace" || key == "delete" || key == "ctrl+h" || key == "ctrl+d":
+			m.replaceSnippetField(i, "")
+			m.mirrorSnippetField(i)
+			return m, nil
+		case msg.Type == tea.KeyRunes && !msg.Alt:
+			m.replaceSnippetField(i, "")
+			m.mirrorSnippetField(i)
+		}
+	}
+
+	f := s.fields[i]
+	before := m.buffer.Len()
+	pos := m.buffer.CursorPos()
+	text := m.buffer.Slice(f.start, f.end)
+	activeTab := m.tabs.ActiveIndex()
+
+	m.activeSnippet = nil
+	model, cmd := m.dispatchKey(msg)
+	m.activeSnippet = s
+
+	if m.mode != ModeNormal || m.tabs.ActiveIndex() != activeTab {
+		m.finishSnippet()
+		return model, cmd
+	}
+
+	delta := m.buffer.Len() - before
+	cur := m.buffer.CursorPos()
+	if delta != 0 {
+		if pos < f.start || pos > f.end || cur < f.start || cur > f.end+delta {
+			m.finishSnippet()
+			return model, cmd
+		}
+		s.fields[i].end += delta
+		m.shiftSnippetFields(i+1, delta)
+		m.mirrorSnippetField(i)
+		return model, cmd
+	}
+
+	if m.buffer.Slice(f.start, f.end) != text {
+		m.mirrorSnippetField(i)
+	}
+	if cur < s.fields[0].start || cur > s.fields[len(s.fields)-1].end {
+		m.finishSnippet()
+	}
+	return model, cmd
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// writeSnippets creates a snippets file in a temporary config directory.
+func writeSnippets(t *testing.T, name, content string) {
+	t.Helper()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	dir := filepath.Join(configHome, "gesh", "snippets")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnippetExpandAndNavigate(t *testing.T) {
+	writeSnippets(t, "go.yaml", "iferr:\n  body: |\n    if ${1:err} != nil {\n    \treturn ${2:nil}, $1\n    }\n")
+	m := NewFromFile("main.go", "main.go", "\tiferr")
+	m.SetInsertSpaces(false)
+	m.buffer.MoveToEnd()
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	want := "\tif err != nil {\n\t\treturn nil, err\n\t}"
+	if got := m.buffer.String(); got != want {
+		t.Fatalf("expanded = %q, want %q", got, want)
+	}
+	if start, end := m.getSelectionBounds(); m.buffer.Slice(start, end) != "err" || start != 4 {
+		t.Errorf("first field selection = %d..%d, want the first err", start, end)
+	}
+
+	// Typing replaces the placeholder and updates its mirror
+	typeText(m, "e")
+	want = "\tif e != nil {\n\t\treturn nil, e\n\t}"
+	if got := m.buffer.String(); got != want {
+		t.Errorf("after typing = %q, want %q", got, want)
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	typeText(m, "0")
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyShiftTab})
+	typeText(m, "x")
+	want = "\tif x != nil {\n\t\treturn 0, x\n\t}"
+	if got := m.buffer.String(); got != want {
+		t.Errorf("after navigating = %q, want %q", got, want)
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	if m.activeSnippet != nil {
+		t.Error("snippet still active after the last field")
+	}
+	if got := m.buffer.CursorPos(); got != m.buffer.Len() {
+		t.Errorf("final cursor = %d, want end of snippet %d", got, m.buffer.Len())
+	}
+
+	m.undo()
+	if got := m.buffer.String(); got != "\tiferr" {
+		t.Errorf("after undo = %q, want %q", got, "\tiferr")
+	}
+}
+
+func TestSnippetFinalPosition(t *testing.T) {
+	writeSnippets(t, "all.yaml", "todo:\n  body: \"// TODO($0): \"\n")
+	m := NewWithContent("todo")
+	m.buffer.MoveToEnd()
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.buffer.String(); got != "// TODO(): " {
+		t.Fatalf("expanded = %q", got)
+	}
+	if m.activeSnippet != nil {
+		t.Error("snippet without fields left active")
+	}
+	if got := m.buffer.CursorPos(); got != 8 {
+		t.Errorf("cursor = %d, want 8", got)
+	}
+}
+
+func TestSnippetNoMatchInsertsTab(t *testing.T) {
+	writeSnippets(t, "all.yaml", "todo:\n  body: TODO\n")
+	m := NewWithContent("nothing")
+	m.buffer.MoveToEnd()
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.buffer.String(); got != "nothing    " {
+		t.Errorf("buffer = %q, want an inserted indent", got)
+	}
+}
@@ -0,0 +1,194 @@
+// Package app provides spell checking of prose, comments and strings.
+package app
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/KilimcininKorOglu/gesh/internal/config"
+	"github.com/KilimcininKorOglu/gesh/internal/spell"
+	"github.com/KilimcininKorOglu/gesh/internal/syntax"
+)
+
+// maxSpellSuggestions limits the suggestions offered for a misspelled word.
+const maxSpellSuggestions = 8
+
+// misspelledStyle is layered over the syntax colors of misspelled words.
+var misspelledStyle = lipgloss.NewStyle().Underline(true)
+
+// loadSpeller returns the spell checker, loading the configured dictionary
+// and the personal dictionary on first use. Returns nil if no dictionary
+// could be loaded.
+func (m *Model) loadSpeller() *spell.Checker {
+	if m.speller != nil {
+		return m.speller
+	}
+
+	dirs := append([]string{config.GetDictionariesDir()}, spell.SystemDictionaryDirs()...)
+	path := spell.FindDictionary(m.spellDictionary, dirs)
+	if path == "" {
+		m.SetStatusMessage("No dictionary found for " + m.spellDictionary)
+		return nil
+	}
+	checker := spell.New()
+	if err := checker.LoadDictionary(path); err != nil {
+		m.SetStatusMessage("Error loading dictionary: " + err.Error())
+		return nil
+	}
+	if err := checker.LoadPersonal(config.GetPersonalDictionaryPath()); err != nil {
+		m.SetStatusMessage("Error loading personal dictionary: " + err.Error())
+	}
+	m.speller = checker
+	return checker
+}
+
+// toggleSpellCheck turns underlining of misspelled words on or off.
+func (m *Model) toggleSpellCheck() {
+	if m.spellCheck {
+		m.spellCheck = false
+		m.SetStatusMessage("Spell check disabled")
+		return
+	}
+	if m.loadSpeller() == nil {
+		return
+	}
+	m.spellCheck = true
+	m.SetStatusMessage("Spell check enabled")
+}
+
+// spellCandidates returns the words of a line that are spell checked: every
+// word in prose files and files without a language, otherwise only words
+// inside comments and strings.
+func (m *Model) spellCandidates(lineNum int, line string) []spell.Word {
+	lang := syntax.DetectLanguage(m.filename)
+	if lang == nil || lang.Prose {
+		return spell.Words(line)
+	}
+	if m.highlighter == nil {
+		m.highlighter = syntax.New(lang)
+	}
+
+	var words []spell.Word
+	col := 0
+	for _, token := range m.highlighter.HighlightLine(lineNum, line) {
+		n := len([]rune(token.Text))
+		if token.Type == syntax.TokenComment || token.Type == syntax.TokenString {
+			for _, w := range spell.Words(token.Text) {
+				w.Start += col
+				w.End += col
+				words = append(words, w)
+			}
+		}
+		col += n
+	}
+	return words
+}
+
+// misspelledWords returns the misspelled words of a line.
+func (m *Model) misspelledWords(checker *spell.Checker, lineNum int, line string) []spell.Word {
+	var result []spell.Word
+	for _, w := range m.spellCandidates(lineNum, line) {
+		if !checker.Check(w.Text) {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// misspelledSpans returns the underline spans of the misspelled words of a
+// line while spell checking is enabled.
+func (m *Model) misspelledSpans(lineNum int, line string) []underlineSpan {
+	if !m.spellCheck {
+		return nil
+	}
+	checker := m.loadSpeller()
+	if checker == nil {
+		m.spellCheck = false
+		return nil
+	}
+
+	var spans []underlineSpan
+	for _, w := range m.misspelledWords(checker, lineNum, line) {
+		spans = append(spans, underlineSpan{start: w.Start, end: w.End, style: misspelledStyle})
+	}
+	return spans
+}
+
+// nextMisspelling moves to the next misspelled word after the cursor,
+// wrapping around the end of the buffer, and offers suggestions for it.
+func (m *Model) nextMisspelling() {
+	checker := m.loadSpeller()
+	if checker == nil {
+		return
+	}
+
+	cursor := m.linePositionOf(m.buffer.CursorPos())
+	lineCount := m.buffer.LineCount()
+	for i := 0; i <= lineCount; i++ {
+		lineNum := (cursor.line + i) % lineCount
+		for _, w := range m.misspelledWords(checker, lineNum, m.buffer.Line(lineNum)) {
+			// Skip words before the cursor on the first pass over its line,
+			// and words after it when wrapping back around to that line
+			if i == 0 && w.End <= cursor.col || i == lineCount && w.End > cursor.col {
+				continue
+			}
+			lineStart := m.buffer.LineStart(lineNum)
+			m.showSpellSuggestions(w, lineStart+w.Start, lineStart+w.End)
+			return
+		}
+	}
+	m.SetStatusMessage("No misspelled words")
+}
+
+// showSpellSuggestions selects a misspelled word and opens a picker with
+// replacements and the options to add it to the dictionary or ignore it.
+func (m *Model) showSpellSuggestions(w spell.Word, start, end int) {
+	checker := m.speller
+	m.clearSelection()
+	m.buffer.MoveTo(end)
+	m.selecting = true
+	m.selectionStart = start
+	m.selectionEnd = end
+	m.ensureCursorVisible()
+
+	var items []pickerItem
+	for _, s := range checker.Suggest(w.Text, maxSpellSuggestions) {
+		items = append(items, pickerItem{
+			label: s,
+			onSelect: func() {
+				if m.readonly {
+					m.SetStatusMessage("File is read-only")
+					return
+				}
+				m.clearSelection()
+				m.replaceRange(start, end, s)
+				m.SetStatusMessage(fmt.Sprintf("Replaced %q with %q", w.Text, s))
+			},
+		})
+	}
+	items = append(items,
+		pickerItem{
+			label:  "Add to dictionary",
+			detail: config.GetPersonalDictionaryPath(),
+			onSelect: func() {
+				m.clearSelection()
+				if err := checker.AddPersonal(w.Text); err != nil {
+					m.SetStatusMessage("Error saving personal dictionary: " + err.Error())
+					return
+				}
+				m.SetStatusMessage(fmt.Sprintf("Added %q to dictionary", w.Text))
+			},
+		},
+		pickerItem{
+			label:  "Ignore",
+			detail: "this session",
+			onSelect: func() {
+				m.clearSelection()
+				checker.Ignore(w.Text)
+				m.SetStatusMessage(fmt.Sprintf("Ignoring %q", w.Text))
+			},
+		},
+	)
+	m.openPicker(fmt.Sprintf("Spelling: %s", w.Text), items)
+}
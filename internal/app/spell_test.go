@@ -0,0 +1,138 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setupSpell enables spell checking with a small word list and a temporary
+// config directory for the personal dictionary.
+func setupSpell(t *testing.T, m *Model) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dict := filepath.Join(t.TempDir(), "words")
+	words := "a\nis\nthe\nhello\nworld\nthis\ncomment\nstring\nword\n"
+	if err := os.WriteFile(dict, []byte(words), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.SetSpellCheck(true, dict)
+}
+
+// underlinedWords returns the text of the underlined spans of a line.
+func underlinedWords(m *Model, lineNum int) []string {
+	line := m.buffer.Line(lineNum)
+	var words []string
+	for _, span := range m.lineUnderlines(lineNum, line) {
+		words = append(words, string([]rune(line)[span.start:span.end]))
+	}
+	return words
+}
+
+func TestSpellUnderlines(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     []string
+	}{
+		{"plain text", "notes.txt", "Hello wrold, this is a tset", []string{"wrold", "tset"}},
+		{"markdown", "README.md", "# Hello wrold", []string{"wrold"}},
+		{"go comment", "main.go", "x := mispeled // a comnent", []string{"comnent"}},
+		{"go string", "main.go", `fmt.Println("hello wrold")`, []string{"wrold"}},
+		{"go code only", "main.go", "func mispeled() {}", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewFromFile(tt.filename, tt.filename, tt.content)
+			setupSpell(t, m)
+			if got := underlinedWords(m, 0); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("underlined = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpellToggle(t *testing.T) {
+	m := NewFromFile("notes.txt", "notes.txt", "wrold")
+	setupSpell(t, m)
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF7, Alt: true})
+	if m.spellCheck {
+		t.Fatal("spell check still enabled after Alt+F7")
+	}
+	if got := underlinedWords(m, 0); got != nil {
+		t.Errorf("underlined while disabled = %q", got)
+	}
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF7, Alt: true})
+	if got := underlinedWords(m, 0); len(got) != 1 {
+		t.Errorf("underlined after re-enabling = %q, want [wrold]", got)
+	}
+}
+
+func TestSpellNextAndReplace(t *testing.T) {
+	m := NewFromFile("notes.txt", "notes.txt", "hello wrold\nthe wrod")
+	setupSpell(t, m)
+	m.buffer.MoveTo(0)
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF7})
+	if m.mode != ModePicker {
+		t.Fatalf("mode = %v, want picker", m.mode)
+	}
+	if start, end := m.getSelectionBounds(); m.buffer.Slice(start, end) != "wrold" {
+		t.Errorf("selected %q, want wrold", m.buffer.Slice(start, end))
+	}
+	if got := m.picker.items[0].label; got != "world" {
+		t.Errorf("first suggestion = %q, want world", got)
+	}
+
+	m.handlePickerInput(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.buffer.String(); got != "hello world\nthe wrod" {
+		t.Errorf("after replace = %q", got)
+	}
+
+	// The next misspelling follows the replaced word
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF7})
+	if start, end := m.getSelectionBounds(); m.buffer.Slice(start, end) != "wrod" {
+		t.Errorf("selected %q, want wrod", m.buffer.Slice(start, end))
+	}
+	m.handlePickerInput(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// The replacement is a single undo step
+	m.clearSelection()
+	m.undo()
+	if got := m.buffer.String(); got != "hello wrold\nthe wrod" {
+		t.Errorf("after undo = %q", got)
+	}
+}
+
+func TestSpellAddToDictionary(t *testing.T) {
+	m := NewFromFile("notes.txt", "notes.txt", "gesh is a word")
+	setupSpell(t, m)
+	m.buffer.MoveTo(0)
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF7})
+	m.picker.query = "add"
+	m.picker.filter()
+	m.handlePickerInput(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if got := underlinedWords(m, 0); got != nil {
+		t.Errorf("underlined after adding = %q", got)
+	}
+	data, err := os.ReadFile(filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "gesh", "dictionary.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "gesh\n" {
+		t.Errorf("personal dictionary = %q, want %q", data, "gesh\n")
+	}
+
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyF7})
+	if m.mode == ModePicker {
+		t.Error("picker opened with no misspelled words")
+	}
+}
@@ -3,6 +3,7 @@ package app
 
 import (
 	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+	"github.com/KilimcininKorOglu/gesh/internal/lint"
 )
 
 // Tab represents a single buffer/file in the editor.
@@ -35,6 +36,22 @@ type Tab struct {
 	searchQuery   string
 	searchMatches []int
 	searchIndex   int
+
+	// Folded regions: start line -> last hidden line
+	folds map[int]int
+
+	// Bookmarked lines, sorted (loaded lazily from the state directory)
+	bookmarks        []int
+	bookmarksLoaded  bool
+	bookmarksChanged bool // toggled since they were last written
+
+	// Diagnostics of the last lint run and from the language server, each
+	// sorted by position
+	diagnostics    []lint.Diagnostic
+	lspDiagnostics []lint.Diagnostic
+
+	// Both merged and indexed by line for rendering; nil after a change
+	diagnosticIndex *diagnosticIndex
 }
 
 // TabManager manages multiple tabs/buffers.
@@ -60,6 +77,7 @@ func newEmptyTab() *Tab {
 		modified:   false, // Explicitly set
 		encoding:   "UTF-8",
 		lineEnding: "LF",
+		folds:      make(map[int]int),
 	}
 }
 
@@ -73,6 +91,7 @@ func NewTabFromFile(filepath, filename, content, encoding, lineEnding string) *T
 		modified:   false, // Explicitly set - file just loaded
 		encoding:   encoding,
 		lineEnding: lineEnding,
+		folds:      make(map[int]int),
 	}
 }
 
@@ -105,6 +124,13 @@ func (tm *TabManager) AddTab(tab *Tab) {
 	tm.activeIndex = len(tm.tabs) - 1
 }
 
+// ReplaceActiveTab puts tab in place of the active tab.
+func (tm *TabManager) ReplaceActiveTab(tab *Tab) {
+	if tm.activeIndex >= 0 && tm.activeIndex < len(tm.tabs) {
+		tm.tabs[tm.activeIndex] = tab
+	}
+}
+
 // AddEmptyTab adds a new empty tab and makes it active.
 func (tm *TabManager) AddEmptyTab() {
 	tm.AddTab(newEmptyTab())
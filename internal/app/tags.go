@@ -0,0 +1,155 @@
+// Package app provides go to definition through ctags and a jump list.
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/KilimcininKorOglu/gesh/internal/tags"
+)
+
+// maxJumps limits the positions kept for jumping back.
+const maxJumps = 100
+
+// jumpPosition is a position to return to. The path reopens the file when
+// its tab has been closed since.
+type jumpPosition struct {
+	tab  *Tab
+	path string
+	pos  int
+}
+
+// jumpFrom returns the current position.
+func (m *Model) jumpFrom() jumpPosition {
+	path, _ := m.tabFile(m.tabs.ActiveTab())
+	return jumpPosition{tab: m.tabs.ActiveTab(), path: path, pos: m.buffer.CursorPos()}
+}
+
+// pushJump records a position to return to with jumpBack.
+func (m *Model) pushJump(from jumpPosition) {
+	m.jumpStack = append(m.jumpStack, from)
+	if len(m.jumpStack) > maxJumps {
+		m.jumpStack = m.jumpStack[len(m.jumpStack)-maxJumps:]
+	}
+}
+
+// jumpBack returns to the position before the last definition jump.
+func (m *Model) jumpBack() {
+	for len(m.jumpStack) > 0 {
+		jump := m.jumpStack[len(m.jumpStack)-1]
+		m.jumpStack = m.jumpStack[:len(m.jumpStack)-1]
+
+		index := m.tabIndex(jump.tab)
+		switch {
+		case index >= 0:
+			m.SelectTab(index)
+		case jump.path == "":
+			continue // an unsaved buffer that was closed
+		case !m.selectFileTab(jump.path) && !m.openFileTab(jump.path):
+			return
+		}
+
+		m.clearSelection()
+		m.buffer.MoveTo(min(jump.pos, m.buffer.Len()))
+		m.revealLine(m.buffer.CurrentLine())
+		m.ensureCursorVisible()
+		return
+	}
+	m.SetStatusMessage("No previous position")
+}
+
+// tabIndex returns the index of an open tab, or -1.
+func (m *Model) tabIndex(tab *Tab) int {
+	for i, t := range m.tabs.Tabs() {
+		if t == tab {
+			return i
+		}
+	}
+	return -1
+}
+
+// gotoTag jumps to the definition of the word under the cursor as listed
+// in the nearest tags file, letting the user pick when there are several.
+func (m *Model) gotoTag() {
+	name := m.wordAtCursor()
+	if name == "" {
+		m.SetStatusMessage("No word under cursor")
+		return
+	}
+
+	dir := "."
+	if m.filepath != "" {
+		dir = filepath.Dir(m.filepath)
+	}
+	path, ok := tags.Find(dir)
+	if !ok {
+		m.SetStatusMessage("No tags file found")
+		return
+	}
+	found, err := tags.Lookup(path, name)
+	switch {
+	case err != nil:
+		m.SetStatusMessage("Error reading tags: " + err.Error())
+		return
+	case len(found) == 0:
+		m.SetStatusMessage("No tag found for " + name)
+		return
+	case len(found) == 1:
+		m.openTag(found[0])
+		return
+	}
+
+	items := make([]pickerItem, 0, len(found))
+	for _, tag := range found {
+		label := displayPath(tag.File)
+		if tag.Line > 0 {
+			label = fmt.Sprintf("%s:%d", label, tag.Line)
+		}
+		detail := strings.TrimSpace(tag.Pattern)
+		if tag.Kind != "" {
+			detail = strings.TrimSpace(tag.Kind + "  " + detail)
+		}
+		items = append(items, pickerItem{
+			label:    label,
+			detail:   detail,
+			onSelect: func() { m.openTag(tag) },
+		})
+	}
+	m.openPicker("Tags: "+name, items)
+}
+
+// openTag moves to a tag's definition, switching to the tab of its file or
+// opening the file in a new tab.
+func (m *Model) openTag(tag tags.Tag) {
+	if !m.jumpToFile(tag.File) {
+		return
+	}
+	line := tag.Locate(m.buffer.String()) - 1
+	text, col := m.buffer.Line(line), 0
+	if i := strings.Index(text, tag.Name); i >= 0 {
+		col = len([]rune(text[:i]))
+	}
+	m.jumpToLine(line, col)
+}
+
+// jumpToFile switches to the tab of a file, opening it in a new tab if
+// needed, and records the position left for jumpBack.
+func (m *Model) jumpToFile(path string) bool {
+	from := m.jumpFrom()
+	if !m.selectFileTab(path) && !m.openFileTab(path) {
+		return false
+	}
+	m.pushJump(from)
+	return true
+}
+
+// jumpToLine moves the cursor to a 0-based line and rune column, unfolding
+// the line and scrolling it into view.
+func (m *Model) jumpToLine(line, col int) {
+	line = max(0, min(line, m.buffer.LineCount()-1))
+	m.clearSelection()
+	m.revealLine(line)
+	m.GotoLine(line+1, col+1)
+	m.ensureCursorVisible()
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGotoTag(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":  "package main\n\nfunc main() {\n\thelper()\n\tother()\n}\n",
+		"util.go":  "package main\n\n// moved down\nfunc helper() {}\n",
+		"other.go": "package main\n\nfunc other() {}\n",
+		"more.go":  "package main\n\nfunc other() {}\n",
+		"tags": "helper\tutil.go\t/^func helper() {}$/;\"\tf\tline:3\n" +
+			"main\tmain.go\t/^func main() {$/;\"\tf\n" +
+			"other\tmore.go\t3;\"\tf\n" +
+			"other\tother.go\t3;\"\tf\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewFromFile(filepath.Join(dir, "main.go"), "main.go", files["main.go"])
+	m.GotoLine(4, 3)
+
+	// A single tag opens its file in a new tab, found by its pattern
+	m.Update(tea.KeyMsg{Type: tea.KeyF12})
+	if m.filename != "util.go" || m.tabs.Count() != 2 {
+		t.Fatalf("active file = %q with %d tabs, want util.go in a new tab", m.filename, m.tabs.Count())
+	}
+	if line, col := m.buffer.CurrentLine(), m.buffer.CurrentColumn(); line != 3 || col != 5 {
+		t.Errorf("cursor = %d:%d, want 3:5", line, col)
+	}
+
+	// Jumping back restores the tab and position
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{','}, Alt: true})
+	if m.filename != "main.go" {
+		t.Fatalf("active file after jumping back = %q", m.filename)
+	}
+	if line, col := m.buffer.CurrentLine(), m.buffer.CurrentColumn(); line != 3 || col != 2 {
+		t.Errorf("cursor after jumping back = %d:%d, want 3:2", line, col)
+	}
+
+	// Several tags are offered in a picker
+	m.GotoLine(5, 3)
+	m.Update(tea.KeyMsg{Type: tea.KeyF12})
+	if m.mode != ModePicker || len(m.picker.items) != 2 {
+		t.Fatalf("mode = %v, want a picker with two tags", m.mode)
+	}
+	m.picker.query = "other.go"
+	m.picker.filter()
+	m.handlePickerInput(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filename != "other.go" || m.buffer.CurrentLine() != 2 {
+		t.Errorf("active file = %q at line %d, want other.go at line 2", m.filename, m.buffer.CurrentLine())
+	}
+
+	// Positions in closed tabs reopen their file
+	m.Update(tea.KeyMsg{Type: tea.KeyF12})
+	m.handlePickerInput(tea.KeyMsg{Type: tea.KeyEnter})
+	m.SelectTab(0)
+	m.CloseTab()
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{','}, Alt: true})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{','}, Alt: true})
+	if m.filename != "main.go" || m.buffer.CurrentLine() != 4 {
+		t.Errorf("active file = %q at line %d, want main.go at line 4", m.filename, m.buffer.CurrentLine())
+	}
+	m.jumpStack = nil
+	m.jumpBack()
+	if m.statusMessage != "No previous position" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+
+	m.GotoLine(1, 1)
+	m.Update(tea.KeyMsg{Type: tea.KeyF12})
+	if m.statusMessage != "No tag found for package" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+}
+
+func TestGotoTagWithoutTagsFile(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFromFile(filepath.Join(dir, "main.go"), "main.go", "package main\n")
+	m.gotoTag()
+	if m.statusMessage != "No tags file found" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+}
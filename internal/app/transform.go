@@ -0,0 +1,370 @@
+// Package app provides text transformation commands.
+package app
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// textTransform is a named transformation of the selection or buffer.
+// Exactly one of lines and text is set.
+type textTransform struct {
+	name  string
+	lines func([]string) []string      // works on whole lines
+	text  func(string) (string, error) // works on the exact text
+}
+
+// textTransforms returns the transformations offered by the Transform picker.
+func textTransforms() []textTransform {
+	return []textTransform{
+		{name: "Sort lines", lines: func(l []string) []string { return sortLines(l, sortOptions{}) }},
+		{name: "Sort lines (reverse)", lines: func(l []string) []string { return sortLines(l, sortOptions{reverse: true}) }},
+		{name: "Sort lines (numeric)", lines: func(l []string) []string { return sortLines(l, sortOptions{numeric: true}) }},
+		{name: "Sort lines (case-insensitive)", lines: func(l []string) []string { return sortLines(l, sortOptions{ignoreCase: true}) }},
+		{name: "Unique lines", lines: uniqueLines},
+		{name: "Reverse lines", lines: reverseLines},
+		{name: "Trim whitespace", lines: func(l []string) []string { return mapLines(l, strings.TrimSpace) }},
+		{name: "Trim trailing whitespace", lines: func(l []string) []string {
+			return mapLines(l, func(s string) string { return strings.TrimRight(s, " \t") })
+		}},
+		{name: "UPPER CASE", text: plainTransform(strings.ToUpper)},
+		{name: "lower case", text: plainTransform(strings.ToLower)},
+		{name: "Title Case", text: plainTransform(toTitleCase)},
+		{name: "snake_case", text: plainTransform(eachLine(toSnakeCase))},
+		{name: "camelCase", text: plainTransform(eachLine(toCamelCase))},
+		{name: "Base64 encode", text: plainTransform(func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		})},
+		{name: "Base64 decode", text: func(s string) (string, error) {
+			data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+			return string(data), err
+		}},
+		{name: "URL encode", text: plainTransform(url.QueryEscape)},
+		{name: "URL decode", text: url.QueryUnescape},
+	}
+}
+
+// showTransforms opens a picker with the available text transformations.
+func (m *Model) showTransforms() {
+	var items []pickerItem
+	for _, t := range textTransforms() {
+		scope := "text"
+		if t.lines != nil {
+			scope = "lines"
+		}
+		items = append(items, pickerItem{
+			label:    t.name,
+			detail:   scope,
+			onSelect: func() { m.applyTransform(t) },
+		})
+	}
+	items = append(items, pickerItem{
+		label:  "Align on delimiter...",
+		detail: "lines",
+		onSelect: func() {
+			m.mode = ModeAlign
+			m.inputBuffer = ""
+			m.inputPrompt = "Align on: "
+		},
+	})
+
+	target := "buffer"
+	if m.hasTextSelection() {
+		target = "selection"
+	}
+	m.openPicker("Transform "+target, items)
+}
+
+// transformLineRange returns the lines a line transformation applies to:
+// the selected lines, or the whole buffer.
+func (m *Model) transformLineRange() (first, last int) {
+	if m.hasTextSelection() {
+		return m.selectedLines()
+	}
+	return 0, m.buffer.LineCount() - 1
+}
+
+// applyTransform applies t to the selection (or the whole buffer) as a
+// single undo step.
+func (m *Model) applyTransform(t textTransform) {
+	if t.lines != nil {
+		first, last := m.transformLineRange()
+		lines := m.linesInRange(first, last)
+		m.replaceTransformedLines(t.name, first, last, lines, t.lines(lines))
+		return
+	}
+
+	start, end := 0, m.buffer.Len()
+	if m.hasTextSelection() {
+		start, end = m.getSelectionBounds()
+	}
+	old := m.buffer.Slice(start, end)
+	text, err := t.text(old)
+	if err != nil {
+		m.SetStatusMessage(t.name + " failed: " + err.Error())
+		return
+	}
+	if text == old {
+		m.SetStatusMessage(t.name + ": no changes")
+		return
+	}
+
+	cursor := m.linePositionOf(m.buffer.CursorPos())
+	m.replaceRange(start, end, text)
+	if m.selecting {
+		m.selectionStart = start
+		m.selectionEnd = start + len([]rune(text))
+		m.buffer.MoveTo(m.selectionEnd)
+	} else {
+		m.buffer.MoveTo(m.positionOf(cursor))
+	}
+	m.SetStatusMessage(t.name)
+}
+
+// replaceTransformedLines replaces lines first through last with newLines,
+// keeping the cursor on the same line and column when nothing is selected.
+func (m *Model) replaceTransformedLines(name string, first, last int, lines, newLines []string) {
+	if strings.Join(lines, "\n") == strings.Join(newLines, "\n") {
+		m.SetStatusMessage(name + ": no changes")
+		return
+	}
+
+	cursor := m.linePositionOf(m.buffer.CursorPos())
+	m.replaceLines(first, last, newLines)
+	if !m.selecting {
+		m.buffer.MoveTo(m.positionOf(cursor))
+	}
+	m.SetStatusMessage(fmt.Sprintf("%s: %d lines", name, len(lines)))
+}
+
+// alignOnDelimiter aligns the first occurrence of delim in the selected
+// lines (or the whole buffer).
+func (m *Model) alignOnDelimiter(delim string) {
+	first, last := m.transformLineRange()
+	lines := m.linesInRange(first, last)
+	m.replaceTransformedLines("Align on "+delim, first, last, lines, alignLines(lines, delim))
+}
+
+// plainTransform adapts a string function that cannot fail.
+func plainTransform(fn func(string) string) func(string) (string, error) {
+	return func(s string) (string, error) {
+		return fn(s), nil
+	}
+}
+
+// eachLine applies fn to the text of every line, keeping the leading and
+// trailing whitespace of each line.
+func eachLine(fn func(string) string) func(string) string {
+	return func(s string) string {
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			core := strings.TrimSpace(line)
+			if core == "" {
+				continue
+			}
+			lead := line[:strings.Index(line, core)]
+			trail := line[len(lead)+len(core):]
+			lines[i] = lead + fn(core) + trail
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+// mapLines returns lines with fn applied to each.
+func mapLines(lines []string, fn func(string) string) []string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = fn(line)
+	}
+	return result
+}
+
+// sortOptions controls sortLines.
+type sortOptions struct {
+	numeric    bool // compare by leading number
+	reverse    bool // descending order
+	ignoreCase bool // compare case-insensitively
+}
+
+// sortLines returns lines sorted according to opts. The sort is stable.
+func sortLines(lines []string, opts sortOptions) []string {
+	sorted := append([]string(nil), lines...)
+	less := func(a, b string) bool {
+		if opts.numeric {
+			na, nb := leadingNumber(a), leadingNumber(b)
+			if na != nb {
+				return na < nb
+			}
+		}
+		if opts.ignoreCase {
+			la, lb := strings.ToLower(a), strings.ToLower(b)
+			if la != lb {
+				return la < lb
+			}
+		}
+		return a < b
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if opts.reverse {
+			return less(sorted[j], sorted[i])
+		}
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// leadingNumber parses the number at the start of s (after blanks).
+// Lines without a number count as 0, like sort -n.
+func leadingNumber(s string) float64 {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.' ||
+		end == 0 && (s[end] == '-' || s[end] == '+')) {
+		end++
+	}
+	for ; end > 0; end-- {
+		if n, err := strconv.ParseFloat(s[:end], 64); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// uniqueLines removes repeated lines, keeping the first occurrence.
+func uniqueLines(lines []string) []string {
+	seen := make(map[string]bool, len(lines))
+	var result []string
+	for _, line := range lines {
+		if !seen[line] {
+			seen[line] = true
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// reverseLines returns lines in reverse order.
+func reverseLines(lines []string) []string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[len(lines)-1-i] = line
+	}
+	return result
+}
+
+// toTitleCase capitalizes the first letter of every word and lowercases
+// the rest.
+func toTitleCase(s string) string {
+	runes := []rune(s)
+	start := true
+	for i, r := range runes {
+		if unicode.IsSpace(r) {
+			start = true
+			continue
+		}
+		if start {
+			runes[i] = unicode.ToUpper(r)
+		} else {
+			runes[i] = unicode.ToLower(r)
+		}
+		start = false
+	}
+	return string(runes)
+}
+
+// splitWords splits an identifier or phrase into words at spaces,
+// punctuation and case changes ("parseHTTPRequest" -> parse, HTTP, Request).
+func splitWords(s string) []string {
+	runes := []rune(s)
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// toSnakeCase converts s to snake_case.
+func toSnakeCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "_"))
+}
+
+// toCamelCase converts s to camelCase.
+func toCamelCase(s string) string {
+	var b strings.Builder
+	for i, word := range splitWords(s) {
+		word = strings.ToLower(word)
+		if i > 0 {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			word = string(runes)
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// alignLines pads the text before the first delim of every line so the
+// delimiters line up. Lines without delim are left alone. If any line has
+// blanks before the delimiter, one space separates text and delimiter.
+func alignLines(lines []string, delim string) []string {
+	if delim == "" {
+		return lines
+	}
+
+	width := 0
+	spaced := false
+	for _, line := range lines {
+		i := strings.Index(line, delim)
+		if i < 0 {
+			continue
+		}
+		before := strings.TrimRight(line[:i], " \t")
+		if len(before) < i {
+			spaced = true
+		}
+		if w := len([]rune(before)); w > width {
+			width = w
+		}
+	}
+
+	result := make([]string, len(lines))
+	for n, line := range lines {
+		i := strings.Index(line, delim)
+		if i < 0 {
+			result[n] = line
+			continue
+		}
+		before := strings.TrimRight(line[:i], " \t")
+		pad := strings.Repeat(" ", width-len([]rune(before)))
+		if spaced {
+			pad += " "
+		}
+		result[n] = before + pad + line[i:]
+	}
+	return result
+}
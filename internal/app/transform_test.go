@@ -0,0 +1,122 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSortLines(t *testing.T) {
+	lines := []string{"b", "10 x", "A", "2 y", "a"}
+	tests := []struct {
+		name string
+		opts sortOptions
+		want []string
+	}{
+		{"plain", sortOptions{}, []string{"10 x", "2 y", "A", "a", "b"}},
+		{"reverse", sortOptions{reverse: true}, []string{"b", "a", "A", "2 y", "10 x"}},
+		{"numeric", sortOptions{numeric: true}, []string{"A", "a", "b", "2 y", "10 x"}},
+		{"case-insensitive", sortOptions{ignoreCase: true}, []string{"10 x", "2 y", "A", "a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sortLines(lines, tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaseConversions(t *testing.T) {
+	tests := []struct {
+		in, snake, camel, title string
+	}{
+		{"parseHTTPRequest", "parse_http_request", "parseHttpRequest", "Parsehttprequest"},
+		{"max retry count", "max_retry_count", "maxRetryCount", "Max Retry Count"},
+		{"user-id2Value", "user_id2_value", "userId2Value", "User-id2value"},
+	}
+
+	for _, tt := range tests {
+		if got := toSnakeCase(tt.in); got != tt.snake {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", tt.in, got, tt.snake)
+		}
+		if got := toCamelCase(tt.in); got != tt.camel {
+			t.Errorf("toCamelCase(%q) = %q, want %q", tt.in, got, tt.camel)
+		}
+		if got := toTitleCase(tt.in); got != tt.title {
+			t.Errorf("toTitleCase(%q) = %q, want %q", tt.in, got, tt.title)
+		}
+	}
+}
+
+func TestAlignLines(t *testing.T) {
+	lines := []string{"a = 1", "long_name = 2", "// no delimiter", "mid= 3"}
+	want := []string{"a         = 1", "long_name = 2", "// no delimiter", "mid       = 3"}
+	if got := alignLines(lines, "="); !reflect.DeepEqual(got, want) {
+		t.Errorf("alignLines() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyTransformOnSelection(t *testing.T) {
+	m := NewWithContent("keep\ncherry\napple\napple\nbanana\nkeep")
+	m.moveToLineColumn(1, 0)
+	m.startSelection()
+	m.moveToLineColumn(5, 0)
+	m.updateSelection()
+
+	m.applyTransform(textTransform{name: "Sort lines", lines: func(l []string) []string {
+		return uniqueLines(sortLines(l, sortOptions{}))
+	}})
+	if got := m.buffer.String(); got != "keep\napple\nbanana\ncherry\nkeep" {
+		t.Errorf("after sort = %q", got)
+	}
+
+	m.undo()
+	if got := m.buffer.String(); got != "keep\ncherry\napple\napple\nbanana\nkeep" {
+		t.Errorf("after undo = %q", got)
+	}
+}
+
+func TestApplyTextTransform(t *testing.T) {
+	m := NewWithContent("const max_value = 1")
+	m.buffer.MoveTo(6)
+	m.startSelection()
+	m.buffer.MoveTo(15)
+	m.updateSelection()
+
+	for _, tr := range textTransforms() {
+		if tr.name == "UPPER CASE" {
+			m.applyTransform(tr)
+		}
+	}
+	if got := m.buffer.String(); got != "const MAX_VALUE = 1" {
+		t.Errorf("after upper case = %q", got)
+	}
+
+	for _, tr := range textTransforms() {
+		if tr.name == "Base64 decode" {
+			m.applyTransform(tr)
+		}
+	}
+	if got := m.buffer.String(); got != "const MAX_VALUE = 1" {
+		t.Errorf("failed decode changed the buffer: %q", got)
+	}
+}
+
+func TestAlignPrompt(t *testing.T) {
+	m := NewWithContent("x: 1\nlonger: 2")
+	m.showTransforms()
+	typeText(m, "align")
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeAlign {
+		t.Fatalf("mode = %v, want ModeAlign", m.mode)
+	}
+	typeText(m, ":")
+	m.handleKeyMsg(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if got := m.buffer.String(); got != "x     : 1\nlonger: 2" {
+		t.Errorf("aligned = %q", got)
+	}
+}
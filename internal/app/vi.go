@@ -0,0 +1,1015 @@
+// Package app provides the optional vi-style modal keymap.
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/KilimcininKorOglu/gesh/internal/buffer"
+)
+
+// viMode is the mode of the vi keymap.
+type viMode int
+
+const (
+	viNormal viMode = iota
+	viInsert
+	viVisual
+	viVisualLine
+)
+
+// viState is the state of the vi keymap: its mode and the command being
+// typed.
+type viState struct {
+	mode    viMode
+	count   int    // count typed before the command, 0 for none
+	op      string // pending operator: "d", "c" or "y"
+	opCount int    // count typed before the operator
+	arg     string // command waiting for a character: g, f, t, F, T or r
+	anchor  int    // where visual mode started
+	find    string // last f, t, F or T motion with its character, for ; and ,
+
+	// Keys of the change being typed, and of the last change, for "."
+	keys       []tea.KeyMsg
+	recording  bool
+	lastChange []tea.KeyMsg
+	replaying  bool
+
+	// History of the change being typed in insert mode, grouped with the
+	// edit that started it for undo
+	group *buffer.History
+}
+
+// viAliases map the keys vi also accepts to its own.
+var viAliases = map[string]string{
+	"left":      "h",
+	"right":     "l",
+	"up":        "k",
+	"down":      "j",
+	"home":      "0",
+	"end":       "$",
+	"backspace": "h",
+	" ":         "l",
+	"enter":     "+",
+	"delete":    "x",
+}
+
+// viShortcuts are commands that stand for an operator and a motion.
+var viShortcuts = map[string]string{
+	"x": "dl",
+	"X": "dh",
+	"D": "d$",
+	"C": "c$",
+	"s": "cl",
+	"S": "cc",
+	"Y": "yy",
+}
+
+// SetViMode turns the vi keymap on or off. It starts in normal mode.
+func (m *Model) SetViMode(enabled bool) {
+	if !enabled {
+		if m.vi != nil {
+			m.viEndChange()
+		}
+		m.vi = nil
+		return
+	}
+	m.vi = &viState{}
+}
+
+// status returns the mode and the pending command, for the status bar.
+func (v *viState) status() string {
+	label := "NORMAL"
+	switch v.mode {
+	case viInsert:
+		label = "INSERT"
+	case viVisual:
+		label = "VISUAL"
+	case viVisualLine:
+		label = "V-LINE"
+	}
+	pending := ""
+	if v.opCount > 0 {
+		pending += strconv.Itoa(v.opCount)
+	}
+	pending += v.op
+	if v.count > 0 {
+		pending += strconv.Itoa(v.count)
+	}
+	pending += v.arg
+	if pending != "" {
+		label += " " + pending
+	}
+	return label
+}
+
+// reset drops the command being typed.
+func (v *viState) reset() {
+	v.count = 0
+	v.op = ""
+	v.opCount = 0
+	v.arg = ""
+	if !v.recording {
+		v.keys = nil
+	}
+}
+
+// handleViKey handles a key with the vi keymap and reports whether it did.
+// Keys vi does not use, such as Ctrl and Alt combinations, and every key
+// of insert mode except Esc keep their usual meaning.
+func (m *Model) handleViKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	v := m.vi
+	if v.mode == viInsert {
+		if v.recording && !v.replaying {
+			v.keys = append(v.keys, msg)
+		}
+		if msg.String() != "esc" {
+			return false, nil
+		}
+		// Esc also ends a snippet, a completion or a running command
+		if m.activeSnippet != nil || m.completion != nil || m.command != nil {
+			m.dispatchKey(msg)
+		}
+		m.viEndInsert()
+		return true, nil
+	}
+	if msg.Paste {
+		return false, nil
+	}
+	if !v.replaying {
+		v.keys = append(v.keys, msg)
+	}
+
+	// The character of f, t, r and the second g
+	if v.arg != "" {
+		arg := v.arg
+		v.arg = ""
+		r, ok := viRune(msg)
+		switch {
+		case !ok:
+			v.reset()
+			return true, nil
+		case arg == "r":
+			m.viReplaceChar(r)
+			return true, nil
+		}
+		return true, m.viKey(arg + string(r))
+	}
+
+	key := msg.String()
+	if alias, ok := viAliases[key]; ok {
+		key = alias
+	}
+	if key == "esc" {
+		if v.mode != viNormal {
+			m.viEndVisual()
+		}
+		v.reset()
+		return true, nil
+	}
+	if key != "ctrl+r" && len([]rune(key)) != 1 {
+		v.reset()
+		if msg.Type == tea.KeyRunes {
+			// Text typed in normal mode is not inserted
+			return true, nil
+		}
+		return m.viPassThrough(msg)
+	}
+
+	// Counts
+	if r := []rune(key)[0]; r >= '1' && r <= '9' || r == '0' && v.count > 0 {
+		if v.count < 100000 {
+			v.count = v.count*10 + int(r-'0')
+		}
+		return true, nil
+	}
+	return true, m.viKey(key)
+}
+
+// viRune returns the character a key types, for f, t and r.
+func viRune(msg tea.KeyMsg) (rune, bool) {
+	switch {
+	case msg.Type == tea.KeySpace:
+		return ' ', true
+	case msg.Type == tea.KeyRunes && len(msg.Runes) == 1 && !msg.Alt:
+		return msg.Runes[0], true
+	case msg.Type == tea.KeyTab:
+		return '\t', true
+	}
+	return 0, false
+}
+
+// viPassThrough handles a key vi does not use. In visual mode the
+// selection follows the cursor afterwards.
+func (m *Model) viPassThrough(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if m.vi.mode == viNormal {
+		return false, nil
+	}
+	msg, cmd, handled := m.applyKeymap(msg)
+	if !handled {
+		_, cmd = m.dispatchKey(msg)
+	}
+	if m.mode == ModeNormal && m.selecting {
+		m.viUpdateVisual()
+	} else {
+		m.vi.mode = viNormal
+	}
+	return true, cmd
+}
+
+// viKey runs a vi command: a motion, an operator or a command of its own.
+func (m *Model) viKey(key string) tea.Cmd {
+	v := m.vi
+	if shortcut, ok := viShortcuts[key]; ok && v.op == "" && v.mode == viNormal {
+		v.op = shortcut[:1]
+		v.opCount = v.count
+		v.count = 0
+		key = shortcut[1:]
+	}
+	if key == "g" || key == "f" || key == "t" || key == "F" || key == "T" || key == "r" && v.op == "" {
+		v.arg = key
+		return nil
+	}
+
+	n := max(v.count, 1) * max(v.opCount, 1)
+	hasCount := v.count > 0 || v.opCount > 0
+
+	// Operators: in visual mode on the selection, doubled on whole lines
+	if key == "d" || key == "c" || key == "y" {
+		switch {
+		case v.mode != viNormal:
+			linewise := v.mode == viVisualLine
+			v.mode = viNormal
+			m.clearSelection()
+			if key == "c" {
+				m.viBeginChange()
+			}
+			if m.viOperate(key, v.anchor, m.buffer.CursorPos(), linewise, true) && key == "c" {
+				// Visual changes depend on the selection and are not
+				// repeated by "."
+				m.viStartInsert(false)
+				return nil
+			}
+			m.viEndChange()
+			m.viClamp()
+			v.reset()
+		case v.op == key:
+			line := m.buffer.CurrentLine()
+			last := min(line+n-1, m.buffer.LineCount()-1)
+			m.viApply(key, m.buffer.CursorPos(), m.buffer.LineStart(last), true, false)
+		case v.op == "":
+			v.op = key
+			v.opCount = v.count
+			v.count = 0
+		default:
+			v.reset()
+		}
+		return nil
+	}
+
+	if cur := m.buffer.CursorPos(); (key == "w" || key == "W") && v.op == "c" && !isSpace(m.buffer.RuneAt(cur)) {
+		// cw changes to the end of the word, counting the word under the
+		// cursor even on its last character
+		big := key == "W"
+		pos := cur
+		class := viClass(m.buffer.RuneAt(pos), big)
+		for pos+1 < m.buffer.Len() && viClass(m.buffer.RuneAt(pos+1), big) == class {
+			pos++
+		}
+		for range n - 1 {
+			pos = m.viWordEnd(pos, big)
+		}
+		m.viApply("c", cur, pos, false, true)
+		return nil
+	}
+	if pos, linewise, inclusive, ok := m.viMotion(key, n, hasCount); ok {
+		switch {
+		case v.op != "":
+			m.viApply(v.op, m.buffer.CursorPos(), pos, linewise, inclusive)
+		case v.mode != viNormal:
+			m.buffer.MoveTo(pos)
+			m.viUpdateVisual()
+			m.viDone(false)
+		default:
+			m.buffer.MoveTo(pos)
+			m.viClamp()
+			m.viDone(false)
+		}
+		m.ensureCursorVisible()
+		return nil
+	}
+	if v.op != "" {
+		v.reset()
+		return nil
+	}
+	if v.mode != viNormal {
+		return m.viVisualKey(key, n)
+	}
+	return m.viNormalKey(key, n)
+}
+
+// viApply applies the operator of a command in normal mode and finishes
+// the command.
+func (m *Model) viApply(op string, from, to int, linewise, inclusive bool) {
+	if op == "c" {
+		m.viBeginChange()
+	}
+	if !m.viOperate(op, from, to, linewise, inclusive) {
+		m.viEndChange()
+		m.vi.reset()
+		return
+	}
+	if op == "c" {
+		m.viStartInsert(true)
+		return
+	}
+	m.viDone(op == "d")
+}
+
+// viDone finishes a command; a change is remembered for ".".
+func (m *Model) viDone(change bool) {
+	v := m.vi
+	if change && !v.replaying && v.mode != viInsert {
+		v.lastChange = v.keys
+	}
+	v.reset()
+}
+
+// viNormalKey runs a command of normal mode other than a motion or
+// operator.
+func (m *Model) viNormalKey(key string, n int) tea.Cmd {
+	v := m.vi
+	cur := m.buffer.CursorPos()
+	line := m.buffer.CurrentLine()
+	if strings.Contains("iaIAoOpPJ", key) && m.readonly {
+		m.SetStatusMessage("File is read-only")
+		v.reset()
+		return nil
+	}
+
+	switch key {
+	case "i":
+		m.viStartInsert(true)
+	case "a":
+		if cur < m.buffer.LineEnd(line) {
+			m.buffer.MoveTo(cur + 1)
+		}
+		m.viStartInsert(true)
+	case "I":
+		m.buffer.MoveTo(m.viFirstNonBlank(line))
+		m.viStartInsert(true)
+	case "A":
+		m.buffer.MoveTo(m.buffer.LineEnd(line))
+		m.viStartInsert(true)
+	case "o", "O":
+		indent := getIndent(m.buffer.Line(line))
+		m.viBeginChange()
+		if key == "o" {
+			end := m.buffer.LineEnd(line)
+			m.replaceRange(end, end, "\n"+indent)
+		} else {
+			start := m.buffer.LineStart(line)
+			m.replaceRange(start, start, indent+"\n")
+			m.buffer.MoveTo(start + len([]rune(indent)))
+		}
+		m.viStartInsert(true)
+	case "p", "P":
+		m.viPut(key == "p", n)
+		m.viDone(true)
+	case "J":
+		for range max(n-1, 1) {
+			m.joinLines()
+		}
+		m.viClamp()
+		m.viDone(true)
+	case "u":
+		for range n {
+			m.undo()
+		}
+		m.viClamp()
+		m.viDone(false)
+	case "ctrl+r":
+		for range n {
+			m.redo()
+		}
+		m.viClamp()
+		m.viDone(false)
+	case ".":
+		return m.viRepeat()
+	case "v", "V":
+		v.mode = viVisual
+		if key == "V" {
+			v.mode = viVisualLine
+		}
+		v.anchor = cur
+		m.viUpdateVisual()
+		m.viDone(false)
+	case "/":
+		m.mode = ModeSearch
+		m.inputBuffer = ""
+		m.inputPrompt = "/"
+		m.viDone(false)
+	case "n", "N":
+		for range n {
+			if key == "n" {
+				m.nextMatch()
+			} else {
+				m.prevMatch()
+			}
+		}
+		m.viDone(false)
+	case ":":
+		m.mode = ModeViCommand
+		m.inputBuffer = ""
+		m.inputPrompt = ":"
+		m.viDone(false)
+	default:
+		m.viDone(false)
+	}
+	m.ensureCursorVisible()
+	return nil
+}
+
+// viVisualKey runs a command of visual mode other than a motion or
+// operator.
+func (m *Model) viVisualKey(key string, n int) tea.Cmd {
+	v := m.vi
+	switch key {
+	case "v", "V":
+		mode := viVisual
+		if key == "V" {
+			mode = viVisualLine
+		}
+		if v.mode == mode {
+			m.viEndVisual()
+		} else {
+			v.mode = mode
+			m.viUpdateVisual()
+		}
+	case "o":
+		cur := m.buffer.CursorPos()
+		m.buffer.MoveTo(v.anchor)
+		v.anchor = cur
+		m.viUpdateVisual()
+	case "x":
+		return m.viKey("d")
+	case "s":
+		return m.viKey("c")
+	case "J":
+		if m.readonly {
+			m.SetStatusMessage("File is read-only")
+			break
+		}
+		m.joinLines()
+		v.mode = viNormal
+		m.viClamp()
+	case ":":
+		m.viEndVisual()
+		return m.viNormalKey(key, n)
+	}
+	v.reset()
+	return nil
+}
+
+// viStartInsert enters insert mode. Changes entered from normal mode are
+// recorded up to Esc for ".".
+func (m *Model) viStartInsert(repeatable bool) {
+	v := m.vi
+	v.mode = viInsert
+	v.recording = repeatable && !v.replaying
+	if !v.recording {
+		v.keys = nil
+	}
+	v.count = 0
+	v.op = ""
+	v.opCount = 0
+}
+
+// viEndInsert leaves insert mode, moving the cursor back onto the last
+// character typed as vi does.
+func (m *Model) viEndInsert() {
+	v := m.vi
+	v.mode = viNormal
+	m.viEndChange()
+	if v.recording {
+		v.lastChange = v.keys
+		v.recording = false
+	}
+	v.keys = nil
+	pos := m.buffer.CursorPos()
+	if pos > m.buffer.LineStart(m.buffer.CurrentLine()) {
+		m.buffer.MoveTo(pos - 1)
+	}
+}
+
+// viBeginChange starts an undo group for a change that continues in insert
+// mode, so that undo restores the replaced text and removes the typed text
+// in one step. The group ends with insert mode.
+func (m *Model) viBeginChange() {
+	m.viEndChange()
+	m.vi.group = m.history
+	m.history.BeginGroup()
+}
+
+// viEndChange ends the undo group of a change, in the history it started
+// in even if another tab is active now.
+func (m *Model) viEndChange() {
+	if v := m.vi; v.group != nil {
+		v.group.EndGroup()
+		v.group = nil
+	}
+}
+
+// viEndVisual leaves visual mode.
+func (m *Model) viEndVisual() {
+	m.vi.mode = viNormal
+	m.clearSelection()
+	m.viClamp()
+}
+
+// viUpdateVisual selects from the visual mode anchor to the cursor,
+// including the character under the cursor, or whole lines in V-LINE
+// mode.
+func (m *Model) viUpdateVisual() {
+	lo, hi := m.vi.anchor, m.buffer.CursorPos()
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	if m.vi.mode == viVisualLine {
+		lo = m.buffer.LineStart(m.lineAt(lo))
+		hi = m.buffer.LineEnd(m.lineAt(hi))
+	}
+	m.selecting = true
+	m.selectionStart = lo
+	m.selectionEnd = min(hi+1, m.buffer.Len())
+}
+
+// viClamp keeps the cursor on a character in normal mode: it cannot
+// stand after the last character of a line.
+func (m *Model) viClamp() {
+	pos := m.buffer.CursorPos()
+	line := m.buffer.CurrentLine()
+	if pos == m.buffer.LineEnd(line) && pos > m.buffer.LineStart(line) {
+		m.buffer.MoveTo(pos - 1)
+	}
+}
+
+// viRepeat repeats the last change. A count replaces the count of the
+// change.
+func (m *Model) viRepeat() tea.Cmd {
+	v := m.vi
+	keys := v.lastChange
+	count := v.count
+	v.reset()
+	if len(keys) == 0 || v.replaying {
+		return nil
+	}
+	if count > 0 {
+		i := 0
+		for i < len(keys) && keys[i].Type == tea.KeyRunes && len(keys[i].Runes) == 1 &&
+			unicode.IsDigit(keys[i].Runes[0]) && (i > 0 || keys[i].Runes[0] != '0') {
+			i++
+		}
+		var counted []tea.KeyMsg
+		for _, r := range strconv.Itoa(count) {
+			counted = append(counted, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		keys = append(counted, keys[i:]...)
+	}
+
+	v.replaying = true
+	defer func() { v.replaying = false }()
+	var cmds []tea.Cmd
+	for _, msg := range keys {
+		if handled, cmd := m.handleViKey(msg); handled {
+			cmds = append(cmds, cmd)
+			continue
+		}
+		msg, cmd, handled := m.applyKeymap(msg)
+		if !handled {
+			_, cmd = m.dispatchKey(msg)
+		}
+		cmds = append(cmds, cmd)
+	}
+	v.lastChange = keys
+	return tea.Batch(cmds...)
+}
+
+// viMotion returns where a motion repeated n times moves the cursor, and
+// whether it covers whole lines or includes its last character when an
+// operator applies to it.
+func (m *Model) viMotion(key string, n int, hasCount bool) (pos int, linewise, inclusive, ok bool) {
+	cur := m.buffer.CursorPos()
+	line := m.buffer.CurrentLine()
+	start := m.buffer.LineStart(line)
+	end := m.buffer.LineEnd(line)
+	lastLine := m.buffer.LineCount() - 1
+
+	switch key {
+	case "h":
+		return max(start, cur-n), false, false, cur > start
+	case "l":
+		return min(cur+n, end), false, false, cur < end
+	case "0":
+		return start, false, false, true
+	case "^":
+		return m.viFirstNonBlank(line), false, false, true
+	case "$":
+		target := min(line+n-1, lastLine)
+		return m.buffer.LineEnd(target), false, false, true
+	case "j", "k", "+", "-":
+		target := line + n
+		if key == "k" || key == "-" {
+			target = line - n
+		}
+		target = max(0, min(target, lastLine))
+		if target == line {
+			return cur, true, false, false
+		}
+		if key == "+" || key == "-" {
+			return m.viFirstNonBlank(target), true, false, true
+		}
+		// Keep the column, skipping folded lines like the arrow keys
+		for range n {
+			if key == "j" {
+				m.moveCursorDown()
+			} else {
+				m.moveCursorUp()
+			}
+		}
+		pos = m.buffer.CursorPos()
+		m.buffer.MoveTo(cur)
+		return pos, true, false, true
+	case "gg", "G":
+		target := lastLine
+		if hasCount || key == "gg" {
+			target = max(0, min(n-1, lastLine))
+		}
+		return m.viFirstNonBlank(target), true, false, true
+	case "w", "W", "b", "B", "e", "E":
+		big := key == "W" || key == "B" || key == "E"
+		pos = cur
+		for range n {
+			switch key {
+			case "w", "W":
+				pos = m.viWordForward(pos, big)
+			case "b", "B":
+				pos = m.viWordBackward(pos, big)
+			default:
+				pos = m.viWordEnd(pos, big)
+			}
+		}
+		inclusive = key == "e" || key == "E"
+		return pos, false, inclusive, pos != cur
+	case "%":
+		match, found := m.findBracketPartner()
+		return match.partnerStart, false, true, found
+	case ";", ",":
+		if m.vi.find == "" {
+			return cur, false, false, false
+		}
+		find := m.vi.find
+		if key == "," {
+			find = string(map[byte]byte{'f': 'F', 'F': 'f', 't': 'T', 'T': 't'}[find[0]]) + find[1:]
+		}
+		pos, inclusive, ok = m.viFind(find, n, true)
+		return pos, false, inclusive, ok
+	}
+	if len(key) > 1 && strings.Contains("fFtT", key[:1]) {
+		m.vi.find = key
+		pos, inclusive, ok = m.viFind(key, n, false)
+		return pos, false, inclusive, ok
+	}
+	return cur, false, false, false
+}
+
+// viFind finds the n-th character of an f, F, t or T motion in the
+// current line. Repeated t and T motions skip the character next to the
+// cursor so they do not stay in place.
+func (m *Model) viFind(find string, n int, repeat bool) (pos int, inclusive, ok bool) {
+	cur := m.buffer.CursorPos()
+	line := m.buffer.CurrentLine()
+	start := m.buffer.LineStart(line)
+	end := m.buffer.LineEnd(line)
+	target := []rune(find[1:])[0]
+	forward := find[0] == 'f' || find[0] == 't'
+	till := find[0] == 't' || find[0] == 'T'
+
+	pos = cur
+	if till && repeat {
+		if forward {
+			pos++
+		} else {
+			pos--
+		}
+	}
+	for found := 0; found < n; {
+		if forward {
+			pos++
+		} else {
+			pos--
+		}
+		if pos < start || pos >= end {
+			return cur, false, false
+		}
+		if m.buffer.RuneAt(pos) == target {
+			found++
+		}
+	}
+	if till {
+		if forward {
+			pos--
+		} else {
+			pos++
+		}
+	}
+	return pos, forward, true
+}
+
+// viClass returns the class of a character for word motions: 0 for
+// whitespace, 1 for word characters and 2 for punctuation. Big words
+// (W, B, E) are made of any non-blank characters.
+func viClass(r rune, big bool) int {
+	switch {
+	case isSpace(r):
+		return 0
+	case big || isWordRune(r):
+		return 1
+	}
+	return 2
+}
+
+// viWordForward returns the start of the next word. An empty line counts
+// as a word.
+func (m *Model) viWordForward(pos int, big bool) int {
+	length := m.buffer.Len()
+	if pos >= length {
+		return pos
+	}
+	if class := viClass(m.buffer.RuneAt(pos), big); class != 0 {
+		for pos < length && viClass(m.buffer.RuneAt(pos), big) == class {
+			pos++
+		}
+	}
+	for pos < length && isSpace(m.buffer.RuneAt(pos)) {
+		if m.buffer.RuneAt(pos) == '\n' && pos+1 < length && m.buffer.RuneAt(pos+1) == '\n' {
+			return pos + 1
+		}
+		pos++
+	}
+	return pos
+}
+
+// viWordBackward returns the start of the word before pos.
+func (m *Model) viWordBackward(pos int, big bool) int {
+	for pos > 0 && isSpace(m.buffer.RuneAt(pos-1)) {
+		pos--
+		if pos > 0 && m.buffer.RuneAt(pos) == '\n' && m.buffer.RuneAt(pos-1) == '\n' {
+			return pos
+		}
+	}
+	if pos == 0 {
+		return 0
+	}
+	class := viClass(m.buffer.RuneAt(pos-1), big)
+	for pos > 0 && viClass(m.buffer.RuneAt(pos-1), big) == class {
+		pos--
+	}
+	return pos
+}
+
+// viWordEnd returns the last character of the word after pos.
+func (m *Model) viWordEnd(pos int, big bool) int {
+	length := m.buffer.Len()
+	pos++
+	for pos < length && isSpace(m.buffer.RuneAt(pos)) {
+		pos++
+	}
+	if pos >= length {
+		return max(length-1, 0)
+	}
+	class := viClass(m.buffer.RuneAt(pos), big)
+	for pos+1 < length && viClass(m.buffer.RuneAt(pos+1), big) == class {
+		pos++
+	}
+	return pos
+}
+
+// viFirstNonBlank returns the position of the first non-blank character
+// of a line.
+func (m *Model) viFirstNonBlank(line int) int {
+	start := m.buffer.LineStart(line)
+	return start + len([]rune(getIndent(m.buffer.Line(line))))
+}
+
+// viOperate applies an operator between the cursor and where a motion
+// took it: d deletes, c deletes what insert mode then replaces, y copies.
+// The text goes to the clipboard; whole lines end with a newline. It
+// reports false if the file is read-only.
+func (m *Model) viOperate(op string, from, to int, linewise, inclusive bool) bool {
+	if op != "y" && m.readonly {
+		m.SetStatusMessage("File is read-only")
+		return false
+	}
+	lo, hi := min(from, to), max(from, to)
+
+	if linewise {
+		first, last := m.lineAt(lo), m.lineAt(hi)
+		start, end, text := m.viLines(first, last)
+		m.clipboard = text
+		switch op {
+		case "y":
+			m.buffer.MoveTo(min(from, to))
+			if n := last - first + 1; n > 2 {
+				m.SetStatusMessage(fmt.Sprintf("%d lines yanked", n))
+			}
+		case "d":
+			m.replaceRange(start, end, "")
+			m.buffer.MoveTo(m.viFirstNonBlank(min(first, m.buffer.LineCount()-1)))
+		case "c":
+			// Keep the lines' indentation
+			indent := getIndent(m.buffer.Line(first))
+			m.replaceRange(m.buffer.LineStart(first), m.buffer.LineEnd(last), indent)
+		}
+		return true
+	}
+
+	if inclusive {
+		hi = min(hi+1, m.buffer.Len())
+	} else if hi > lo && m.lineAt(hi) > m.lineAt(lo) && hi == m.buffer.LineStart(m.lineAt(hi)) {
+		// An exclusive motion to the start of a line stops at the end of
+		// the line before
+		hi--
+	}
+	text := m.buffer.Slice(lo, hi)
+	if text != "" {
+		m.clipboard = text
+	}
+	switch op {
+	case "y":
+		m.buffer.MoveTo(lo)
+	case "d":
+		m.replaceRange(lo, hi, "")
+		m.buffer.MoveTo(lo)
+		m.viClamp()
+	case "c":
+		m.replaceRange(lo, hi, "")
+		m.buffer.MoveTo(lo)
+	}
+	return true
+}
+
+// viLines returns the text of whole lines with its final newline, and the
+// range that deletes the lines.
+func (m *Model) viLines(first, last int) (start, end int, text string) {
+	start = m.buffer.LineStart(first)
+	end = m.buffer.LineEnd(last)
+	text = m.buffer.Slice(start, end) + "\n"
+	if last < m.buffer.LineCount()-1 {
+		end++
+	} else if first > 0 {
+		start--
+	}
+	return start, end, text
+}
+
+// viPut pastes the clipboard n times after the cursor (p) or before it
+// (P). Text ending with a newline, as cut and copied lines do, goes on
+// lines of its own below or above the current line.
+func (m *Model) viPut(after bool, n int) {
+	if m.clipboard == "" {
+		m.SetStatusMessage("Clipboard is empty")
+		return
+	}
+	text := strings.Repeat(m.clipboard, n)
+	line := m.buffer.CurrentLine()
+
+	if strings.HasSuffix(text, "\n") {
+		pos := m.buffer.LineStart(line)
+		target := line
+		if after {
+			target++
+			if line < m.buffer.LineCount()-1 {
+				pos = m.buffer.LineStart(line + 1)
+			} else {
+				pos = m.buffer.Len()
+				text = "\n" + strings.TrimSuffix(text, "\n")
+			}
+		}
+		m.replaceRange(pos, pos, text)
+		m.buffer.MoveTo(m.viFirstNonBlank(target))
+		return
+	}
+
+	pos := m.buffer.CursorPos()
+	if after && pos < m.buffer.LineEnd(line) {
+		pos++
+	}
+	m.replaceRange(pos, pos, text)
+	m.buffer.MoveTo(pos + len([]rune(text)) - 1)
+}
+
+// viReplaceChar replaces the character under the cursor, and the ones
+// after it for a count, with r.
+func (m *Model) viReplaceChar(r rune) {
+	v := m.vi
+	n := max(v.count, 1)
+	if m.readonly {
+		m.SetStatusMessage("File is read-only")
+		v.reset()
+		return
+	}
+	pos := m.buffer.CursorPos()
+	if pos+n > m.buffer.LineEnd(m.buffer.CurrentLine()) {
+		v.reset()
+		return
+	}
+	m.replaceRange(pos, pos+n, strings.Repeat(string(r), n))
+	m.buffer.MoveTo(pos + n - 1)
+	m.viDone(true)
+}
+
+// handleViCommandInput handles input in the vi command line.
+func (m *Model) handleViCommandInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		command := strings.TrimSpace(m.inputBuffer)
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		return m.runViCommand(command)
+
+	case "esc":
+		m.mode = ModeNormal
+		m.inputBuffer = ""
+		m.SetStatusMessage("")
+		return m, nil
+
+	case "backspace":
+		if m.inputBuffer == "" {
+			m.mode = ModeNormal
+			return m, nil
+		}
+		runes := []rune(m.inputBuffer)
+		m.inputBuffer = string(runes[:len(runes)-1])
+		return m, nil
+
+	default:
+		if r, ok := viRune(msg); ok {
+			m.inputBuffer += string(r)
+		}
+		return m, nil
+	}
+}
+
+// runViCommand runs a command of the vi command line: :w, :q, :q!, :wq,
+// :x or a line number.
+func (m *Model) runViCommand(command string) (tea.Model, tea.Cmd) {
+	name, arg, _ := strings.Cut(command, " ")
+	arg = strings.TrimSpace(arg)
+
+	if line, err := strconv.Atoi(command); err == nil {
+		line = max(1, min(line, m.buffer.LineCount()))
+		m.buffer.MoveTo(m.viFirstNonBlank(line - 1))
+		m.ensureCursorVisible()
+		return m, nil
+	}
+
+	switch name {
+	case "":
+		return m, nil
+
+	case "w", "wq", "x":
+		if arg != "" {
+			m.SetFilepath(expandHome(arg))
+		}
+		if m.filepath == "" {
+			m.SetStatusMessage("No file name")
+			return m, nil
+		}
+		var cmd tea.Cmd
+		if name != "x" || m.modified {
+			_, cmd = m.saveFile()
+		}
+		if name == "w" || m.modified {
+			// Saving failed (e.g. the formatter); keep the editor open
+			return m, cmd
+		}
+		m.quitting = true
+		return m, tea.Quit
+
+	case "q", "q!":
+		if name == "q" && m.modified {
+			m.SetStatusMessage("No write since last change (add ! to override)")
+			return m, nil
+		}
+		m.quitting = true
+		return m, tea.Quit
+	}
+
+	m.SetStatusMessage("Not an editor command: " + command)
+	return m, nil
+}
@@ -0,0 +1,254 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newViModel returns a model with the vi keymap on the given content.
+func newViModel(content string) *Model {
+	m := NewFromFile("test.txt", "test.txt", content)
+	m.SetSize(80, 20)
+	m.SetViMode(true)
+	return m
+}
+
+// viKeys types keys in the vi keymap; "<esc>" and "<enter>" stand for
+// those keys.
+func viKeys(m *Model, keys ...string) {
+	for _, k := range keys {
+		switch k {
+		case "<esc>":
+			m.Update(tea.KeyMsg{Type: tea.KeyEscape})
+		case "<enter>":
+			m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		case "<c-r>":
+			m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+		default:
+			typeText(m, k)
+		}
+	}
+}
+
+func TestViMotions(t *testing.T) {
+	tests := []struct {
+		keys string
+		want int // cursor position
+	}{
+		{"w", 4},
+		{"2w", 8},
+		{"e", 2},
+		{"wb", 0},
+		{"$", 14},
+		{"$0", 0},
+		{"j", 16},
+		{"jk", 0},
+		{"G", 30}, // the empty line after the final newline
+		{"G2gg", 16},
+		{"fe", 2},
+		{"2fe", 11},
+		{"te", 1},
+		{"fe;", 11},
+		{"fe;,", 2},
+		{"$Fe", 12},
+		{"2fo", 0}, // not found
+		{"w.", 4},  // nothing to repeat
+		{"lll", 3},
+		{"3l3h", 0},
+		{"www", 13}, // w stops on punctuation
+		{"3W", 16},
+	}
+	for _, tt := range tests {
+		m := newViModel("one two three()\nfour five\nsix\n")
+		viKeys(m, tt.keys)
+		if got := m.buffer.CursorPos(); got != tt.want {
+			t.Errorf("%q: cursor at %d, want %d", tt.keys, got, tt.want)
+		}
+		if m.Content() != "one two three()\nfour five\nsix\n" {
+			t.Errorf("%q changed the text: %q", tt.keys, m.Content())
+		}
+	}
+}
+
+func TestViOperators(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"dw"}, "two three\nfour five\n"},
+		{[]string{"d2w"}, "three\nfour five\n"},
+		{[]string{"2dw"}, "three\nfour five\n"},
+		{[]string{"wwdw"}, "one two \nfour five\n"},
+		{[]string{"de"}, " two three\nfour five\n"},
+		{[]string{"d$"}, "\nfour five\n"},
+		{[]string{"wD"}, "one \nfour five\n"},
+		{[]string{"dd"}, "four five\n"},
+		{[]string{"2dd"}, ""},
+		{[]string{"dj"}, ""},
+		{[]string{"jdk"}, ""},
+		{[]string{"dG"}, ""},
+		{[]string{"x"}, "ne two three\nfour five\n"},
+		{[]string{"3x"}, " two three\nfour five\n"},
+		{[]string{"dtt"}, "two three\nfour five\n"},
+		{[]string{"dft"}, "wo three\nfour five\n"},
+		{[]string{"cwON", "<esc>"}, "ON two three\nfour five\n"},
+		{[]string{"ccnew", "<esc>"}, "new\nfour five\n"},
+		{[]string{"C!", "<esc>"}, "!\nfour five\n"},
+		{[]string{"yyp"}, "one two three\none two three\nfour five\n"},
+		{[]string{"yyjP"}, "one two three\none two three\nfour five\n"},
+		{[]string{"ddp"}, "four five\none two three\n"},
+		{[]string{"ywP"}, "one one two three\nfour five\n"},
+		{[]string{"xp"}, "noe two three\nfour five\n"},
+		{[]string{"rX"}, "Xne two three\nfour five\n"},
+		{[]string{"J"}, "one two three four five\n"},
+		{[]string{"dwu"}, "one two three\nfour five\n"},
+		{[]string{"dwdwuu", "<c-r>"}, "two three\nfour five\n"},
+		{[]string{"ix", "<esc>"}, "xone two three\nfour five\n"},
+		{[]string{"ax", "<esc>"}, "oxne two three\nfour five\n"},
+		{[]string{"A!", "<esc>"}, "one two three!\nfour five\n"},
+		{[]string{"wI>", "<esc>"}, ">one two three\nfour five\n"},
+		{[]string{"onew", "<esc>"}, "one two three\nnew\nfour five\n"},
+		{[]string{"Onew", "<esc>"}, "new\none two three\nfour five\n"},
+		{[]string{"cwON", "<esc>", "u"}, "one two three\nfour five\n"},
+		{[]string{"cwON", "<esc>", "u", "<c-r>"}, "ON two three\nfour five\n"},
+		{[]string{"cwON", "<esc>", "xu"}, "ON two three\nfour five\n"},
+		{[]string{"ccnew", "<esc>", "u"}, "one two three\nfour five\n"},
+		{[]string{"snew", "<esc>", "u"}, "one two three\nfour five\n"},
+		{[]string{"onew", "<esc>", "u"}, "one two three\nfour five\n"},
+		{[]string{"Onew", "<esc>", "u"}, "one two three\nfour five\n"},
+		{[]string{"vecX", "<esc>", "u"}, "one two three\nfour five\n"},
+	}
+	for _, tt := range tests {
+		m := newViModel("one two three\nfour five\n")
+		for _, k := range tt.keys {
+			viKeys(m, k)
+		}
+		if got := m.Content(); got != tt.want {
+			t.Errorf("%q: content %q, want %q", tt.keys, got, tt.want)
+		}
+		if m.vi.mode != viNormal {
+			t.Errorf("%q: left in mode %v", tt.keys, m.vi.mode)
+		}
+	}
+}
+
+func TestViDotRepeat(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"dw", "."}, "c d e\n"},
+		{[]string{"dw", "2."}, "d e\n"},
+		{[]string{"x", "w", "."}, "  c d e\n"},
+		{[]string{"cwX", "<esc>", "w", "."}, "X X c d e\n"},
+		{[]string{"ve", "d", "."}, " c d e\n"}, // visual changes are not repeated
+	}
+	for _, tt := range tests {
+		m := newViModel("a b c d e\n")
+		for _, k := range tt.keys {
+			viKeys(m, k)
+		}
+		if got := m.Content(); got != tt.want {
+			t.Errorf("%q: content %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+
+	// An insert is repeated on another line
+	m := newViModel("one\ntwo\n")
+	viKeys(m, "A;", "<esc>", "j", ".")
+	if got := m.Content(); got != "one;\ntwo;\n" {
+		t.Errorf("content %q, want both lines ended", got)
+	}
+}
+
+func TestViVisual(t *testing.T) {
+	m := newViModel("one two three\nfour five\n")
+	viKeys(m, "vw")
+	if m.vi.mode != viVisual || m.selectionStart != 0 || m.selectionEnd != 5 {
+		t.Fatalf("vw: mode %v, selection %d-%d", m.vi.mode, m.selectionStart, m.selectionEnd)
+	}
+	if got := m.vi.status(); got != "VISUAL" {
+		t.Errorf("status = %q", got)
+	}
+	viKeys(m, "y")
+	if m.clipboard != "one t" || m.selecting || m.vi.mode != viNormal {
+		t.Errorf("y: clipboard %q, selecting %v", m.clipboard, m.selecting)
+	}
+
+	m = newViModel("one\ntwo\nthree\n")
+	viKeys(m, "Vjd")
+	if got := m.Content(); got != "three\n" || m.clipboard != "one\ntwo\n" {
+		t.Errorf("Vjd: content %q, clipboard %q", got, m.clipboard)
+	}
+
+	m = newViModel("one two\n")
+	viKeys(m, "wvec", "2", "<esc>")
+	if got := m.Content(); got != "one 2\n" {
+		t.Errorf("vec: content %q", got)
+	}
+}
+
+func TestViStatus(t *testing.T) {
+	m := newViModel("one\n")
+	viKeys(m, "2d3")
+	if got := m.vi.status(); got != "NORMAL 2d3" {
+		t.Errorf("status = %q, want the pending command", got)
+	}
+	viKeys(m, "<esc>", "i")
+	if got := m.vi.status(); got != "INSERT" {
+		t.Errorf("status = %q, want INSERT", got)
+	}
+	if m.Content() != "one\n" {
+		t.Errorf("content = %q", m.Content())
+	}
+}
+
+func TestViCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	m := NewFromFile(path, "test.txt", "one\ntwo\nthree\n")
+	m.SetSize(80, 20)
+	m.SetViMode(true)
+
+	viKeys(m, ":3", "<enter>")
+	if m.buffer.CurrentLine() != 2 || m.mode != ModeNormal {
+		t.Errorf(":3 went to line %d", m.buffer.CurrentLine()+1)
+	}
+
+	viKeys(m, "dd", ":q", "<enter>")
+	if m.quitting || m.statusMessage != "No write since last change (add ! to override)" {
+		t.Errorf(":q on a modified file: quitting %v, status %q", m.quitting, m.statusMessage)
+	}
+
+	viKeys(m, ":w", "<enter>")
+	if data, err := os.ReadFile(path); err != nil || string(data) != "one\ntwo\n" {
+		t.Errorf(":w wrote %q, %v", data, err)
+	}
+
+	viKeys(m, ":wq", "<enter>")
+	if !m.quitting {
+		t.Error(":wq did not quit")
+	}
+
+	m = newViModel("")
+	viKeys(m, ":bogus", "<enter>")
+	if m.statusMessage != "Not an editor command: bogus" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+}
+
+func TestViPassesOtherKeys(t *testing.T) {
+	m := newViModel("one\n")
+	// Typed text is not inserted in normal mode; Ctrl keys keep working
+	viKeys(m, "z")
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	if m.Content() != "" {
+		t.Errorf("content = %q, want the line cut", m.Content())
+	}
+	viKeys(m, "P")
+	if m.Content() != "one\n" {
+		t.Errorf("content = %q, want the line back", m.Content())
+	}
+}
@@ -18,6 +18,7 @@ type EditOperation struct {
 	Position  int
 	Text      string
 	Timestamp time.Time
+	Group     int // non-zero for operations undone/redone together
 }
 
 // History manages undo/redo stacks.
@@ -26,6 +27,11 @@ type History struct {
 	redoStack    []EditOperation
 	maxSize      int
 	mergeTimeout time.Duration
+
+	// Grouping of compound edits into a single undo step
+	lastGroup   int
+	activeGroup int
+	groupDepth  int
 }
 
 // NewHistory creates a new History with default settings.
@@ -42,6 +48,7 @@ func NewHistory() *History {
 // Similar consecutive operations within mergeTimeout are merged.
 func (h *History) Push(op EditOperation) {
 	op.Timestamp = time.Now()
+	op.Group = h.activeGroup
 
 	// Clear redo stack on new operation
 	h.redoStack = nil
@@ -66,8 +73,8 @@ func (h *History) Push(op EditOperation) {
 
 // canMerge checks if two operations can be merged.
 func (h *History) canMerge(last, new *EditOperation) bool {
-	// Must be same type
-	if last.Type != new.Type {
+	// Must be same type and belong to the same group
+	if last.Type != new.Type || last.Group != new.Group {
 		return false
 	}
 
@@ -148,6 +155,58 @@ func (h *History) Redo() *EditOperation {
 	return &op
 }
 
+// BeginGroup starts a compound edit. All operations pushed until the
+// matching EndGroup are undone and redone as a single step.
+// Groups may be nested; only the outermost pair takes effect.
+func (h *History) BeginGroup() {
+	if h.groupDepth == 0 {
+		h.lastGroup++
+		h.activeGroup = h.lastGroup
+	}
+	h.groupDepth++
+}
+
+// EndGroup finishes a compound edit started with BeginGroup.
+func (h *History) EndGroup() {
+	if h.groupDepth == 0 {
+		return
+	}
+	h.groupDepth--
+	if h.groupDepth == 0 {
+		h.activeGroup = 0
+	}
+}
+
+// UndoGroup pops the last operation together with every operation of the
+// same group. Operations are returned in the order they must be reversed
+// (most recent first). Returns nil if there is nothing to undo.
+func (h *History) UndoGroup() []EditOperation {
+	op := h.Undo()
+	if op == nil {
+		return nil
+	}
+	ops := []EditOperation{*op}
+	for op.Group != 0 && len(h.undoStack) > 0 && h.undoStack[len(h.undoStack)-1].Group == op.Group {
+		ops = append(ops, *h.Undo())
+	}
+	return ops
+}
+
+// RedoGroup pops the next operation together with every operation of the
+// same group. Operations are returned in the order they must be re-applied.
+// Returns nil if there is nothing to redo.
+func (h *History) RedoGroup() []EditOperation {
+	op := h.Redo()
+	if op == nil {
+		return nil
+	}
+	ops := []EditOperation{*op}
+	for op.Group != 0 && len(h.redoStack) > 0 && h.redoStack[len(h.redoStack)-1].Group == op.Group {
+		ops = append(ops, *h.Redo())
+	}
+	return ops
+}
+
 // CanUndo returns true if there are operations to undo.
 func (h *History) CanUndo() bool {
 	return len(h.undoStack) > 0
@@ -172,3 +172,72 @@ func TestClear(t *testing.T) {
 		t.Error("CanRedo should be false after Clear")
 	}
 }
+
+func TestGroupUndoRedo(t *testing.T) {
+	h := NewHistory()
+
+	h.Push(EditOperation{Type: OpInsert, Position: 0, Text: "x"})
+
+	h.BeginGroup()
+	h.Push(EditOperation{Type: OpInsert, Position: 0, Text: "("})
+	h.Push(EditOperation{Type: OpInsert, Position: 2, Text: ")"})
+	h.EndGroup()
+
+	ops := h.UndoGroup()
+	if len(ops) != 2 {
+		t.Fatalf("UndoGroup() returned %d ops, want 2", len(ops))
+	}
+	if ops[0].Text != ")" || ops[1].Text != "(" {
+		t.Errorf("UndoGroup() order = %q, %q, want \")\", \"(\"", ops[0].Text, ops[1].Text)
+	}
+
+	ops = h.RedoGroup()
+	if len(ops) != 2 {
+		t.Fatalf("RedoGroup() returned %d ops, want 2", len(ops))
+	}
+	if ops[0].Text != "(" || ops[1].Text != ")" {
+		t.Errorf("RedoGroup() order = %q, %q, want \"(\", \")\"", ops[0].Text, ops[1].Text)
+	}
+
+	h.UndoGroup()
+	ops = h.UndoGroup()
+	if len(ops) != 1 || ops[0].Text != "x" {
+		t.Errorf("UndoGroup() = %v, want single \"x\" operation", ops)
+	}
+}
+
+func TestGroupDoesNotMergeWithUngrouped(t *testing.T) {
+	h := NewHistory()
+
+	h.Push(EditOperation{Type: OpInsert, Position: 0, Text: "a"})
+	h.BeginGroup()
+	h.Push(EditOperation{Type: OpInsert, Position: 1, Text: "b"})
+	h.EndGroup()
+	h.Push(EditOperation{Type: OpInsert, Position: 2, Text: "c"})
+
+	for _, want := range []string{"c", "b", "a"} {
+		ops := h.UndoGroup()
+		if len(ops) != 1 || ops[0].Text != want {
+			t.Errorf("UndoGroup() = %v, want single %q operation", ops, want)
+		}
+	}
+}
+
+func TestNestedGroups(t *testing.T) {
+	h := NewHistory()
+
+	h.BeginGroup()
+	h.Push(EditOperation{Type: OpInsert, Position: 0, Text: "a"})
+	h.BeginGroup()
+	h.Push(EditOperation{Type: OpDelete, Position: 0, Text: "a"})
+	h.EndGroup()
+	h.Push(EditOperation{Type: OpInsert, Position: 0, Text: "b"})
+	h.EndGroup()
+
+	if ops := h.UndoGroup(); len(ops) != 3 {
+		t.Errorf("UndoGroup() returned %d ops, want 3", len(ops))
+	}
+	if h.CanUndo() {
+		t.Error("Nested group should undo as a single step")
+	}
+}
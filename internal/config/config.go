@@ -16,20 +16,56 @@ type Config struct {
 
 	// Theme settings
 	Theme string `yaml:"theme"`
+
+	// Spell checking
+	Spell SpellConfig `yaml:"spell"`
+
+	// Linters
+	Lint LintConfig `yaml:"lint"`
+
+	// Formatters run before saving
+	Format FormatConfig `yaml:"format"`
+
+	// Language servers
+	LSP LSPConfig `yaml:"lsp"`
 }
 
 // EditorConfig contains editor-specific settings.
 type EditorConfig struct {
-	TabSize            int  `yaml:"tab_size"`
-	InsertSpaces       bool `yaml:"insert_spaces"`
-	AutoIndent         bool `yaml:"auto_indent"`
-	WordWrap           bool `yaml:"word_wrap"`
-	LineNumbers        bool `yaml:"line_numbers"`
-	ScrollPadding      int  `yaml:"scroll_padding"`
-	TrimTrailingSpaces bool `yaml:"trim_trailing_spaces"`
-	FinalNewline       bool `yaml:"final_newline"`
-	CreateBackup       bool `yaml:"create_backup"`
-	AutoSaveInterval   int  `yaml:"auto_save_interval"` // seconds, 0 = disabled
+	TabSize            int    `yaml:"tab_size"`
+	InsertSpaces       bool   `yaml:"insert_spaces"`
+	AutoIndent         bool   `yaml:"auto_indent"`
+	WordWrap           bool   `yaml:"word_wrap"`
+	LineNumbers        bool   `yaml:"line_numbers"`
+	ScrollPadding      int    `yaml:"scroll_padding"`
+	TrimTrailingSpaces bool   `yaml:"trim_trailing_spaces"`
+	FinalNewline       bool   `yaml:"final_newline"`
+	CreateBackup       bool   `yaml:"create_backup"`
+	AutoSaveInterval   int    `yaml:"auto_save_interval"` // seconds, 0 = disabled
+	AutoPairs          bool   `yaml:"auto_pairs"`         // auto-close brackets and quotes
+	Keymap             string `yaml:"keymap"`             // "nano" (default) or "vi"
+}
+
+// SpellConfig contains spell checker settings.
+type SpellConfig struct {
+	Enabled    bool   `yaml:"enabled"`    // underline misspelled words
+	Dictionary string `yaml:"dictionary"` // dictionary name (e.g. en_US) or path
+}
+
+// LintConfig contains linter settings.
+type LintConfig struct {
+	OnSave   bool              `yaml:"on_save"`  // lint after every save
+	Commands map[string]string `yaml:"commands"` // lowercase language name -> command
+}
+
+// FormatConfig contains formatter settings.
+type FormatConfig struct {
+	Commands map[string]string `yaml:"commands"` // lowercase language name -> command
+}
+
+// LSPConfig contains language server settings.
+type LSPConfig struct {
+	Servers map[string]string `yaml:"servers"` // lowercase language name -> command
 }
 
 // DefaultConfig returns the default configuration.
@@ -46,8 +82,17 @@ func DefaultConfig() *Config {
 			FinalNewline:       false,
 			CreateBackup:       false,
 			AutoSaveInterval:   0, // disabled by default
+			AutoPairs:          false,
+			Keymap:             "nano",
 		},
 		Theme: "dark",
+		Spell: SpellConfig{
+			Enabled:    false,
+			Dictionary: "en_US",
+		},
+		Lint: LintConfig{
+			OnSave: true,
+		},
 	}
 }
 
@@ -76,6 +121,49 @@ func GetConfigPath() string {
 	return filepath.Join(GetConfigDir(), "gesh.yaml")
 }
 
+// GetKeybindingsPath returns the full path to the key bindings file.
+func GetKeybindingsPath() string {
+	return filepath.Join(GetConfigDir(), "keybindings.yaml")
+}
+
+// GetSnippetsDir returns the directory holding per-language snippet files.
+func GetSnippetsDir() string {
+	return filepath.Join(GetConfigDir(), "snippets")
+}
+
+// GetDictionariesDir returns the directory searched first for Hunspell
+// dictionaries.
+func GetDictionariesDir() string {
+	return filepath.Join(GetConfigDir(), "dictionaries")
+}
+
+// GetPersonalDictionaryPath returns the path of the personal dictionary,
+// the word list that "Add to dictionary" appends to.
+func GetPersonalDictionaryPath() string {
+	return filepath.Join(GetConfigDir(), "dictionary.txt")
+}
+
+// GetStateDir returns the directory for persistent editor state
+// (bookmarks, histories).
+func GetStateDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		localAppData := os.Getenv("LOCALAPPDATA")
+		if localAppData != "" {
+			return filepath.Join(localAppData, "gesh")
+		}
+		return filepath.Join(os.Getenv("USERPROFILE"), "AppData", "Local", "gesh")
+	default:
+		// Linux, macOS
+		xdgState := os.Getenv("XDG_STATE_HOME")
+		if xdgState != "" {
+			return filepath.Join(xdgState, "gesh")
+		}
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, ".local", "state", "gesh")
+	}
+}
+
 // Load loads configuration from file.
 func Load() (*Config, error) {
 	configPath := GetConfigPath()
@@ -0,0 +1,115 @@
+// Package diff computes line-based differences between two texts.
+package diff
+
+// maxEdits bounds the Myers search. Inputs that differ in more lines are
+// reported as a single hunk covering everything between the common prefix
+// and suffix.
+const maxEdits = 2000
+
+// Hunk is a changed region: lines [OldStart, OldEnd) of the old text are
+// replaced by lines [NewStart, NewEnd) of the new text. Empty old ranges
+// are insertions and empty new ranges deletions.
+type Hunk struct {
+	OldStart, OldEnd int
+	NewStart, NewEnd int
+}
+
+// Lines returns the hunks that turn a into b, in order. Lines outside the
+// hunks are equal in both texts.
+func Lines(a, b []string) []Hunk {
+	// Common prefix and suffix are matched directly
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	if len(midA) == 0 && len(midB) == 0 {
+		return nil
+	}
+
+	matches, ok := myers(midA, midB)
+	if !ok {
+		return []Hunk{{prefix, len(a) - suffix, prefix, len(b) - suffix}}
+	}
+
+	// Gaps between matching lines are the hunks
+	var hunks []Hunk
+	x, y := 0, 0
+	for _, match := range append(matches, [2]int{len(midA), len(midB)}) {
+		if match[0] > x || match[1] > y {
+			hunks = append(hunks, Hunk{prefix + x, prefix + match[0], prefix + y, prefix + match[1]})
+		}
+		x, y = match[0]+1, match[1]+1
+	}
+	return hunks
+}
+
+// myers returns the index pairs of matching lines of a shortest edit script
+// from a to b, in order. It gives up after maxEdits edits.
+func myers(a, b []string) ([][2]int, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // insertion
+			} else {
+				x = v[offset+k-1] + 1 // deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, offset, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrack walks the saved search fronts back from (n, m) and collects
+// the diagonal moves, which are the matching lines.
+func backtrack(trace [][]int, offset, n, m int) [][2]int {
+	var matches [][2]int
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			matches = append(matches, [2]int{x, y})
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+	return matches
+}
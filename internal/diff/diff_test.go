@@ -0,0 +1,69 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// apply rebuilds the new text from a and the hunks.
+func apply(a, b []string, hunks []Hunk) []string {
+	var result []string
+	pos := 0
+	for _, h := range hunks {
+		result = append(result, a[pos:h.OldStart]...)
+		result = append(result, b[h.NewStart:h.NewEnd]...)
+		pos = h.OldEnd
+	}
+	return append(result, a[pos:]...)
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Hunk
+	}{
+		{"equal", "a b c", "a b c", nil},
+		{"insert", "a c", "a b c", []Hunk{{1, 1, 1, 2}}},
+		{"delete", "a b c", "a c", []Hunk{{1, 2, 1, 1}}},
+		{"change", "a b c", "a x c", []Hunk{{1, 2, 1, 2}}},
+		{"scattered", "a b c d e f", "x b c d e y", []Hunk{{0, 1, 0, 1}, {5, 6, 5, 6}}},
+		{"from empty", "", "a b", []Hunk{{0, 0, 0, 2}}},
+		{"move", "a b c d", "b c d a", []Hunk{{0, 1, 0, 0}, {4, 4, 3, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			got := Lines(a, b)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %v, want %v", got, tt.want)
+			}
+			if result := apply(a, b, got); !reflect.DeepEqual(result, b) && len(b) > 0 {
+				t.Errorf("applying hunks = %q, want %q", result, b)
+			}
+		})
+	}
+}
+
+func TestLinesRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "d"}
+	random := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = words[rng.Intn(len(words))]
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		result := apply(a, b, Lines(a, b))
+		if strings.Join(result, " ") != strings.Join(b, " ") {
+			t.Fatalf("Lines(%q, %q) does not rebuild the new text: %q", a, b, result)
+		}
+	}
+}
@@ -96,6 +96,45 @@ func SaveWithOptions(path string, content string, opts SaveOptions) error {
 	return os.WriteFile(path, []byte(processedContent), 0644)
 }
 
+// WriteAtomic replaces the contents of a file by writing a temporary file
+// in the same directory and renaming it over the original, so readers see
+// either the old or the new contents. The file's permissions are kept, and
+// a symlink is followed so the file it points to is replaced, not the link.
+func WriteAtomic(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 // trimTrailingWhitespace removes trailing spaces/tabs from each line.
 func trimTrailingWhitespace(content string) string {
 	lines := strings.Split(content, "\n")
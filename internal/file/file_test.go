@@ -3,6 +3,7 @@ package file
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 )
 
@@ -346,3 +347,39 @@ func TestConvertLineEndings(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "script.sh")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteAtomic(path, []byte("new")); err != nil {
+		t.Fatalf("WriteAtomic() error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("content = %q, want %q", data, "new")
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v, want 0755", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the directory, want no temporary files left", len(entries))
+	}
+
+	// Writing through a symlink replaces the target and keeps the link
+	link := filepath.Join(dir, "link.sh")
+	if err := os.Symlink(path, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := WriteAtomic(link, []byte("linked")); err != nil {
+		t.Fatalf("WriteAtomic() through a symlink error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link replaced by a regular file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "linked" {
+		t.Errorf("target content = %q, want %q", data, "linked")
+	}
+}
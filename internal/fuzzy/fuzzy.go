@@ -0,0 +1,237 @@
+// Package fuzzy scores approximate matches of a typed pattern against
+// candidate strings, in the manner of fzf.
+//
+// A pattern matches when its characters appear in order in the text. The
+// shortest such occurrence is scored: matched characters earn points,
+// characters at word boundaries (after a space, "/", "_" or at a camelCase
+// or digit transition) and consecutive runs earn bonuses, and gaps cost
+// points. Matching ignores case unless the pattern contains an upper-case
+// letter. Space-separated words of a pattern must all match.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scoring constants, as in fzf.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundary          = scoreMatch / 2
+	bonusBoundaryWhite     = bonusBoundary + 2
+	bonusBoundaryDelimiter = bonusBoundary + 1
+	bonusNonWord           = scoreMatch / 2
+	bonusCamel123          = bonusBoundary + scoreGapExtension
+	bonusConsecutive       = -(scoreGapStart + scoreGapExtension)
+	bonusFirstCharFactor   = 2
+)
+
+// charClass classifies characters for boundary bonuses.
+type charClass int
+
+const (
+	classWhite charClass = iota
+	classDelimiter
+	classNonWord
+	classLower
+	classUpper
+	classLetter
+	classNumber
+)
+
+func classOf(r rune) charClass {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return classLower
+	case r >= 'A' && r <= 'Z':
+		return classUpper
+	case r >= '0' && r <= '9':
+		return classNumber
+	case unicode.IsSpace(r):
+		return classWhite
+	case strings.ContainsRune("/,:;|", r):
+		return classDelimiter
+	case unicode.IsLower(r):
+		return classLower
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsLetter(r):
+		return classLetter
+	case unicode.IsNumber(r):
+		return classNumber
+	}
+	return classNonWord
+}
+
+// bonusFor returns the bonus of a character of class cur after one of
+// class prev.
+func bonusFor(prev, cur charClass) int {
+	if cur > classNonWord {
+		switch prev {
+		case classWhite:
+			return bonusBoundaryWhite
+		case classDelimiter:
+			return bonusBoundaryDelimiter
+		case classNonWord:
+			return bonusBoundary
+		}
+	}
+	switch {
+	case prev == classLower && cur == classUpper,
+		prev != classNumber && cur == classNumber:
+		return bonusCamel123
+	case cur == classNonWord || cur == classDelimiter:
+		return bonusNonWord
+	case cur == classWhite:
+		return bonusBoundaryWhite
+	}
+	return 0
+}
+
+// Match scores pattern against text. It returns the score, the rune
+// indices of the matched characters in increasing order, and whether the
+// pattern matched at all. An empty pattern matches everything with score 0.
+func Match(pattern, text string) (score int, positions []int, ok bool) {
+	runes := []rune(text)
+	for _, word := range strings.Fields(pattern) {
+		s, pos, ok := matchWord([]rune(word), runes)
+		if !ok {
+			return 0, nil, false
+		}
+		score += s
+		positions = append(positions, pos...)
+	}
+	if len(positions) > 0 {
+		sort.Ints(positions)
+		positions = dedupe(positions)
+	}
+	return score, positions, true
+}
+
+// matchWord finds the shortest occurrence of pattern in text ending at its
+// first complete match and scores it.
+func matchWord(pattern, text []rune) (int, []int, bool) {
+	caseSensitive := false
+	for _, r := range pattern {
+		if unicode.IsUpper(r) {
+			caseSensitive = true
+			break
+		}
+	}
+	fold := func(r rune) rune {
+		if caseSensitive {
+			return r
+		}
+		return unicode.ToLower(r)
+	}
+
+	// Forward: the end of the first occurrence
+	p, end := 0, -1
+	for i, r := range text {
+		if fold(r) == pattern[p] {
+			p++
+			if p == len(pattern) {
+				end = i + 1
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	// Backward: the latest start for that end
+	p, start := len(pattern)-1, 0
+	for i := end - 1; i >= 0; i-- {
+		if fold(text[i]) == pattern[p] {
+			p--
+			if p < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	score, consecutive, firstBonus := 0, 0, 0
+	inGap := false
+	prevClass := classWhite
+	if start > 0 {
+		prevClass = classOf(text[start-1])
+	}
+	positions := make([]int, 0, len(pattern))
+	p = 0
+	for i := start; i < end; i++ {
+		class := classOf(text[i])
+		if p < len(pattern) && fold(text[i]) == pattern[p] {
+			score += scoreMatch
+			bonus := bonusFor(prevClass, class)
+			if consecutive == 0 {
+				firstBonus = bonus
+			} else {
+				if bonus >= bonusBoundary && bonus > firstBonus {
+					firstBonus = bonus
+				}
+				bonus = max(bonus, firstBonus, bonusConsecutive)
+			}
+			if p == 0 {
+				score += bonus * bonusFirstCharFactor
+			} else {
+				score += bonus
+			}
+			positions = append(positions, i)
+			inGap = false
+			consecutive++
+			p++
+		} else {
+			if inGap {
+				score += scoreGapExtension
+			} else {
+				score += scoreGapStart
+			}
+			inGap = true
+			consecutive = 0
+			firstBonus = 0
+		}
+		prevClass = class
+	}
+	return score, positions, true
+}
+
+func dedupe(sorted []int) []int {
+	out := sorted[:1]
+	for _, n := range sorted[1:] {
+		if n != out[len(out)-1] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Result is a candidate matched by Filter.
+type Result struct {
+	Index     int   // index of the candidate
+	Score     int   // higher is better
+	Positions []int // rune indices of the matched characters
+}
+
+// Filter returns the candidates matching pattern, best first. Equal scores
+// prefer shorter candidates, then the original order.
+func Filter(pattern string, candidates []string) []Result {
+	results := make([]Result, 0, len(candidates))
+	for i, candidate := range candidates {
+		if score, positions, ok := Match(pattern, candidate); ok {
+			results = append(results, Result{Index: i, Score: score, Positions: positions})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return len(candidates[a.Index]) < len(candidates[b.Index])
+	})
+	return results
+}
@@ -0,0 +1,67 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		text      string
+		ok        bool
+		positions []int
+	}{
+		{"abc", "a_b_c", true, []int{0, 2, 4}},
+		{"mgo", "cmd/main.go", true, []int{4, 9, 10}},
+		{"mgo", "main.rs", false, nil},
+		{"MG", "main.go", false, nil},
+		{"MG", "MainGo", true, []int{0, 4}},
+		{"mg", "MainGo", true, []int{0, 4}},
+		{"app model", "internal/app/model.go", true, []int{9, 10, 11, 13, 14, 15, 16, 17}},
+		{"app zzz", "internal/app/model.go", false, nil},
+		{"", "anything", true, nil},
+		// The shortest occurrence ending at the first match is used
+		{"ab", "a_xab", true, []int{3, 4}},
+	}
+	for _, tt := range tests {
+		_, positions, ok := Match(tt.pattern, tt.text)
+		if ok != tt.ok || !reflect.DeepEqual(positions, tt.positions) {
+			t.Errorf("Match(%q, %q) = %v, %v, want %v, %v", tt.pattern, tt.text, positions, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestScoreOrder(t *testing.T) {
+	// Each pair lists a better match first
+	tests := []struct {
+		pattern      string
+		better, than string
+	}{
+		{"mod", "go.mod", "gomod"},
+		{"fb", "foo_bar", "fooxbar"},
+		{"fb", "FooBar", "Foobar"},
+		{"main", "main.go", "domain.go"},
+		{"ago", "app/go.mod", "abcdefgo"},
+	}
+	for _, tt := range tests {
+		a, _, okA := Match(tt.pattern, tt.better)
+		b, _, okB := Match(tt.pattern, tt.than)
+		if !okA || !okB || a <= b {
+			t.Errorf("Match(%q): %q scores %d, %q scores %d, want the first higher", tt.pattern, tt.better, a, tt.than, b)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	candidates := []string{"docs/CONFIG.md", "internal/config/config.go", "config.go", "README.md"}
+	results := Filter("config", candidates)
+	var got []string
+	for _, r := range results {
+		got = append(got, candidates[r.Index])
+	}
+	want := []string{"config.go", "docs/CONFIG.md", "internal/config/config.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+}
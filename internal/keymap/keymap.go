@@ -0,0 +1,488 @@
+// Package keymap binds keys and key sequences (chords) to named editor
+// actions, with a map per editor mode, and loads user bindings from a
+// keybindings.yaml file.
+//
+// Keys are written as Bubble Tea names them, e.g. "ctrl+o", "alt+u",
+// "shift+tab", "f5" or "x", with "space" for the space bar; a chord is
+// its keys separated by spaces, e.g. "ctrl+k ctrl+c". The file maps
+// modes to actions to their keys:
+//
+//	normal:
+//	  tab.new: ctrl+n
+//	  edit.comment: [alt+3, "ctrl+k ctrl+c"]
+//	  toggle.tab-bar: []
+//
+// Binding an action replaces its default keys in that mode; an empty
+// list leaves it unbound.
+package keymap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+// Modes with their own key maps. The search prompts use the prompt map
+// with the search map's bindings on top.
+const (
+	Normal = "normal"
+	Prompt = "prompt"
+	Search = "search"
+)
+
+// Modes lists the key map modes in order.
+var Modes = []string{Normal, Prompt, Search}
+
+// modeMap holds the bindings of one mode.
+type modeMap struct {
+	keys     map[string][]string // action -> key sequences
+	bindings map[string]string   // key sequence -> action
+	prefixes map[string]bool     // proper prefixes of chords
+	reserved map[string]bool     // keys of the default bindings
+	user     map[string]int      // action -> order bound in the file, from 1
+	lines    map[string]int      // action -> line bound on in the file
+}
+
+// conflict is a problem found building the bindings of a mode.
+type conflict struct {
+	line int // line of the user binding involved, or 0
+	text string
+}
+
+// Keymap binds key sequences to actions, per mode.
+type Keymap struct {
+	modes map[string]*modeMap
+}
+
+// New returns a keymap with default bindings, given per mode as action
+// names to key sequences. Every action of a mode must be listed, with no
+// keys if it has no default binding.
+func New(defaults map[string]map[string][]string) *Keymap {
+	k := &Keymap{modes: make(map[string]*modeMap)}
+	for _, mode := range Modes {
+		mm := &modeMap{
+			keys:     make(map[string][]string),
+			reserved: make(map[string]bool),
+			user:     make(map[string]int),
+			lines:    make(map[string]int),
+		}
+		for action, seqs := range defaults[mode] {
+			mm.keys[action] = normalizeAll(seqs)
+		}
+		k.modes[mode] = mm
+	}
+	// Search prompts start from the prompt bindings
+	for action, seqs := range k.modes[Prompt].keys {
+		if _, ok := k.modes[Search].keys[action]; !ok {
+			k.modes[Search].keys[action] = seqs
+		}
+	}
+	for _, mode := range Modes {
+		mm := k.modes[mode]
+		for _, seqs := range mm.keys {
+			for _, seq := range seqs {
+				mm.reserved[seq] = true
+			}
+		}
+		mm.build()
+	}
+	return k
+}
+
+// normalizeAll normalizes key sequences, dropping invalid ones and
+// duplicates.
+func normalizeAll(seqs []string) []string {
+	var out []string
+	for _, seq := range seqs {
+		if norm, err := NormalizeSequence(seq); err == nil && !contains(out, norm) {
+			out = append(out, norm)
+		}
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// build indexes the bindings of a mode and reports conflicts: a sequence
+// bound to two actions in the file (the later one wins), and a key that
+// starts a chord, which then no longer runs its own action. User bindings
+// take keys from default bindings silently.
+func (mm *modeMap) build() []conflict {
+	var conflicts []conflict
+	actions := make([]string, 0, len(mm.keys))
+	for action := range mm.keys {
+		actions = append(actions, action)
+	}
+	// Defaults first, then user bindings in file order
+	sort.Slice(actions, func(i, j int) bool {
+		a, b := mm.user[actions[i]], mm.user[actions[j]]
+		if a != b {
+			return a < b
+		}
+		return actions[i] < actions[j]
+	})
+
+	mm.bindings = make(map[string]string)
+	for _, action := range actions {
+		for _, seq := range mm.keys[action] {
+			if other, ok := mm.bindings[seq]; ok {
+				if mm.user[other] > 0 {
+					conflicts = append(conflicts, conflict{mm.lines[action], fmt.Sprintf("%s is bound to both %s and %s; using %s", seq, other, action, action)})
+				}
+				mm.unbind(other, seq)
+			}
+			mm.bindings[seq] = action
+		}
+	}
+
+	mm.prefixes = make(map[string]bool)
+	var chords []string
+	for seq := range mm.bindings {
+		if strings.Contains(seq, " ") {
+			chords = append(chords, seq)
+		}
+	}
+	sort.Strings(chords)
+	for _, seq := range chords {
+		if _, ok := mm.bindings[seq]; !ok {
+			continue
+		}
+		keys := strings.Split(seq, " ")
+		for i := 1; i < len(keys); i++ {
+			prefix := strings.Join(keys[:i], " ")
+			mm.prefixes[prefix] = true
+			if other, ok := mm.bindings[prefix]; ok {
+				conflicts = append(conflicts, conflict{mm.lines[mm.bindings[seq]], fmt.Sprintf("%s no longer runs %s: it starts the chord %s (%s)", prefix, other, seq, mm.bindings[seq])})
+				mm.unbind(other, prefix)
+				delete(mm.bindings, prefix)
+			}
+		}
+	}
+	return conflicts
+}
+
+// unbind removes a sequence from the keys of an action.
+func (mm *modeMap) unbind(action, seq string) {
+	seqs := mm.keys[action]
+	for i, s := range seqs {
+		if s == seq {
+			mm.keys[action] = append(seqs[:i:i], seqs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Load applies the user bindings of a keybindings file and returns
+// warnings about its problems. A missing file is not an error.
+func (k *Keymap) Load(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return k.Parse(filepath.Base(path), data)
+}
+
+// Parse applies user bindings in the keybindings file format. Warnings
+// are prefixed with name and the line they are about.
+func (k *Keymap) Parse(name string, data []byte) ([]string, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: expected modes (normal, prompt, search)", name, doc.Line)
+	}
+
+	var warnings []string
+	warn := func(node *yaml.Node, format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf("%s:%d: ", name, node.Line)+fmt.Sprintf(format, args...))
+	}
+	order := 0
+	bound := make(map[string]bool) // modes with user bindings
+	modeNodes := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		modeNode, actionsNode := doc.Content[i], doc.Content[i+1]
+		mode := modeNode.Value
+		if _, ok := k.modes[mode]; !ok {
+			warn(modeNode, "unknown mode %q", mode)
+			continue
+		}
+		if actionsNode.Kind != yaml.MappingNode {
+			if actionsNode.Tag != "!!null" {
+				warn(actionsNode, "expected actions for mode %s", mode)
+			}
+			continue
+		}
+		modeNodes[mode] = actionsNode
+	}
+	// The prompt map goes before the search map, which starts from it
+	for _, mode := range Modes {
+		actionsNode := modeNodes[mode]
+		if actionsNode == nil {
+			continue
+		}
+		mm := k.modes[mode]
+		for j := 0; j+1 < len(actionsNode.Content); j += 2 {
+			actionNode, keysNode := actionsNode.Content[j], actionsNode.Content[j+1]
+			action := actionNode.Value
+			if _, ok := mm.keys[action]; !ok {
+				warn(actionNode, "unknown action %q in mode %s", action, mode)
+				continue
+			}
+			var seqs []string
+			switch {
+			case keysNode.Kind == yaml.ScalarNode && keysNode.Tag == "!!null":
+			case keysNode.Kind == yaml.ScalarNode:
+				seqs = []string{keysNode.Value}
+			case keysNode.Kind == yaml.SequenceNode:
+				for _, n := range keysNode.Content {
+					if n.Kind != yaml.ScalarNode {
+						warn(n, "expected a key for %s", action)
+						continue
+					}
+					seqs = append(seqs, n.Value)
+				}
+			default:
+				warn(keysNode, "expected a key or a list of keys for %s", action)
+				continue
+			}
+			var norms []string
+			for _, seq := range seqs {
+				norm, err := NormalizeSequence(seq)
+				if err != nil {
+					warn(keysNode, "%s: %v", action, err)
+					continue
+				}
+				if !contains(norms, norm) {
+					norms = append(norms, norm)
+				}
+			}
+			order++
+			mm.keys[action] = norms
+			mm.user[action] = order
+			mm.lines[action] = actionNode.Line
+			bound[mode] = true
+			if mode == Prompt {
+				// The search prompts follow unless they bind it themselves
+				if _, ok := k.modes[Search].user[action]; !ok {
+					k.modes[Search].keys[action] = norms
+				}
+			}
+		}
+	}
+	if bound[Prompt] {
+		bound[Search] = true
+	}
+	// Search prompts repeat the conflicts of the prompt map they start from
+	seen := make(map[string]bool)
+	for _, mode := range Modes {
+		if !bound[mode] {
+			continue
+		}
+		for _, c := range k.modes[mode].build() {
+			if !seen[c.text] {
+				seen[c.text] = true
+				warnings = append(warnings, fmt.Sprintf("%s:%d: %s: %s", name, c.line, mode, c.text))
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// Lookup resolves a key sequence in a mode. It returns the action bound
+// to it, or reports whether the sequence starts a longer chord.
+func (k *Keymap) Lookup(mode, seq string) (action string, prefix bool) {
+	mm := k.modes[mode]
+	if mm == nil {
+		return "", false
+	}
+	if action, ok := mm.bindings[seq]; ok {
+		return action, false
+	}
+	return "", mm.prefixes[seq]
+}
+
+// Reserved reports whether a key has a default binding in a mode. Such
+// keys do nothing once the user binds their action to other keys.
+func (k *Keymap) Reserved(mode, key string) bool {
+	mm := k.modes[mode]
+	return mm != nil && mm.reserved[key]
+}
+
+// Keys returns the key sequences bound to an action in a mode.
+func (k *Keymap) Keys(mode, action string) []string {
+	mm := k.modes[mode]
+	if mm == nil {
+		return nil
+	}
+	return mm.keys[action]
+}
+
+// Actions returns the actions of a mode, sorted by name.
+func (k *Keymap) Actions(mode string) []string {
+	mm := k.modes[mode]
+	if mm == nil {
+		return nil
+	}
+	actions := make([]string, 0, len(mm.keys))
+	for action := range mm.keys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// keyTypes maps key names to the key types Bubble Tea reports them with.
+var keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{"space": tea.KeySpace}
+	for k := tea.KeyType(-100); k < 128; k++ {
+		if name := k.String(); name != "" && k != tea.KeyRunes && k != tea.KeySpace {
+			if _, ok := types[name]; !ok {
+				types[name] = k
+			}
+		}
+	}
+	return types
+}()
+
+// Name returns the name of a pressed key as key maps use it: its String,
+// except that the space bar is "space".
+func Name(msg tea.KeyMsg) string {
+	if msg.Type == tea.KeySpace || (msg.Type == tea.KeyRunes && string(msg.Runes) == " ") {
+		if msg.Alt {
+			return "alt+space"
+		}
+		return "space"
+	}
+	return msg.String()
+}
+
+// keyAliases maps other common names of keys to Bubble Tea's names.
+var keyAliases = map[string]string{
+	"escape":     "esc",
+	"return":     "enter",
+	"del":        "delete",
+	"ins":        "insert",
+	"pageup":     "pgup",
+	"pagedown":   "pgdown",
+	"pgdn":       "pgdown",
+	"ctrl+m":     "enter",
+	"ctrl+i":     "tab",
+	"ctrl+[":     "esc",
+	"ctrl+space": "ctrl+@",
+	"ctrl+2":     "ctrl+@",
+	"ctrl+6":     "ctrl+^",
+	"ctrl+/":     "ctrl+_",
+}
+
+// Normalize returns the name Bubble Tea reports a key with, accepting
+// modifiers in any case and common aliases such as "space" or "pgdn".
+// Terminals report Shift+F1 to Shift+F8 as F13 to F20.
+func Normalize(key string) (string, error) {
+	rest := strings.TrimSpace(key)
+	var alt, ctrl, shift bool
+	for {
+		lower := strings.ToLower(rest)
+		switch {
+		case len(rest) > 4 && strings.HasPrefix(lower, "alt+"):
+			alt, rest = true, rest[4:]
+		case len(rest) > 5 && strings.HasPrefix(lower, "ctrl+"):
+			ctrl, rest = true, rest[5:]
+		case len(rest) > 6 && strings.HasPrefix(lower, "shift+"):
+			shift, rest = true, rest[6:]
+		default:
+			goto done
+		}
+	}
+done:
+	if rest == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	if ctrl || utf8.RuneCountInString(rest) > 1 {
+		rest = strings.ToLower(rest)
+	}
+	if alias, ok := keyAliases[rest]; ok {
+		rest = alias
+	}
+
+	name := rest
+	if shift {
+		if n, err := strconv.Atoi(strings.TrimPrefix(rest, "f")); err == nil && strings.HasPrefix(rest, "f") && n >= 1 && n <= 8 && !ctrl {
+			name = fmt.Sprintf("f%d", n+12)
+			shift = false
+		} else if r, size := utf8.DecodeRuneInString(rest); size == len(rest) && !ctrl {
+			name = strings.ToUpper(string(r))
+			shift = false
+		}
+	}
+	if shift {
+		name = "shift+" + name
+	}
+	if ctrl {
+		name = "ctrl+" + name
+	}
+	if alias, ok := keyAliases[name]; ok {
+		name = alias
+	}
+
+	if _, ok := keyTypes[name]; !ok && (ctrl || shift || utf8.RuneCountInString(name) != 1) {
+		return "", fmt.Errorf("unknown key %q", key)
+	}
+	if alt {
+		name = "alt+" + name
+	}
+	return name, nil
+}
+
+// NormalizeSequence normalizes the keys of a space-separated sequence.
+func NormalizeSequence(seq string) (string, error) {
+	fields := strings.Fields(seq)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty key")
+	}
+	for i, field := range fields {
+		key, err := Normalize(field)
+		if err != nil {
+			return "", err
+		}
+		fields[i] = key
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// Msg returns the key message named key, such as "ctrl+o", "alt+up" or
+// "x".
+func Msg(key string) tea.KeyMsg {
+	if keyType, ok := keyTypes[key]; ok {
+		return tea.KeyMsg{Type: keyType}
+	}
+	alt := false
+	if rest, ok := strings.CutPrefix(key, "alt+"); ok && rest != "" {
+		alt, key = true, rest
+		if keyType, ok := keyTypes[key]; ok {
+			return tea.KeyMsg{Type: keyType, Alt: true}
+		}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key), Alt: alt}
+}
@@ -0,0 +1,174 @@
+package keymap
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"ctrl+k", "ctrl+k"},
+		{"Ctrl+K", "ctrl+k"},
+		{"ALT+Up", "alt+up"},
+		{"alt+X", "alt+X"},
+		{"x", "x"},
+		{"space", "space"},
+		{"alt+space", "alt+space"},
+		{"ctrl+space", "ctrl+@"},
+		{"PgDn", "pgdown"},
+		{"ctrl+pgdn", "ctrl+pgdown"},
+		{"escape", "esc"},
+		{"ctrl+m", "enter"},
+		{"shift+tab", "shift+tab"},
+		{"shift+a", "A"},
+		{"shift+f6", "f18"},
+		{"alt+}", "alt+}"},
+		{"ctrl+\\", "ctrl+\\"},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.key)
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+
+	for _, key := range []string{"", "ctrl+", "hyper+x", "ctrl+foo", "shift+ctrl+q"} {
+		if got, err := Normalize(key); err == nil {
+			t.Errorf("Normalize(%q) = %q, want an error", key, got)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		msg  tea.KeyMsg
+		want string
+	}{
+		{tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}, "space"},
+		{tea.KeyMsg{Type: tea.KeySpace, Alt: true}, "alt+space"},
+		{tea.KeyMsg{Type: tea.KeyCtrlK}, "ctrl+k"},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}, Alt: true}, "alt+x"},
+	}
+	for _, tt := range tests {
+		if got := Name(tt.msg); got != tt.want {
+			t.Errorf("Name(%v) = %q, want %q", tt.msg, got, tt.want)
+		}
+		if got := Name(Msg(tt.want)); got != tt.want {
+			t.Errorf("Name(Msg(%q)) = %q", tt.want, got)
+		}
+	}
+}
+
+// testKeymap returns a keymap with a few nano-like defaults.
+func testKeymap() *Keymap {
+	return New(map[string]map[string][]string{
+		Normal: {
+			"edit.cut":     {"ctrl+k"},
+			"edit.undo":    {"alt+u"},
+			"edit.redo":    {"alt+e"},
+			"edit.comment": {"alt+3"},
+			"tab.new":      {"ctrl+t"},
+			"tab.close":    nil,
+		},
+		Prompt: {
+			"prompt.accept": {"enter"},
+			"prompt.cancel": {"esc"},
+		},
+	})
+}
+
+func TestDefaults(t *testing.T) {
+	k := testKeymap()
+	if action, _ := k.Lookup(Normal, "ctrl+k"); action != "edit.cut" {
+		t.Errorf("ctrl+k = %q, want edit.cut", action)
+	}
+	if action, _ := k.Lookup(Search, "enter"); action != "prompt.accept" {
+		t.Errorf("enter in search = %q, want the prompt binding", action)
+	}
+	if action, _ := k.Lookup(Prompt, "ctrl+k"); action != "" {
+		t.Errorf("ctrl+k in prompt = %q, want nothing", action)
+	}
+	if got := k.Actions(Normal); len(got) != 6 || got[0] != "edit.comment" {
+		t.Errorf("Actions = %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	k := testKeymap()
+	warnings, err := k.Parse("keybindings.yaml", []byte(`
+normal:
+  edit.undo: Ctrl+Z
+  edit.redo: ctrl+z
+  edit.comment: [alt+3, "ctrl+k ctrl+c"]
+  tab.close: ctrl+t
+  tab.new: []
+  bogus.action: f10
+prompt:
+  prompt.accept: [enter, ctrl+j]
+  prompt.cancel: hyper+x
+search:
+  prompt.accept: ctrl+s
+modal: {}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantWarnings := []string{
+		`keybindings.yaml:14: unknown mode "modal"`,
+		`keybindings.yaml:8: unknown action "bogus.action" in mode normal`,
+		`keybindings.yaml:11: prompt.cancel: unknown key "hyper+x"`,
+		`keybindings.yaml:4: normal: ctrl+z is bound to both edit.undo and edit.redo; using edit.redo`,
+		`keybindings.yaml:5: normal: ctrl+k no longer runs edit.cut: it starts the chord ctrl+k ctrl+c (edit.comment)`,
+	}
+	if got := strings.Join(warnings, "\n"); got != strings.Join(wantWarnings, "\n") {
+		t.Errorf("warnings:\n%s\nwant:\n%s", got, strings.Join(wantWarnings, "\n"))
+	}
+
+	tests := []struct {
+		mode, seq  string
+		wantAction string
+		wantPrefix bool
+	}{
+		{Normal, "ctrl+z", "edit.redo", false},
+		{Normal, "alt+u", "", false},
+		{Normal, "ctrl+k", "", true},
+		{Normal, "ctrl+k ctrl+c", "edit.comment", false},
+		{Normal, "alt+3", "edit.comment", false},
+		{Normal, "ctrl+t", "tab.close", false},
+		{Prompt, "ctrl+j", "prompt.accept", false},
+		{Prompt, "esc", "", false},
+		{Search, "ctrl+s", "prompt.accept", false},
+		{Search, "enter", "", false},
+		{Search, "esc", "", false},
+	}
+	for _, tt := range tests {
+		action, prefix := k.Lookup(tt.mode, tt.seq)
+		if action != tt.wantAction || prefix != tt.wantPrefix {
+			t.Errorf("Lookup(%s, %q) = %q, %v, want %q, %v", tt.mode, tt.seq, action, prefix, tt.wantAction, tt.wantPrefix)
+		}
+	}
+
+	if !k.Reserved(Normal, "alt+u") || k.Reserved(Normal, "ctrl+z") {
+		t.Error("Reserved should report the default keys only")
+	}
+	if got := k.Keys(Normal, "edit.cut"); len(got) != 0 {
+		t.Errorf("edit.cut keys = %q, want none", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{"normal: [", "- normal"} {
+		if _, err := testKeymap().Parse("keybindings.yaml", []byte(data)); err == nil {
+			t.Errorf("Parse(%q) succeeded", data)
+		}
+	}
+	if warnings, err := testKeymap().Parse("keybindings.yaml", nil); err != nil || len(warnings) != 0 {
+		t.Errorf("empty file: %v, %q", err, warnings)
+	}
+}
@@ -0,0 +1,151 @@
+// Package lint builds linter command lines and parses their diagnostics.
+//
+// Linters are expected to print one diagnostic per line in the format
+// used by compilers and most linters:
+//
+//	file:line:col: message
+//	file:line: message
+//
+// Messages starting with a severity ("error:", "warning:", "[warning]",
+// ...) are classified accordingly; everything else counts as an error.
+package lint
+
+import (
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity classifies a diagnostic.
+type Severity int
+
+const (
+	// SeverityError is a problem that must be fixed.
+	SeverityError Severity = iota
+	// SeverityWarning is a likely problem.
+	SeverityWarning
+	// SeverityInfo is a note or style hint.
+	SeverityInfo
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "error"
+	}
+}
+
+// Diagnostic is a single problem reported by a linter.
+type Diagnostic struct {
+	Line     int // 1-based
+	Col      int // 1-based byte column, 0 if not reported
+	Severity Severity
+	Message  string
+}
+
+// linePattern matches "file:line:col: message" with an optional column.
+var linePattern = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)?\s*(.*)$`)
+
+// severityPrefixes maps message prefixes to severities.
+var severityPrefixes = []struct {
+	prefix   string
+	severity Severity
+}{
+	{"error:", SeverityError},
+	{"[error]", SeverityError},
+	{"warning:", SeverityWarning},
+	{"[warning]", SeverityWarning},
+	{"note:", SeverityInfo},
+	{"info:", SeverityInfo},
+	{"style:", SeverityInfo},
+	{"[info]", SeverityInfo},
+}
+
+// Parse extracts the diagnostics for the file at path from linter output,
+// sorted by position. File names in the output that are not absolute are
+// resolved against dir, the directory the linter ran in.
+func Parse(output, path, dir string) []Diagnostic {
+	target := absPath(path, "")
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		match := linePattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil || absPath(match[1], dir) != target {
+			continue
+		}
+		lineNum, err := strconv.Atoi(match[2])
+		if err != nil || lineNum < 1 {
+			continue
+		}
+		col, _ := strconv.Atoi(match[3])
+		severity, message := classify(match[4])
+		diagnostics = append(diagnostics, Diagnostic{
+			Line:     lineNum,
+			Col:      col,
+			Severity: severity,
+			Message:  message,
+		})
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Col < b.Col
+	})
+	return diagnostics
+}
+
+// classify splits a leading severity off message.
+func classify(message string) (Severity, string) {
+	lower := strings.ToLower(message)
+	for _, p := range severityPrefixes {
+		if strings.HasPrefix(lower, p.prefix) {
+			return p.severity, strings.TrimSpace(message[len(p.prefix):])
+		}
+	}
+	return SeverityError, message
+}
+
+// absPath returns the cleaned absolute form of name, relative to dir.
+func absPath(name, dir string) string {
+	if !filepath.IsAbs(name) && dir != "" {
+		name = filepath.Join(dir, name)
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// Command returns the shell command line that runs template on path.
+// "{file}" in template is replaced by the quoted path; without it the path
+// is appended.
+func Command(template, path string) string {
+	if strings.Contains(template, "{file}") {
+		return Expand(template, path)
+	}
+	return template + " " + quote(path)
+}
+
+// Expand returns template with "{file}" replaced by the quoted path, for
+// commands such as formatters that do not take the path otherwise.
+func Expand(template, path string) string {
+	return strings.ReplaceAll(template, "{file}", quote(path))
+}
+
+// quote quotes s for the system shell.
+func quote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,80 @@
+package lint
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+
+	tests := []struct {
+		name   string
+		output string
+		want   []Diagnostic
+	}{
+		{
+			name:   "go vet",
+			output: "# example\n./main.go:7:2: fmt.Printf format %d has arg s of wrong type string\n",
+			want:   []Diagnostic{{Line: 7, Col: 2, Message: "fmt.Printf format %d has arg s of wrong type string"}},
+		},
+		{
+			name:   "gcc format with severity",
+			output: "main.go:3:5: warning: Double quote to prevent globbing. [SC2086]\nmain.go:1:1: note: Not following. [SC1091]",
+			want: []Diagnostic{
+				{Line: 1, Col: 1, Severity: SeverityInfo, Message: "Not following. [SC1091]"},
+				{Line: 3, Col: 5, Severity: SeverityWarning, Message: "Double quote to prevent globbing. [SC2086]"},
+			},
+		},
+		{
+			name:   "parsable with brackets",
+			output: path + ":2:1: [warning] missing document start (document-start)",
+			want:   []Diagnostic{{Line: 2, Col: 1, Severity: SeverityWarning, Message: "missing document start (document-start)"}},
+		},
+		{
+			name:   "no column",
+			output: "main.go:4: undefined: x\r\n",
+			want:   []Diagnostic{{Line: 4, Message: "undefined: x"}},
+		},
+		{
+			name:   "other files and noise",
+			output: "other.go:1:1: bad\nexit status 1\nsub/main.go:2:2: bad\n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.output, path, dir)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX quoting")
+	}
+	tests := []struct {
+		template string
+		path     string
+		want     string
+	}{
+		{"go vet", "/src/main.go", "go vet '/src/main.go'"},
+		{"shellcheck -f gcc {file}", "/src/it's.sh", `shellcheck -f gcc '/src/it'\''s.sh'`},
+	}
+	for _, tt := range tests {
+		if got := Command(tt.template, tt.path); got != tt.want {
+			t.Errorf("Command(%q, %q) = %q, want %q", tt.template, tt.path, got, tt.want)
+		}
+	}
+
+	if got := Expand("gofmt", "/src/main.go"); got != "gofmt" {
+		t.Errorf("Expand() = %q, want the path left out", got)
+	}
+}
@@ -0,0 +1,323 @@
+// Package lsp implements a Language Server Protocol client that talks to a
+// server process over stdio.
+//
+// A Client keeps the server informed about open documents (didOpen,
+// didChange, didClose) and wraps the requests the editor uses: hover,
+// definition, completion, rename and formatting. Diagnostics published by
+// the server are collected and handed out with WaitDiagnostics.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Text document sync kinds announced by the server.
+const (
+	syncNone        = 0
+	syncFull        = 1
+	syncIncremental = 2
+)
+
+// shutdownTimeout bounds how long Close waits for the server to exit.
+const shutdownTimeout = 2 * time.Second
+
+// Client is a connection to a running language server.
+type Client struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	conn  *conn
+
+	syncKind int
+
+	closeOnce sync.Once
+	closeErr  error
+
+	// Latest diagnostics by URI, not yet handed out
+	mu          sync.Mutex
+	diagnostics map[string]PublishDiagnosticsParams
+	ready       chan struct{} // signaled when diagnostics arrive
+}
+
+// Start runs the server command and performs the initialize handshake
+// with rootDir as the workspace folder. The server is stopped again if
+// the handshake fails or ctx is done first.
+func Start(ctx context.Context, command []string, rootDir string) (*Client, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no language server command")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = rootDir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		cmd:         cmd,
+		stdin:       stdin,
+		diagnostics: make(map[string]PublishDiagnosticsParams),
+		ready:       make(chan struct{}, 1),
+	}
+	c.conn = newConn(stdout, stdin, c.handleNotification)
+
+	if err := c.initialize(ctx, rootDir); err != nil {
+		c.kill()
+		return nil, err
+	}
+	return c, nil
+}
+
+// initialize announces the client capabilities and reads the sync kind.
+func (c *Client) initialize(ctx context.Context, rootDir string) error {
+	rootURI := FileURI(rootDir)
+	params := map[string]any{
+		"processId":  os.Getpid(),
+		"clientInfo": map[string]any{"name": "gesh"},
+		"rootUri":    rootURI,
+		"workspaceFolders": []map[string]any{
+			{"uri": rootURI, "name": rootDir},
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"synchronization": map[string]any{"didSave": false},
+				"hover": map[string]any{
+					"contentFormat": []string{"plaintext", "markdown"},
+				},
+				"definition": map[string]any{"linkSupport": true},
+				"completion": map[string]any{
+					"completionItem": map[string]any{"snippetSupport": false},
+				},
+				"rename":             map[string]any{},
+				"formatting":         map[string]any{},
+				"publishDiagnostics": map[string]any{},
+			},
+			"workspace": map[string]any{
+				"workspaceFolders": true,
+				"configuration":    true,
+			},
+		},
+	}
+
+	var result struct {
+		Capabilities struct {
+			TextDocumentSync json.RawMessage `json:"textDocumentSync"`
+		} `json:"capabilities"`
+	}
+	if err := c.conn.call(ctx, "initialize", params, &result); err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	c.syncKind = decodeSyncKind(result.Capabilities.TextDocumentSync)
+	return c.conn.send("initialized", map[string]any{})
+}
+
+// decodeSyncKind reads textDocumentSync, a kind or a TextDocumentSyncOptions
+// object. Servers that leave it out get full document updates.
+func decodeSyncKind(raw json.RawMessage) int {
+	var kind int
+	if json.Unmarshal(raw, &kind) == nil {
+		return kind
+	}
+	var options struct {
+		Change *int `json:"change"`
+	}
+	if json.Unmarshal(raw, &options) == nil && options.Change != nil {
+		return *options.Change
+	}
+	return syncFull
+}
+
+// handleNotification collects published diagnostics; other notifications
+// (progress, log messages) are ignored.
+func (c *Client) handleNotification(method string, params json.RawMessage) {
+	if method != "textDocument/publishDiagnostics" {
+		return
+	}
+	var p PublishDiagnosticsParams
+	if json.Unmarshal(params, &p) != nil {
+		return
+	}
+	c.mu.Lock()
+	c.diagnostics[p.URI] = p
+	c.mu.Unlock()
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// WaitDiagnostics blocks until diagnostics were published and returns the
+// latest ones for each document since the last call. It returns false once
+// the server has exited.
+func (c *Client) WaitDiagnostics() ([]PublishDiagnosticsParams, bool) {
+	for {
+		select {
+		case <-c.ready:
+		case <-c.conn.done:
+		}
+
+		c.mu.Lock()
+		if len(c.diagnostics) > 0 {
+			published := make([]PublishDiagnosticsParams, 0, len(c.diagnostics))
+			for _, p := range c.diagnostics {
+				published = append(published, p)
+			}
+			clear(c.diagnostics)
+			c.mu.Unlock()
+			return published, true
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-c.conn.done:
+			return nil, false
+		default:
+		}
+	}
+}
+
+// DidOpen tells the server a document was opened with the given text.
+func (c *Client) DidOpen(uri, languageID string, version int, text string) error {
+	return c.conn.send("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{
+			"uri":        uri,
+			"languageId": languageID,
+			"version":    version,
+			"text":       text,
+		},
+	})
+}
+
+// DidChange tells the server a document changed from oldText to newText.
+// Servers supporting incremental sync only get the changed range.
+func (c *Client) DidChange(uri string, version int, oldText, newText string) error {
+	var change TextDocumentContentChangeEvent
+	switch c.syncKind {
+	case syncNone:
+		return nil
+	case syncIncremental:
+		var changed bool
+		if change, changed = Change(oldText, newText); !changed {
+			return nil
+		}
+	default:
+		change.Text = newText
+	}
+	return c.conn.send("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": version},
+		"contentChanges": []TextDocumentContentChangeEvent{change},
+	})
+}
+
+// DidClose tells the server a document was closed.
+func (c *Client) DidClose(uri string) error {
+	return c.conn.send("textDocument/didClose", map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+	})
+}
+
+// positionParams builds TextDocumentPositionParams.
+func positionParams(uri string, pos Position) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     pos,
+	}
+}
+
+// Hover returns the hover text at a position, or "" if there is none.
+func (c *Client) Hover(ctx context.Context, uri string, pos Position) (string, error) {
+	var result *hoverResult
+	if err := c.conn.call(ctx, "textDocument/hover", positionParams(uri, pos), &result); err != nil || result == nil {
+		return "", err
+	}
+	return result.text(), nil
+}
+
+// Definition returns the locations defining the symbol at a position.
+func (c *Client) Definition(ctx context.Context, uri string, pos Position) ([]Location, error) {
+	var result json.RawMessage
+	if err := c.conn.call(ctx, "textDocument/definition", positionParams(uri, pos), &result); err != nil {
+		return nil, err
+	}
+	return decodeLocations(result), nil
+}
+
+// Completion returns completion items at a position.
+func (c *Client) Completion(ctx context.Context, uri string, pos Position) ([]CompletionItem, error) {
+	var result json.RawMessage
+	if err := c.conn.call(ctx, "textDocument/completion", positionParams(uri, pos), &result); err != nil {
+		return nil, err
+	}
+	return decodeCompletion(result), nil
+}
+
+// Rename returns the edits renaming the symbol at a position to newName.
+func (c *Client) Rename(ctx context.Context, uri string, pos Position, newName string) (*WorkspaceEdit, error) {
+	params := positionParams(uri, pos)
+	params["newName"] = newName
+	var result *WorkspaceEdit
+	if err := c.conn.call(ctx, "textDocument/rename", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Format returns the edits formatting a whole document.
+func (c *Client) Format(ctx context.Context, uri string, tabSize int, insertSpaces bool) ([]TextEdit, error) {
+	params := map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"options":      map[string]any{"tabSize": tabSize, "insertSpaces": insertSpaces},
+	}
+	var result []TextEdit
+	if err := c.conn.call(ctx, "textDocument/formatting", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Close asks the server to shut down and exit, killing it if it does not
+// exit in time.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { c.closeErr = c.shutdown() })
+	return c.closeErr
+}
+
+// shutdown runs the shutdown handshake and stops the process.
+func (c *Client) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := c.conn.call(ctx, "shutdown", nil, nil); err == nil {
+		_ = c.conn.send("exit", nil)
+	}
+	c.stdin.Close()
+
+	select {
+	case <-c.conn.done:
+	case <-ctx.Done():
+	}
+	return c.kill()
+}
+
+// kill stops the server process and waits for it.
+func (c *Client) kill() error {
+	c.stdin.Close()
+	_ = c.cmd.Process.Kill()
+	err := c.cmd.Wait()
+	if _, ok := err.(*exec.ExitError); ok {
+		return nil
+	}
+	return err
+}
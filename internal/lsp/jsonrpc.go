@@ -0,0 +1,240 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrClosed is returned for requests to a server whose connection closed.
+var ErrClosed = errors.New("language server connection closed")
+
+// message is a JSON-RPC 2.0 request, notification or response.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+// ResponseError is an error returned by the server for a request.
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// readMessage reads one message framed with a Content-Length header.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// writeMessage writes v as JSON framed with a Content-Length header.
+func writeMessage(w io.Writer, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// conn is a JSON-RPC connection to a server. Responses are matched to
+// requests by id; notifications go to the notify callback, which must not
+// block.
+type conn struct {
+	w       io.Writer
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan *message
+	err     error // set once the connection closed
+
+	notify func(method string, params json.RawMessage)
+	done   chan struct{}
+}
+
+// newConn starts reading messages from r in the background.
+func newConn(r io.Reader, w io.Writer, notify func(string, json.RawMessage)) *conn {
+	c := &conn{
+		w:       w,
+		pending: make(map[int]chan *message),
+		notify:  notify,
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(r))
+	return c
+}
+
+// readLoop dispatches incoming messages until the stream ends, then fails
+// all pending requests.
+func (c *conn) readLoop(r *bufio.Reader) {
+	var err error
+	for {
+		var body []byte
+		body, err = readMessage(r)
+		if err != nil {
+			break
+		}
+		var msg message
+		if json.Unmarshal(body, &msg) != nil {
+			continue
+		}
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			c.reply(&msg)
+		case msg.Method != "":
+			c.notify(msg.Method, msg.Params)
+		case msg.ID != nil:
+			var id int
+			if json.Unmarshal(*msg.ID, &id) != nil {
+				continue
+			}
+			c.mu.Lock()
+			ch := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- &msg
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.err = ErrClosed
+	if err != nil && err != io.EOF {
+		c.err = fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// reply answers a request from the server. The client offers no
+// configuration and accepts everything else with an empty result.
+func (c *conn) reply(req *message) {
+	result := json.RawMessage("null")
+	if req.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		result, _ = json.Marshal(make([]any, len(params.Items)))
+	}
+	c.write(&message{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+// write sends one message, serializing concurrent writers.
+func (c *conn) write(msg *message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writeMessage(c.w, msg)
+}
+
+// call sends a request and waits for its response, decoding the result
+// into result. It gives up when ctx is done.
+func (c *conn) call(ctx context.Context, method string, params, result any) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	rawID := json.RawMessage(strconv.Itoa(id))
+	if err := c.write(&message{JSONRPC: "2.0", ID: &rawID, Method: method, Params: raw}); err != nil {
+		c.forget(id)
+		return err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return c.closedErr()
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-ctx.Done():
+		c.forget(id)
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+// forget drops a pending request.
+func (c *conn) forget(id int) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// closedErr returns the error the connection closed with.
+func (c *conn) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// send sends a notification.
+func (c *conn) send(method string, params any) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+	if err := c.closedErr(); err != nil {
+		return err
+	}
+	return c.write(&message{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+// marshalParams encodes request parameters; nil params are left out.
+func marshalParams(params any) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	return json.Marshal(params)
+}
@@ -0,0 +1,226 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KilimcininKorOglu/gesh/internal/lsp/lsptest"
+)
+
+func TestMain(m *testing.M) {
+	lsptest.Main()
+	os.Exit(m.Run())
+}
+
+func TestChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     Range
+		text     string
+	}{
+		{"insert", "hello world", "hello, world", Range{Position{0, 5}, Position{0, 5}}, ","},
+		{"delete line", "a\nb\nc", "a\nc", Range{Position{1, 0}, Position{2, 0}}, ""},
+		{"replace", "x := 1\ny := 2", "x := 1\nz := 2", Range{Position{1, 0}, Position{1, 1}}, "z"},
+		{"append", "a", "a\nb", Range{Position{0, 1}, Position{0, 1}}, "\nb"},
+		{"after surrogate pair", "😀a", "😀ba", Range{Position{0, 2}, Position{0, 2}}, "b"},
+		{"shared rune prefix", "é", "è", Range{Position{0, 0}, Position{0, 1}}, "è"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, ok := Change(tt.old, tt.new)
+			if !ok {
+				t.Fatal("Change() reported no change")
+			}
+			if *change.Range != tt.want || change.Text != tt.text {
+				t.Errorf("Change() = %+v %q, want %+v %q", *change.Range, change.Text, tt.want, tt.text)
+			}
+		})
+	}
+
+	if _, ok := Change("same", "same"); ok {
+		t.Error("Change() of equal texts reported a change")
+	}
+}
+
+func TestColumns(t *testing.T) {
+	line := "a😀b"
+	if got := UTF16Column(line, 2); got != 3 {
+		t.Errorf("UTF16Column() = %d, want 3", got)
+	}
+	if got := RuneColumn(line, 3); got != 2 {
+		t.Errorf("RuneColumn() = %d, want 2", got)
+	}
+	if got := RuneColumn(line, 10); got != 3 {
+		t.Errorf("RuneColumn() past the end = %d, want 3", got)
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir with space", "main.go")
+	uri := FileURI(path)
+	if !strings.HasPrefix(uri, "file:///") || strings.Contains(uri, " ") {
+		t.Errorf("FileURI() = %q", uri)
+	}
+	if got := URIPath(uri); got != path {
+		t.Errorf("URIPath() = %q, want %q", got, path)
+	}
+	if got := URIPath("untitled:1"); got != "" {
+		t.Errorf("URIPath() of a non-file URI = %q", got)
+	}
+}
+
+func TestDecodeResults(t *testing.T) {
+	hovers := []struct {
+		raw  string
+		want string
+	}{
+		{`{"kind": "markdown", "value": "**doc**"}`, "**doc**"},
+		{`"plain"`, "plain"},
+		{`[{"language": "go", "value": "func f()"}, "doc"]`, "func f()\ndoc"},
+	}
+	for _, tt := range hovers {
+		if got := markedText(json.RawMessage(tt.raw)); got != tt.want {
+			t.Errorf("markedText(%s) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+
+	want := []Location{{URI: "file:///a.go", Range: Range{Position{1, 2}, Position{1, 2}}}}
+	locations := []string{
+		`{"uri": "file:///a.go", "range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 2}}}`,
+		`[{"uri": "file:///a.go", "range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 2}}}]`,
+		`[{"targetUri": "file:///a.go", "targetSelectionRange": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 2}}}]`,
+	}
+	for _, raw := range locations {
+		if got := decodeLocations(json.RawMessage(raw)); !reflect.DeepEqual(got, want) {
+			t.Errorf("decodeLocations(%s) = %+v", raw, got)
+		}
+	}
+	if got := decodeLocations(json.RawMessage("null")); len(got) != 0 {
+		t.Errorf("decodeLocations(null) = %+v", got)
+	}
+
+	items := decodeCompletion(json.RawMessage(`{"isIncomplete": false, "items": [{"label": "Println", "insertText": "Println($1)", "insertTextFormat": 2}]}`))
+	if len(items) != 1 || items[0].Text() != "Println" {
+		t.Errorf("decodeCompletion() = %+v", items)
+	}
+}
+
+// startFake starts the fake server in a temporary workspace.
+func startFake(t *testing.T) (*Client, string) {
+	t.Helper()
+	t.Setenv(lsptest.EnvVar, "1")
+	root := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := Start(ctx, []string{lsptest.Command()}, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, root
+}
+
+// waitDiagnostics returns the next diagnostics published for uri.
+func waitDiagnostics(t *testing.T, client *Client, uri string) []Diagnostic {
+	t.Helper()
+	for {
+		published, ok := client.WaitDiagnostics()
+		if !ok {
+			t.Fatal("server exited")
+		}
+		for _, p := range published {
+			if p.URI == uri {
+				return p.Diagnostics
+			}
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	client, root := startFake(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	uri := FileURI(filepath.Join(root, "main.go"))
+	text := "package main\n\nfunc helper() {}\n\nfunc main() {\n\thelper()\n}\n"
+	if err := client.DidOpen(uri, "go", 1, text); err != nil {
+		t.Fatal(err)
+	}
+	if d := waitDiagnostics(t, client, uri); len(d) != 0 {
+		t.Errorf("diagnostics after open = %+v, want none", d)
+	}
+
+	// Incremental changes keep the server's copy in sync
+	updated := strings.Replace(text, "\thelper()", "\thelper() // TODO 😀", 1)
+	if err := client.DidChange(uri, 2, text, updated); err != nil {
+		t.Fatal(err)
+	}
+	d := waitDiagnostics(t, client, uri)
+	if len(d) != 1 || d[0].Range.Start != (Position{5, 13}) || d[0].Severity != SeverityWarning {
+		t.Fatalf("diagnostics after change = %+v, want a warning at 5:13", d)
+	}
+
+	hover, err := client.Hover(ctx, uri, Position{5, 2})
+	if err != nil || !strings.Contains(hover, "Hover for helper") {
+		t.Errorf("Hover() = %q, %v", hover, err)
+	}
+
+	locations, err := client.Definition(ctx, uri, Position{5, 2})
+	if err != nil || len(locations) != 1 || locations[0].URI != uri || locations[0].Range.Start.Line != 2 {
+		t.Errorf("Definition() = %+v, %v", locations, err)
+	}
+
+	items, err := client.Completion(ctx, uri, Position{5, 4})
+	if err != nil || len(items) != 1 || items[0].Text() != "helper" {
+		t.Errorf("Completion() = %+v, %v", items, err)
+	}
+
+	edit, err := client.Rename(ctx, uri, Position{2, 6}, "assist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edits := edit.FileEdits()[uri]; len(edits) != 2 || edits[0].NewText != "assist" {
+		t.Errorf("Rename() edits = %+v", edits)
+	}
+
+	if err := client.DidChange(uri, 3, updated, "x  \ny\t\n"); err != nil {
+		t.Fatal(err)
+	}
+	formatting, err := client.Format(ctx, uri, 4, false)
+	if err != nil || len(formatting) != 2 || formatting[0].Range != (Range{Position{0, 1}, Position{0, 3}}) {
+		t.Errorf("Format() = %+v, %v", formatting, err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+	for {
+		if _, ok := client.WaitDiagnostics(); !ok {
+			break // the server has exited
+		}
+	}
+	if _, err := client.Hover(ctx, uri, Position{}); err == nil {
+		t.Error("request after Close succeeded")
+	}
+}
+
+func TestStartFailure(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Start(ctx, []string{filepath.Join(t.TempDir(), "missing-server")}, "."); err == nil {
+		t.Error("Start() of a missing command succeeded")
+	}
+
+	// A server that exits at once fails the handshake
+	t.Setenv(lsptest.EnvVar, "")
+	if _, err := Start(ctx, []string{lsptest.Command(), "-test.run=^$"}, "."); err == nil {
+		t.Error("Start() of a server that exits succeeded")
+	}
+}
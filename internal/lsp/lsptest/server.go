@@ -0,0 +1,442 @@
+// Package lsptest provides a fake language server for tests.
+//
+// The server understands just enough of the protocol to exercise a client:
+// it keeps open documents in sync, publishes a warning for every "TODO",
+// and answers hover, definition, completion, rename and formatting
+// requests with simple word-based results. Definitions are lines starting
+// with "func <word>" in the open documents and the files of the workspace.
+//
+// Test binaries serve it by calling Main from TestMain and running their
+// own executable with EnvVar set.
+package lsptest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// EnvVar is the environment variable that makes Main serve the fake server.
+const EnvVar = "GESH_FAKE_LSP"
+
+// Main serves the fake server on stdin and stdout and exits if EnvVar is
+// set; otherwise it returns.
+func Main() {
+	if os.Getenv(EnvVar) == "" {
+		return
+	}
+	if err := Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Command returns the command line running the fake server from the
+// current test binary. Callers must set EnvVar for the child process.
+func Command() string {
+	return os.Args[0]
+}
+
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  any              `json:"result,omitempty"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type rangeJSON struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type textEdit struct {
+	Range   rangeJSON `json:"range"`
+	NewText string    `json:"newText"`
+}
+
+type location struct {
+	URI   string    `json:"uri"`
+	Range rangeJSON `json:"range"`
+}
+
+type positionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position position `json:"position"`
+	NewName  string   `json:"newName"`
+}
+
+// server is the state of one fake server session.
+type server struct {
+	w       io.Writer
+	root    string            // workspace directory
+	docs    map[string]string // open documents by URI
+	request int               // id of the next request to the client
+}
+
+// Serve runs the fake server until it receives exit or r ends.
+func Serve(r io.Reader, w io.Writer) error {
+	s := &server{w: w, docs: make(map[string]string)}
+	reader := bufio.NewReader(r)
+	for {
+		header, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			return err
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if msg.Method == "" {
+			continue // response to a request of ours
+		}
+		result := s.handle(msg.Method, msg.Params)
+		if msg.ID != nil {
+			s.write(message{JSONRPC: "2.0", ID: msg.ID, Result: jsonNull(result)})
+		}
+	}
+}
+
+// jsonNull makes nil results encode as null instead of being left out.
+func jsonNull(result any) any {
+	if result == nil {
+		return json.RawMessage("null")
+	}
+	return result
+}
+
+func (s *server) write(msg message) {
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *server) notify(method string, params any) {
+	raw, _ := json.Marshal(params)
+	s.write(message{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+// handle answers one request or notification.
+func (s *server) handle(method string, params json.RawMessage) any {
+	switch method {
+	case "initialize":
+		var p struct {
+			RootURI string `json:"rootUri"`
+		}
+		_ = json.Unmarshal(params, &p)
+		s.root = uriPath(p.RootURI)
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":           map[string]any{"openClose": true, "change": 2},
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"completionProvider":         map[string]any{},
+				"renameProvider":             true,
+				"documentFormattingProvider": true,
+			},
+		}
+
+	case "initialized":
+		// Servers ask for settings; the client must answer
+		s.request++
+		raw, _ := json.Marshal(map[string]any{"items": []any{map[string]any{"section": "fake"}}})
+		id := json.RawMessage(strconv.Itoa(s.request))
+		s.write(message{JSONRPC: "2.0", ID: &id, Method: "workspace/configuration", Params: raw})
+
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		_ = json.Unmarshal(params, &p)
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+		s.publish(p.TextDocument.URI)
+
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Range *rangeJSON `json:"range"`
+				Text  string     `json:"text"`
+			} `json:"contentChanges"`
+		}
+		_ = json.Unmarshal(params, &p)
+		text := s.docs[p.TextDocument.URI]
+		for _, change := range p.ContentChanges {
+			if change.Range == nil {
+				text = change.Text
+				continue
+			}
+			start, end := offsetOf(text, change.Range.Start), offsetOf(text, change.Range.End)
+			text = text[:start] + change.Text + text[end:]
+		}
+		s.docs[p.TextDocument.URI] = text
+		s.publish(p.TextDocument.URI)
+
+	case "textDocument/didClose":
+		var p positionParams
+		_ = json.Unmarshal(params, &p)
+		delete(s.docs, p.TextDocument.URI)
+
+	case "textDocument/hover":
+		var p positionParams
+		_ = json.Unmarshal(params, &p)
+		word := wordAt(s.docs[p.TextDocument.URI], p.Position)
+		if word == "" {
+			return nil
+		}
+		return map[string]any{
+			"contents": map[string]any{
+				"kind":  "markdown",
+				"value": "```go\nfunc " + word + "()\n```\n\nHover for " + word,
+			},
+		}
+
+	case "textDocument/definition":
+		var p positionParams
+		_ = json.Unmarshal(params, &p)
+		word := wordAt(s.docs[p.TextDocument.URI], p.Position)
+		var locations []location
+		for _, uri := range s.workspace() {
+			for i, line := range strings.Split(s.text(uri), "\n") {
+				if strings.HasPrefix(line, "func "+word+"(") {
+					pos := position{Line: i, Character: 5}
+					locations = append(locations, location{URI: uri, Range: rangeJSON{pos, pos}})
+				}
+			}
+		}
+		return locations
+
+	case "textDocument/completion":
+		var p positionParams
+		_ = json.Unmarshal(params, &p)
+		text := s.docs[p.TextDocument.URI]
+		prefix := wordBefore(text, p.Position)
+		seen := make(map[string]bool)
+		var items []map[string]any
+		for _, word := range strings.FieldsFunc(text, notWord) {
+			if strings.HasPrefix(word, prefix) && word != prefix && !seen[word] {
+				seen[word] = true
+				items = append(items, map[string]any{"label": word, "insertText": word})
+			}
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i]["label"].(string) < items[j]["label"].(string)
+		})
+		return map[string]any{"isIncomplete": false, "items": items}
+
+	case "textDocument/rename":
+		var p positionParams
+		_ = json.Unmarshal(params, &p)
+		word := wordAt(s.docs[p.TextDocument.URI], p.Position)
+		changes := make(map[string][]textEdit)
+		for _, uri := range s.workspace() {
+			for i, line := range strings.Split(s.text(uri), "\n") {
+				for _, col := range wordColumns(line, word) {
+					changes[uri] = append(changes[uri], textEdit{
+						Range: rangeJSON{
+							position{i, utf16Len(line[:col])},
+							position{i, utf16Len(line[:col+len(word)])},
+						},
+						NewText: p.NewName,
+					})
+				}
+			}
+		}
+		return map[string]any{"changes": changes}
+
+	case "textDocument/formatting":
+		// Trailing white space is removed
+		var p positionParams
+		_ = json.Unmarshal(params, &p)
+		var edits []textEdit
+		for i, line := range strings.Split(s.docs[p.TextDocument.URI], "\n") {
+			trimmed := strings.TrimRight(line, " \t")
+			if trimmed != line {
+				edits = append(edits, textEdit{
+					Range:   rangeJSON{position{i, utf16Len(trimmed)}, position{i, utf16Len(line)}},
+					NewText: "",
+				})
+			}
+		}
+		return edits
+	}
+	return nil
+}
+
+// publish sends a warning for every TODO in a document.
+func (s *server) publish(uri string) {
+	diagnostics := []map[string]any{}
+	for i, line := range strings.Split(s.docs[uri], "\n") {
+		if col := strings.Index(line, "TODO"); col >= 0 {
+			start := position{i, utf16Len(line[:col])}
+			diagnostics = append(diagnostics, map[string]any{
+				"range":    rangeJSON{start, position{i, start.Character + 4}},
+				"severity": 2,
+				"source":   "fake",
+				"message":  "TODO found",
+			})
+		}
+	}
+	s.notify("textDocument/publishDiagnostics", map[string]any{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// workspace returns the URIs of the open documents and the files in the
+// workspace directory, sorted.
+func (s *server) workspace() []string {
+	uris := make(map[string]bool)
+	for uri := range s.docs {
+		uris[uri] = true
+	}
+	if entries, err := os.ReadDir(s.root); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				uris[fileURI(filepath.Join(s.root, entry.Name()))] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(uris))
+	for uri := range uris {
+		sorted = append(sorted, uri)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// text returns the content of an open document, or of the file on disk.
+func (s *server) text(uri string) string {
+	if text, ok := s.docs[uri]; ok {
+		return text
+	}
+	data, _ := os.ReadFile(uriPath(uri))
+	return string(data)
+}
+
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
+
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// offsetOf returns the byte offset of a position in text.
+func offsetOf(text string, pos position) int {
+	offset := 0
+	for i := 0; i < pos.Line; i++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		offset += next + 1
+	}
+	units := 0
+	for i, r := range text[offset:] {
+		if units >= pos.Character || r == '\n' {
+			return offset + i
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(text)
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+func notWord(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+}
+
+// wordAt returns the word around a position.
+func wordAt(text string, pos position) string {
+	offset := offsetOf(text, pos)
+	start, end := offset, offset
+	for start > 0 && !notWord(rune(text[start-1])) {
+		start--
+	}
+	for end < len(text) && !notWord(rune(text[end])) {
+		end++
+	}
+	return text[start:end]
+}
+
+// wordBefore returns the part of the word before a position.
+func wordBefore(text string, pos position) string {
+	offset := offsetOf(text, pos)
+	start := offset
+	for start > 0 && !notWord(rune(text[start-1])) {
+		start--
+	}
+	return text[start:offset]
+}
+
+// wordColumns returns the byte columns where word occurs as a whole word.
+func wordColumns(line, word string) []int {
+	var cols []int
+	if word == "" {
+		return cols
+	}
+	for i := 0; i+len(word) <= len(line); i++ {
+		if line[i:i+len(word)] != word {
+			continue
+		}
+		before := i == 0 || notWord(rune(line[i-1]))
+		after := i+len(word) == len(line) || notWord(rune(line[i+len(word)]))
+		if before && after {
+			cols = append(cols, i)
+		}
+	}
+	return cols
+}
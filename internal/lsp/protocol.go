@@ -0,0 +1,196 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Position is a zero-based line and UTF-16 column in a document.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a half-open range between two positions.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// TextEdit replaces a range of a document with new text.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// TextDocumentContentChangeEvent is a change sent with didChange. Without
+// a range the text replaces the whole document.
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// Diagnostic severities.
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+// Diagnostic is a problem reported by the server.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+// PublishDiagnosticsParams holds the diagnostics of one document; they
+// replace the ones published before.
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// InsertTextFormatSnippet marks completion text with snippet placeholders.
+const InsertTextFormatSnippet = 2
+
+// CompletionItem is a completion proposal.
+type CompletionItem struct {
+	Label            string `json:"label"`
+	Kind             int    `json:"kind,omitempty"`
+	Detail           string `json:"detail,omitempty"`
+	SortText         string `json:"sortText,omitempty"`
+	InsertText       string `json:"insertText,omitempty"`
+	InsertTextFormat int    `json:"insertTextFormat,omitempty"`
+	TextEdit         *struct {
+		NewText string `json:"newText"`
+	} `json:"textEdit,omitempty"`
+}
+
+// Text returns the text inserted for the item. Snippets fall back to the
+// label, since their placeholders cannot be inserted literally.
+func (item CompletionItem) Text() string {
+	switch {
+	case item.InsertTextFormat == InsertTextFormatSnippet:
+		return item.Label
+	case item.TextEdit != nil:
+		return item.TextEdit.NewText
+	case item.InsertText != "":
+		return item.InsertText
+	}
+	return item.Label
+}
+
+// WorkspaceEdit holds edits to several documents, as returned by rename.
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Edits []TextEdit `json:"edits"`
+	} `json:"documentChanges,omitempty"`
+}
+
+// FileEdits returns the text edits by document URI. Resource operations
+// (creating, renaming or deleting files) are not supported and skipped.
+func (e *WorkspaceEdit) FileEdits() map[string][]TextEdit {
+	edits := make(map[string][]TextEdit)
+	if e == nil {
+		return edits
+	}
+	for uri, changes := range e.Changes {
+		edits[uri] = append(edits[uri], changes...)
+	}
+	for _, change := range e.DocumentChanges {
+		if uri := change.TextDocument.URI; uri != "" {
+			edits[uri] = append(edits[uri], change.Edits...)
+		}
+	}
+	return edits
+}
+
+// hoverResult decodes hover contents in any of the protocol's forms:
+// MarkupContent, a MarkedString or a list of MarkedStrings.
+type hoverResult struct {
+	Contents json.RawMessage `json:"contents"`
+}
+
+// text returns the hover contents as plain text.
+func (h *hoverResult) text() string {
+	return markedText(h.Contents)
+}
+
+// markedText flattens MarkupContent or MarkedString values to text.
+func markedText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var markup struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(raw, &markup) == nil && markup.Value != "" {
+		return markup.Value
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		parts := make([]string, 0, len(list))
+		for _, item := range list {
+			if text := markedText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// decodeLocations decodes a definition result: a Location, a list of
+// Locations or a list of LocationLinks.
+func decodeLocations(raw json.RawMessage) []Location {
+	var single Location
+	if json.Unmarshal(raw, &single) == nil && single.URI != "" {
+		return []Location{single}
+	}
+	var links []struct {
+		Location
+		TargetURI            string `json:"targetUri"`
+		TargetSelectionRange Range  `json:"targetSelectionRange"`
+	}
+	if json.Unmarshal(raw, &links) != nil {
+		return nil
+	}
+	locations := make([]Location, 0, len(links))
+	for _, link := range links {
+		if link.TargetURI != "" {
+			locations = append(locations, Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+		} else if link.URI != "" {
+			locations = append(locations, link.Location)
+		}
+	}
+	return locations
+}
+
+// decodeCompletion decodes a completion result: a list of items or a
+// CompletionList.
+func decodeCompletion(raw json.RawMessage) []CompletionItem {
+	var items []CompletionItem
+	if json.Unmarshal(raw, &items) == nil {
+		return items
+	}
+	var list struct {
+		Items []CompletionItem `json:"items"`
+	}
+	_ = json.Unmarshal(raw, &list)
+	return list.Items
+}
@@ -0,0 +1,124 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// FileURI returns the file:// URI of a path.
+func FileURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if runtime.GOOS == "windows" {
+		path = "/" + path // file:///C:/dir
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// URIPath returns the file path of a file:// URI, or "" for other URIs.
+func URIPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
+
+// UTF16Column converts a rune column of line to the UTF-16 column the
+// protocol uses.
+func UTF16Column(line string, col int) int {
+	n := 0
+	for i, r := range []rune(line) {
+		if i >= col {
+			break
+		}
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// RuneColumn converts a UTF-16 column of line to a rune column.
+func RuneColumn(line string, col int) int {
+	n, units := 0, 0
+	for _, r := range line {
+		if units >= col {
+			break
+		}
+		units += utf16.RuneLen(r)
+		n++
+	}
+	return n
+}
+
+// positionAt returns the position of a byte offset in text.
+func positionAt(text string, offset int) Position {
+	line := strings.Count(text[:offset], "\n")
+	lineStart := strings.LastIndexByte(text[:offset], '\n') + 1
+	return Position{
+		Line:      line,
+		Character: len(utf16.Encode([]rune(text[lineStart:offset]))),
+	}
+}
+
+// Change returns the incremental change turning oldText into newText: the
+// range between their common prefix and suffix, replaced by the new text
+// in between. It returns false if the texts are equal.
+func Change(oldText, newText string) (TextDocumentContentChangeEvent, bool) {
+	if oldText == newText {
+		return TextDocumentContentChangeEvent{}, false
+	}
+
+	prefix := 0
+	for prefix < len(oldText) && prefix < len(newText) && oldText[prefix] == newText[prefix] {
+		prefix++
+	}
+	for prefix > 0 && prefix < len(oldText) && !utf8.RuneStart(oldText[prefix]) {
+		prefix--
+	}
+	suffix := 0
+	for suffix < len(oldText)-prefix && suffix < len(newText)-prefix &&
+		oldText[len(oldText)-1-suffix] == newText[len(newText)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !utf8.RuneStart(oldText[len(oldText)-suffix]) {
+		suffix--
+	}
+
+	return TextDocumentContentChangeEvent{
+		Range: &Range{
+			Start: positionAt(oldText, prefix),
+			End:   positionAt(oldText, len(oldText)-suffix),
+		},
+		Text: newText[prefix : len(newText)-suffix],
+	}, true
+}
+
+// languageIDs maps editor language names to protocol language identifiers
+// where they differ from the lowercase name.
+var languageIDs = map[string]string{
+	"c++":      "cpp",
+	"c#":       "csharp",
+	"f#":       "fsharp",
+	"shell":    "shellscript",
+	"protobuf": "proto",
+}
+
+// LanguageID returns the protocol language identifier for a language name
+// such as "Go" or "C++".
+func LanguageID(name string) string {
+	name = strings.ToLower(name)
+	if id, ok := languageIDs[name]; ok {
+		return id
+	}
+	return name
+}
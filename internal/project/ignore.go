@@ -0,0 +1,148 @@
+// Package project walks the files of a project directory, skipping what
+// git ignores, and searches their contents.
+package project
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern of a .gitignore file.
+type ignoreRule struct {
+	base     string // slash-separated directory of the .gitignore, "" for the root
+	pattern  string
+	negate   bool // "!pattern" re-includes a path
+	dirOnly  bool // "pattern/" only matches directories
+	anchored bool // a pattern with a slash is relative to base
+}
+
+// Ignore holds the .gitignore rules that apply to a directory.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+// readIgnoreFile returns the rules of a .gitignore-style file whose
+// patterns are relative to base. Missing files have no rules.
+func readIgnoreFile(file, base string) []ignoreRule {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseIgnoreRule parses one line of a .gitignore file.
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || line[0] == '#' {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if line[0] == '!' {
+		rule.negate = true
+		line = line[1:]
+	} else if line[0] == '\\' {
+		line = line[1:] // escaped leading '#' or '!'
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.pattern = line
+	return rule, true
+}
+
+// Enter returns the rules for the directory rel (slash-separated, relative
+// to the project root), adding those of its .gitignore file.
+func (ig *Ignore) Enter(root, rel string) *Ignore {
+	rules := readIgnoreFile(filepath.Join(root, filepath.FromSlash(rel), ".gitignore"), rel)
+	if rel == "" {
+		rules = append(readIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), ""), rules...)
+	}
+	if len(rules) == 0 {
+		return ig
+	}
+	next := &Ignore{rules: make([]ignoreRule, 0, len(ig.rules)+len(rules))}
+	next.rules = append(append(next.rules, ig.rules...), rules...)
+	return next
+}
+
+// Ignored reports whether the path rel (slash-separated, relative to the
+// project root) is ignored. As in git, the last matching rule wins.
+func (ig *Ignore) Ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		name := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			name = rel[len(rule.base)+1:]
+		}
+		if rule.anchored {
+			if !matchPath(rule.pattern, name) {
+				continue
+			}
+		} else if ok, _ := path.Match(rule.pattern, path.Base(name)); !ok {
+			continue
+		}
+		ignored = !rule.negate
+	}
+	return ignored
+}
+
+// matchPath matches a slash-separated path against a pattern in which
+// "**" stands for any number of directories.
+func matchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
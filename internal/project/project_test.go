@@ -0,0 +1,183 @@
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// writeTree creates files below a temporary directory and returns it.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestIgnored(t *testing.T) {
+	rules := []string{
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/root.txt",
+		"docs/**/*.tmp",
+		"\\#hash",
+		"# comment",
+		"",
+	}
+	ig := &Ignore{}
+	for _, line := range rules {
+		if rule, ok := parseIgnoreRule(line, ""); ok {
+			ig.rules = append(ig.rules, rule)
+		}
+	}
+	ig.rules = append(ig.rules, ignoreRule{base: "sub", pattern: "local.txt", anchored: true})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"deep/dir/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"src/build", true, true},
+		{"root.txt", false, true},
+		{"src/root.txt", false, false},
+		{"docs/a/b/x.tmp", false, true},
+		{"docs/x.tmp", false, true},
+		{"src/x.tmp", false, false},
+		{"#hash", false, true},
+		{"sub/local.txt", false, true},
+		{"local.txt", false, false},
+		{"sub/deeper/local.txt", false, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":           "*.out\nvendor/\n",
+		".git/config":          "",
+		".git/info/exclude":    "secret.txt\n",
+		"main.go":              "",
+		"app.out":              "",
+		"secret.txt":           "",
+		"vendor/lib.go":        "",
+		"pkg/.gitignore":       "gen.go\n!keep.out\n",
+		"pkg/gen.go":           "",
+		"pkg/lib.go":           "",
+		"pkg/keep.out":         "",
+		"other/gen.go":         "",
+		"other/nested/file.md": "",
+	})
+
+	var got []string
+	err := Walk(context.Background(), root, func(path string) error {
+		rel, _ := filepath.Rel(root, path)
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".gitignore", "main.go", "other/gen.go", "other/nested/file.md", "pkg/.gitignore", "pkg/keep.out", "pkg/lib.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() = %v, want %v", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Walk(ctx, root, func(string) error { return nil }); err == nil {
+		t.Error("Walk() with a cancelled context succeeded")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":   "ignored.txt\n",
+		"a.go":         "package a\n\nfunc helper() {}\r\nvar x = helper\n",
+		"b/c.go":       "// héllo helper\n",
+		"ignored.txt":  "helper\n",
+		"binary.bin":   "helper\x00\x01",
+		"nothing.go":   "package nothing\n",
+		"deep/d/e.txt": "helper helper\n",
+	})
+
+	search := func(query string, regex bool) []Match {
+		pattern, err := Compile(query, regex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := make(chan Match)
+		go func() {
+			err = Search(context.Background(), root, pattern, found)
+			close(found)
+		}()
+		var got []Match
+		for match := range found {
+			match.Path, _ = filepath.Rel(root, match.Path)
+			match.Path = filepath.ToSlash(match.Path)
+			got = append(got, match)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sort.Slice(got, func(i, j int) bool {
+			if got[i].Path != got[j].Path {
+				return got[i].Path < got[j].Path
+			}
+			return got[i].Line < got[j].Line
+		})
+		return got
+	}
+
+	want := []Match{
+		{"a.go", 2, 5, "func helper() {}"},
+		{"a.go", 3, 8, "var x = helper"},
+		{"b/c.go", 0, 9, "// héllo helper"},
+		{"deep/d/e.txt", 0, 0, "helper helper"},
+	}
+	if got := search("helper", false); !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %+v, want %+v", got, want)
+	}
+
+	want = []Match{
+		{"a.go", 2, 0, "func helper() {}"},
+		{"nothing.go", 0, 0, "package nothing"},
+	}
+	if got := search(`^(func|package) [hn]`, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() with a regexp = %+v, want %+v", got, want)
+	}
+	if got := search("help.r(", false); len(got) != 0 {
+		t.Errorf("Search() of literal text = %+v, want no matches", got)
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if IsBinary([]byte("plain text\n")) {
+		t.Error("text reported as binary")
+	}
+	if !IsBinary([]byte("\x7fELF\x00\x01")) {
+		t.Error("NUL bytes not reported as binary")
+	}
+}
@@ -0,0 +1,111 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// MaxFileSize is the size above which files are not searched.
+const MaxFileSize = 10 * 1024 * 1024
+
+// binaryCheckSize is how much of a file IsBinary looks at, as in git.
+const binaryCheckSize = 8000
+
+// Match is a line of a file matching the searched pattern.
+type Match struct {
+	Path string
+	Line int    // 0-based line
+	Col  int    // rune column of the first occurrence
+	Text string // the whole line
+}
+
+// Compile returns the pattern searching for query: the literal text, or a
+// regular expression (RE2 syntax) if regex is set.
+func Compile(query string, regex bool) (*regexp.Regexp, error) {
+	if !regex {
+		query = regexp.QuoteMeta(query)
+	}
+	return regexp.Compile(query)
+}
+
+// IsBinary reports whether data looks like binary content: like git, it
+// checks for a NUL byte near the start.
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binaryCheckSize)], 0) >= 0
+}
+
+// Search sends the lines of the files below root matching pattern to
+// found. Files are searched concurrently, so the matches of different files
+// interleave; the matches of one file arrive in line order. Binary files,
+// files larger than MaxFileSize and unreadable files are skipped. Search
+// returns once every file has been searched or ctx is done.
+func Search(ctx context.Context, root string, pattern *regexp.Regexp, found chan<- Match) error {
+	if pattern.String() == "" {
+		return nil
+	}
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				for _, match := range SearchFile(path, pattern) {
+					select {
+					case found <- match:
+					case <-ctx.Done():
+					}
+				}
+			}
+		}()
+	}
+
+	err := Walk(ctx, root, func(path string) error {
+		select {
+		case paths <- path:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(paths)
+	wg.Wait()
+	return err
+}
+
+// SearchFile returns the lines of a file matching pattern. Patterns are
+// matched within a line. Binary, large and unreadable files have no matches.
+func SearchFile(path string, pattern *regexp.Regexp) []Match {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > MaxFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || IsBinary(data) {
+		return nil
+	}
+	// Literal text is first looked for in the whole file, which is faster
+	if prefix, complete := pattern.LiteralPrefix(); complete && !bytes.Contains(data, []byte(prefix)) {
+		return nil
+	}
+
+	var matches []Match
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if loc := pattern.FindStringIndex(line); loc != nil {
+			matches = append(matches, Match{
+				Path: path,
+				Line: i,
+				Col:  utf8.RuneCountInString(line[:loc[0]]),
+				Text: line,
+			})
+		}
+	}
+	return matches
+}
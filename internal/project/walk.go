@@ -0,0 +1,63 @@
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// Walk calls fn with the path of every file below root that git would not
+// ignore, in lexical order. The .git directory and symbolic links to
+// directories are skipped. Walking stops when ctx is done or fn returns an
+// error, which Walk returns.
+func Walk(ctx context.Context, root string, fn func(path string) error) error {
+	return walkDir(ctx, root, "", (&Ignore{}).Enter(root, ""), fn)
+}
+
+func walkDir(ctx context.Context, root, rel string, ig *Ignore, fn func(path string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir := filepath.Join(root, filepath.FromSlash(rel))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if rel == "" {
+			return err
+		}
+		return nil // unreadable subdirectories are skipped
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == ".git" {
+			continue
+		}
+		childRel := name
+		if rel != "" {
+			childRel = rel + "/" + name
+		}
+		isDir := entry.IsDir()
+		if ig.Ignored(childRel, isDir) {
+			continue
+		}
+
+		if isDir {
+			if err := walkDir(ctx, root, childRel, ig.Enter(root, childRel), fn); err != nil {
+				return err
+			}
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+		} else if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := fn(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
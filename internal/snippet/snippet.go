@@ -0,0 +1,232 @@
+// Package snippet loads snippet definitions and expands snippet bodies
+// with tab-stop placeholders.
+//
+// Snippets are defined per language in YAML files named after the language
+// (e.g. go.yaml) inside the snippets directory; all.yaml applies to every
+// language. Each file maps a trigger prefix to a snippet:
+//
+//	iferr:
+//	  description: Return on error
+//	  body: |
+//	    if err != nil {
+//	    	return ${1:err}
+//	    }
+//
+// Bodies support $1 and ${1} tab stops, ${1:default} placeholders, repeated
+// numbers for mirrored placeholders and $0 for the final cursor position.
+// A backslash escapes "$", "}" and "\".
+package snippet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Snippet is a single snippet definition.
+type Snippet struct {
+	Prefix      string `yaml:"-"`
+	Description string `yaml:"description"`
+	Body        string `yaml:"body"`
+}
+
+// Field is one occurrence of a tab stop in an expanded snippet.
+type Field struct {
+	Stop  int // tab stop number, 0 for the final cursor position
+	Start int // rune offset in the expanded text
+	End   int // rune offset after the placeholder text
+}
+
+// Expansion is a parsed snippet body.
+type Expansion struct {
+	Text   string  // text with placeholders replaced by their defaults
+	Fields []Field // fields in text order
+}
+
+// Stops returns the tab stop numbers in navigation order: ascending,
+// with the final position (0) last.
+func (e *Expansion) Stops() []int {
+	seen := make(map[int]bool)
+	var stops []int
+	for _, f := range e.Fields {
+		if f.Stop != 0 && !seen[f.Stop] {
+			seen[f.Stop] = true
+			stops = append(stops, f.Stop)
+		}
+	}
+	sort.Ints(stops)
+	return append(stops, 0)
+}
+
+// segment is a piece of a parsed body: literal text or a tab stop.
+type segment struct {
+	text       string
+	stop       int // -1 for literal text
+	hasDefault bool
+}
+
+// Parse expands a snippet body. Every occurrence of a tab stop gets the
+// default text given at any of its occurrences. If the body has no $0,
+// the final position is the end of the text.
+func Parse(body string) (*Expansion, error) {
+	runes := []rune(body)
+	var segments []segment
+	var literal strings.Builder
+
+	flush := func() {
+		if literal.Len() > 0 {
+			segments = append(segments, segment{text: literal.String(), stop: -1})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\\' && i+1 < len(runes) && strings.ContainsRune(`$}\`, runes[i+1]) {
+			literal.WriteRune(runes[i+1])
+			i++
+			continue
+		}
+		if r != '$' || i+1 >= len(runes) {
+			literal.WriteRune(r)
+			continue
+		}
+
+		// $N
+		if isDigit(runes[i+1]) {
+			j := i + 1
+			for j < len(runes) && isDigit(runes[j]) {
+				j++
+			}
+			flush()
+			segments = append(segments, segment{stop: atoi(runes[i+1 : j])})
+			i = j - 1
+			continue
+		}
+
+		// ${N} or ${N:default}
+		if runes[i+1] == '{' && i+2 < len(runes) && isDigit(runes[i+2]) {
+			j := i + 2
+			for j < len(runes) && isDigit(runes[j]) {
+				j++
+			}
+			seg := segment{stop: atoi(runes[i+2 : j])}
+			if j < len(runes) && runes[j] == ':' {
+				var def strings.Builder
+				j++
+				for j < len(runes) && runes[j] != '}' {
+					if runes[j] == '\\' && j+1 < len(runes) && strings.ContainsRune(`$}\`, runes[j+1]) {
+						j++
+					}
+					def.WriteRune(runes[j])
+					j++
+				}
+				seg.text = def.String()
+				seg.hasDefault = true
+			}
+			if j >= len(runes) || runes[j] != '}' {
+				return nil, fmt.Errorf("unterminated placeholder ${%d", seg.stop)
+			}
+			flush()
+			segments = append(segments, seg)
+			i = j
+			continue
+		}
+
+		literal.WriteRune(r)
+	}
+	flush()
+
+	// The first default given for a stop applies to all of its occurrences
+	defaults := make(map[int]string)
+	for _, seg := range segments {
+		if seg.stop >= 0 && seg.hasDefault {
+			if _, ok := defaults[seg.stop]; !ok {
+				defaults[seg.stop] = seg.text
+			}
+		}
+	}
+
+	exp := &Expansion{}
+	var text strings.Builder
+	offset := 0
+	hasFinal := false
+	for _, seg := range segments {
+		if seg.stop < 0 {
+			text.WriteString(seg.text)
+			offset += len([]rune(seg.text))
+			continue
+		}
+		def := defaults[seg.stop]
+		exp.Fields = append(exp.Fields, Field{
+			Stop:  seg.stop,
+			Start: offset,
+			End:   offset + len([]rune(def)),
+		})
+		text.WriteString(def)
+		offset += len([]rune(def))
+		if seg.stop == 0 {
+			hasFinal = true
+		}
+	}
+	if !hasFinal {
+		exp.Fields = append(exp.Fields, Field{Stop: 0, Start: offset, End: offset})
+	}
+	exp.Text = text.String()
+	return exp, nil
+}
+
+// isDigit reports whether r is an ASCII digit.
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// atoi converts ASCII digits to an int.
+func atoi(digits []rune) int {
+	n := 0
+	for _, r := range digits {
+		n = n*10 + int(r-'0')
+	}
+	return n
+}
+
+// FileName returns the snippet file name for a language name
+// (e.g. "Go" -> "go.yaml").
+func FileName(language string) string {
+	return strings.ToLower(language) + ".yaml"
+}
+
+// Load reads the snippets for a language from dir: all.yaml followed by
+// the language file. Language snippets win over global ones with the
+// same prefix. Missing files are not an error.
+func Load(dir, language string) (map[string]Snippet, error) {
+	snippets := make(map[string]Snippet)
+	files := []string{"all.yaml"}
+	if language != "" {
+		files = append(files, FileName(language))
+	}
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		var defs map[string]Snippet
+		if err := yaml.Unmarshal(data, &defs); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for prefix, s := range defs {
+			s.Prefix = prefix
+			s.Body = strings.TrimSuffix(s.Body, "\n")
+			snippets[prefix] = s
+		}
+	}
+	return snippets, nil
+}
@@ -0,0 +1,110 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		text   string
+		fields []Field
+	}{
+		{
+			name:   "plain text",
+			body:   "hello",
+			text:   "hello",
+			fields: []Field{{Stop: 0, Start: 5, End: 5}},
+		},
+		{
+			name: "defaults and final position",
+			body: "for ${1:i} := 0; $1 < ${2:n}; $1++ {\n\t$0\n}",
+			text: "for i := 0; i < n; i++ {\n\t\n}",
+			fields: []Field{
+				{Stop: 1, Start: 4, End: 5},
+				{Stop: 1, Start: 12, End: 13},
+				{Stop: 2, Start: 16, End: 17},
+				{Stop: 1, Start: 19, End: 20},
+				{Stop: 0, Start: 26, End: 26},
+			},
+		},
+		{
+			name: "default given at a later occurrence",
+			body: "$1 ${1:x}",
+			text: "x x",
+			fields: []Field{
+				{Stop: 1, Start: 0, End: 1},
+				{Stop: 1, Start: 2, End: 3},
+				{Stop: 0, Start: 3, End: 3},
+			},
+		},
+		{
+			name:   "escapes and shell variables",
+			body:   `echo \$HOME $PATH ${1:a\}b}`,
+			text:   "echo $HOME $PATH a}b",
+			fields: []Field{{Stop: 1, Start: 17, End: 20}, {Stop: 0, Start: 20, End: 20}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, err := Parse(tt.body)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if exp.Text != tt.text {
+				t.Errorf("Text = %q, want %q", exp.Text, tt.text)
+			}
+			if !reflect.DeepEqual(exp.Fields, tt.fields) {
+				t.Errorf("Fields = %v, want %v", exp.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestParseUnterminated(t *testing.T) {
+	if _, err := Parse("if ${1:cond {"); err == nil {
+		t.Error("Parse() of unterminated placeholder returned no error")
+	}
+}
+
+func TestStops(t *testing.T) {
+	exp, err := Parse("$2 $1 $0 $2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := exp.Stops(), []int{1, 2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Stops() = %v, want %v", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	all := "todo:\n  body: \"TODO: $0\"\nlog:\n  body: print($1)\n"
+	goSnippets := "log:\n  description: Log a value\n  body: |\n    log.Println($1)\n"
+	if err := os.WriteFile(filepath.Join(dir, "all.yaml"), []byte(all), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.yaml"), []byte(goSnippets), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	snippets, err := Load(dir, "Go")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := snippets["log"].Body; got != "log.Println($1)" {
+		t.Errorf("log body = %q, want the Go snippet", got)
+	}
+	if got := snippets["todo"].Prefix; got != "todo" {
+		t.Errorf("todo prefix = %q, want %q", got, "todo")
+	}
+
+	if snippets, err := Load(dir, "Python"); err != nil || snippets["log"].Body != "print($1)" {
+		t.Errorf("Load(Python) log = %q, %v; want global snippet", snippets["log"].Body, err)
+	}
+}
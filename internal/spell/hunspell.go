@@ -0,0 +1,261 @@
+package spell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// affixRule is one PFX or SFX rule of a Hunspell affix file.
+type affixRule struct {
+	strip     string
+	add       string
+	condition *regexp.Regexp // nil matches any word
+}
+
+// affixClass is the set of rules sharing one affix flag.
+type affixClass struct {
+	prefix bool
+	cross  bool // may combine with affixes of the other kind
+	rules  []affixRule
+}
+
+// affixFile holds the parts of a Hunspell .aff file used for expansion.
+type affixFile struct {
+	flagType string // "", "long", "num" or "UTF-8"
+	latin1   bool   // SET ISO8859-1
+	classes  map[string]*affixClass
+}
+
+// loadHunspell adds the words of a Hunspell .dic file, expanded with the
+// prefix and suffix rules of the matching .aff file.
+func (c *Checker) loadHunspell(path string) error {
+	aff := &affixFile{classes: make(map[string]*affixClass)}
+	affPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".aff"
+	if err := aff.load(affPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	first := true
+	for scanner.Scan() {
+		line := aff.decode(scanner.Text())
+		if first {
+			first = false
+			// The first line holds the approximate word count
+			if _, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+				continue
+			}
+		}
+
+		// Morphological fields follow the word after white space
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			line = line[:i]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		word, flags := line, ""
+		if i := unescapedSlash(line); i >= 0 {
+			word, flags = line[:i], line[i+1:]
+		}
+		word = strings.ReplaceAll(word, `\/`, "/")
+		for _, form := range aff.expand(word, aff.parseFlags(flags)) {
+			c.words[form] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
+
+// unescapedSlash returns the index of the first "/" not preceded by "\".
+func unescapedSlash(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '/' {
+			return i
+		}
+	}
+	return -1
+}
+
+// load parses the affix file at path.
+func (a *affixFile) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(a.decode(scanner.Text()))
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "SET":
+			a.latin1 = strings.EqualFold(fields[1], "ISO8859-1")
+		case "FLAG":
+			a.flagType = fields[1]
+		case "PFX", "SFX":
+			if err := a.parseAffixLine(fields); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// parseAffixLine handles a PFX/SFX header ("SFX D Y 4") or rule
+// ("SFX D y ied [^aeiou]y").
+func (a *affixFile) parseAffixLine(fields []string) error {
+	flag := fields[1]
+	class, ok := a.classes[flag]
+	if !ok {
+		if len(fields) < 4 {
+			return fmt.Errorf("malformed affix header %q", strings.Join(fields, " "))
+		}
+		a.classes[flag] = &affixClass{
+			prefix: fields[0] == "PFX",
+			cross:  fields[2] == "Y",
+		}
+		return nil
+	}
+	if len(fields) < 4 {
+		return fmt.Errorf("malformed affix rule %q", strings.Join(fields, " "))
+	}
+
+	rule := affixRule{strip: fields[2], add: fields[3]}
+	if rule.strip == "0" {
+		rule.strip = ""
+	}
+	// Continuation flags on the affix are not supported
+	if i := strings.IndexByte(rule.add, '/'); i >= 0 {
+		rule.add = rule.add[:i]
+	}
+	if rule.add == "0" {
+		rule.add = ""
+	}
+	if len(fields) > 4 && fields[4] != "." {
+		pattern := fields[4] + "$"
+		if class.prefix {
+			pattern = "^" + fields[4]
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("bad affix condition %q: %w", fields[4], err)
+		}
+		rule.condition = re
+	}
+	class.rules = append(class.rules, rule)
+	return nil
+}
+
+// decode converts a Latin-1 line to UTF-8 when the affix file asks for it.
+func (a *affixFile) decode(line string) string {
+	if !a.latin1 {
+		return line
+	}
+	runes := make([]rune, len(line))
+	for i := 0; i < len(line); i++ {
+		runes[i] = rune(line[i])
+	}
+	return string(runes)
+}
+
+// parseFlags splits the flags of a dictionary word according to FLAG.
+func (a *affixFile) parseFlags(flags string) []string {
+	if flags == "" {
+		return nil
+	}
+	var result []string
+	switch a.flagType {
+	case "long":
+		for i := 0; i+1 < len(flags); i += 2 {
+			result = append(result, flags[i:i+2])
+		}
+	case "num":
+		result = strings.Split(flags, ",")
+	default:
+		for _, r := range flags {
+			result = append(result, string(r))
+		}
+	}
+	return result
+}
+
+// expand returns word and all forms produced by its affix flags. Prefixes
+// and suffixes that allow cross products are combined.
+func (a *affixFile) expand(word string, flags []string) []string {
+	forms := []string{word}
+	var crossSuffixed []string
+	for _, flag := range flags {
+		class, ok := a.classes[flag]
+		if !ok || class.prefix {
+			continue
+		}
+		for _, rule := range class.rules {
+			if form, ok := rule.applySuffix(word); ok {
+				forms = append(forms, form)
+				if class.cross {
+					crossSuffixed = append(crossSuffixed, form)
+				}
+			}
+		}
+	}
+
+	for _, flag := range flags {
+		class, ok := a.classes[flag]
+		if !ok || !class.prefix {
+			continue
+		}
+		bases := []string{word}
+		if class.cross {
+			bases = append(bases, crossSuffixed...)
+		}
+		for _, rule := range class.rules {
+			for _, base := range bases {
+				if form, ok := rule.applyPrefix(base); ok {
+					forms = append(forms, form)
+				}
+			}
+		}
+	}
+	return forms
+}
+
+// applySuffix applies a suffix rule to word if its condition matches.
+func (r affixRule) applySuffix(word string) (string, bool) {
+	if r.condition != nil && !r.condition.MatchString(word) {
+		return "", false
+	}
+	if !strings.HasSuffix(word, r.strip) {
+		return "", false
+	}
+	return strings.TrimSuffix(word, r.strip) + r.add, true
+}
+
+// applyPrefix applies a prefix rule to word if its condition matches.
+func (r affixRule) applyPrefix(word string) (string, bool) {
+	if r.condition != nil && !r.condition.MatchString(word) {
+		return "", false
+	}
+	if !strings.HasPrefix(word, r.strip) {
+		return "", false
+	}
+	return r.add + strings.TrimPrefix(word, r.strip), true
+}
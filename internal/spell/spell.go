@@ -0,0 +1,270 @@
+// Package spell provides an offline spell checker using Hunspell
+// dictionaries or plain word lists.
+package spell
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Checker checks words against a dictionary, a personal word list and
+// words ignored for the session.
+type Checker struct {
+	words        map[string]struct{}
+	personal     map[string]struct{}
+	ignored      map[string]struct{}
+	personalPath string
+}
+
+// New creates an empty checker.
+func New() *Checker {
+	return &Checker{
+		words:    make(map[string]struct{}),
+		personal: make(map[string]struct{}),
+		ignored:  make(map[string]struct{}),
+	}
+}
+
+// WordCount returns the number of dictionary words (including forms
+// generated from affix rules).
+func (c *Checker) WordCount() int {
+	return len(c.words)
+}
+
+// LoadDictionary adds the words of a dictionary file. Files ending in .dic
+// are read as Hunspell dictionaries, using the .aff file next to them if
+// present; anything else is read as a word list with one word per line.
+func (c *Checker) LoadDictionary(path string) error {
+	if strings.EqualFold(filepath.Ext(path), ".dic") {
+		return c.loadHunspell(path)
+	}
+	return loadWordList(path, c.words)
+}
+
+// LoadPersonal loads the personal dictionary, a word list that AddPersonal
+// appends to. A missing file is not an error.
+func (c *Checker) LoadPersonal(path string) error {
+	c.personalPath = path
+	err := loadWordList(path, c.personal)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// AddPersonal adds word to the personal dictionary file.
+func (c *Checker) AddPersonal(word string) error {
+	c.personal[word] = struct{}{}
+	if c.personalPath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.personalPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(c.personalPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(word + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Ignore accepts word for the rest of the session.
+func (c *Checker) Ignore(word string) {
+	c.ignored[word] = struct{}{}
+}
+
+// Check reports whether word is spelled correctly. Capitalized and
+// upper-case forms of dictionary words are accepted.
+func (c *Checker) Check(word string) bool {
+	if c.known(word) {
+		return true
+	}
+	lower := strings.ToLower(word)
+	if lower == word {
+		return false
+	}
+	if word == strings.ToUpper(word) || word == capitalize(lower) {
+		return c.known(lower)
+	}
+	return false
+}
+
+// known reports whether word is in one of the word sets as is.
+func (c *Checker) known(word string) bool {
+	if _, ok := c.words[word]; ok {
+		return true
+	}
+	if _, ok := c.personal[word]; ok {
+		return true
+	}
+	_, ok := c.ignored[word]
+	return ok
+}
+
+// Suggest returns up to limit dictionary words within edit distance 2 of
+// word, closest first. Suggestions follow the capitalization of word.
+func (c *Checker) Suggest(word string, limit int) []string {
+	lower := strings.ToLower(word)
+	length := utf8.RuneCountInString(lower)
+
+	type candidate struct {
+		word     string
+		distance int
+	}
+	var candidates []candidate
+	seen := make(map[string]bool)
+	for _, set := range []map[string]struct{}{c.words, c.personal} {
+		for w := range set {
+			lw := strings.ToLower(w)
+			if seen[lw] || abs(utf8.RuneCountInString(lw)-length) > 2 {
+				continue
+			}
+			if d := distance(lower, lw); d <= 2 && lw != lower {
+				seen[lw] = true
+				candidates = append(candidates, candidate{w, d})
+			}
+		}
+	}
+
+	first, _ := utf8.DecodeRuneInString(lower)
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		// Prefer words with the same first letter
+		fa, _ := utf8.DecodeRuneInString(strings.ToLower(a.word))
+		fb, _ := utf8.DecodeRuneInString(strings.ToLower(b.word))
+		if (fa == first) != (fb == first) {
+			return fa == first
+		}
+		return a.word < b.word
+	})
+
+	var suggestions []string
+	for _, cand := range candidates {
+		if len(suggestions) == limit {
+			break
+		}
+		s := cand.word
+		switch {
+		case word == strings.ToUpper(word) && utf8.RuneCountInString(word) > 1:
+			s = strings.ToUpper(s)
+		case word == capitalize(lower):
+			s = capitalize(s)
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions
+}
+
+// distance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and transpositions of
+// adjacent letters needed to turn a into b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// loadWordList adds every non-empty line of the file at path to words.
+// Lines starting with # are comments.
+func loadWordList(path string, words map[string]struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words[word] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
+
+// FindDictionary locates a dictionary by name (e.g. "en_US") or path.
+// Names are looked up as name.dic in dirs; if nothing is found the system
+// word list is used. Returns "" if no dictionary exists.
+func FindDictionary(name string, dirs []string) string {
+	if name != "" && (strings.ContainsRune(name, os.PathSeparator) || filepath.Ext(name) != "") {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+		return ""
+	}
+	if name != "" {
+		for _, dir := range dirs {
+			path := filepath.Join(dir, name+".dic")
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+	for _, path := range []string{"/usr/share/dict/words", "/usr/dict/words"} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// SystemDictionaryDirs returns the directories searched for Hunspell
+// dictionaries after the user's own dictionary directory.
+func SystemDictionaryDirs() []string {
+	return []string{
+		"/usr/share/hunspell",
+		"/usr/share/myspell",
+		"/usr/share/myspell/dicts",
+		"/usr/local/share/hunspell",
+		"/Library/Spelling",
+	}
+}
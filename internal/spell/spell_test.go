@@ -0,0 +1,119 @@
+package spell
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFile creates a file with content in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHunspellDictionary(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "test.aff", `SET UTF-8
+PFX U Y 1
+PFX U 0 un .
+
+SFX D Y 3
+SFX D 0 d e
+SFX D y ied [^aeiou]y
+SFX D 0 ed [^ey]
+`)
+	dic := writeFile(t, dir, "test.dic", "4\ntry/D\nlock/DU\nbake/D\nParis\n")
+
+	c := New()
+	if err := c.LoadDictionary(dic); err != nil {
+		t.Fatalf("LoadDictionary() error = %v", err)
+	}
+
+	for _, word := range []string{"try", "tried", "locked", "unlocked", "unlock", "baked", "Paris", "Tried", "LOCKED"} {
+		if !c.Check(word) {
+			t.Errorf("Check(%q) = false, want true", word)
+		}
+	}
+	for _, word := range []string{"tryed", "bakeed", "paris", "unbake", "lOcked"} {
+		if c.Check(word) {
+			t.Errorf("Check(%q) = true, want false", word)
+		}
+	}
+}
+
+func TestWordListAndPersonal(t *testing.T) {
+	dir := t.TempDir()
+	list := writeFile(t, dir, "words", "# comment\nhello\nworld\nword\n")
+	personal := filepath.Join(dir, "sub", "personal.txt")
+
+	c := New()
+	if err := c.LoadDictionary(list); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadPersonal(personal); err != nil {
+		t.Fatalf("LoadPersonal() of missing file error = %v", err)
+	}
+	if c.Check("gesh") {
+		t.Fatal("Check(gesh) = true before adding it")
+	}
+	if err := c.AddPersonal("gesh"); err != nil {
+		t.Fatalf("AddPersonal() error = %v", err)
+	}
+
+	reloaded := New()
+	if err := reloaded.LoadPersonal(personal); err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Check("gesh") {
+		t.Error("personal word not persisted")
+	}
+
+	c.Ignore("zzz")
+	if !c.Check("zzz") {
+		t.Error("ignored word reported as misspelled")
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	dir := t.TempDir()
+	list := writeFile(t, dir, "words", "hello\nhelp\nhell\nworld\nword\nwould\n")
+	c := New()
+	if err := c.LoadDictionary(list); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"helo", []string{"hell", "hello", "help"}},
+		{"Wrold", []string{"World", "Word", "Would"}},
+		{"xyzzy", nil},
+	}
+	for _, tt := range tests {
+		if got := c.Suggest(tt.word, 3); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Suggest(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	got := Words("Don't parseHTTP the HTML x v2 snake_case it's fine")
+	var texts []string
+	for _, w := range got {
+		texts = append(texts, w.Text)
+	}
+	want := []string{"Don't", "the", "it's", "fine"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("Words() = %q, want %q", texts, want)
+	}
+	if got[1].Start != 16 || got[1].End != 19 {
+		t.Errorf("Words()[1] columns = %d..%d, want 16..19", got[1].Start, got[1].End)
+	}
+}
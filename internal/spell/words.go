@@ -0,0 +1,60 @@
+package spell
+
+import (
+	"unicode"
+)
+
+// Word is a word found in a line of text.
+type Word struct {
+	Text       string
+	Start, End int // rune columns
+}
+
+// Words returns the words of line worth checking. Words are runs of letters
+// with inner apostrophes. Runs attached to digits or underscores, mixed-case
+// identifiers (camelCase), all-caps acronyms and single letters are skipped.
+func Words(line string) []Word {
+	runes := []rune(line)
+	var words []Word
+	for i := 0; i < len(runes); {
+		if !isWordChar(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && (isWordChar(runes[i]) ||
+			runes[i] == '\'' && i+1 < len(runes) && unicode.IsLetter(runes[i+1]) && i > start) {
+			i++
+		}
+		if word := runes[start:i]; shouldCheck(word) {
+			words = append(words, Word{Text: string(word), Start: start, End: i})
+		}
+	}
+	return words
+}
+
+// isWordChar reports whether r belongs to a word-like run.
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// shouldCheck reports whether a run of word characters is a plain word.
+func shouldCheck(word []rune) bool {
+	if len(word) < 2 {
+		return false
+	}
+	upper := 0
+	for i, r := range word {
+		switch {
+		case unicode.IsDigit(r) || r == '_':
+			return false
+		case unicode.IsUpper(r):
+			upper++
+			// An upper-case letter after a lower-case one: camelCase
+			if i > 0 && unicode.IsLower(word[i-1]) {
+				return false
+			}
+		}
+	}
+	return upper < len(word)
+}
@@ -37,11 +37,49 @@ type Rule struct {
 	Pattern *regexp.Regexp
 }
 
+// BracketPair describes an opening and closing delimiter matched by the editor.
+type BracketPair struct {
+	Open  string
+	Close string
+}
+
 // Language represents a programming language syntax definition.
 type Language struct {
 	Name       string
 	Extensions []string
 	Rules      []Rule
+
+	// Comment syntax
+	LineComment  string      // line comment prefix (e.g. "//"), empty if none
+	BlockComment BracketPair // block comment delimiters (e.g. "/*" and "*/")
+
+	// Bracket matching
+	WordPairs []BracketPair // keyword pairs delimiting blocks (e.g. begin/end)
+	MatchTags bool          // match markup tags such as <div> and </div>
+
+	// Auto-pairing of brackets and quotes (nil = DefaultAutoPairs)
+	AutoPairs []BracketPair
+
+	// Prose is set for text formats whose whole content is spell checked;
+	// in other languages only comments and strings are.
+	Prose bool
+}
+
+// DefaultAutoPairs are auto-closed for languages without their own list.
+var DefaultAutoPairs = []BracketPair{
+	{Open: "(", Close: ")"},
+	{Open: "[", Close: "]"},
+	{Open: "{", Close: "}"},
+	{Open: "\"", Close: "\""},
+	{Open: "'", Close: "'"},
+}
+
+// AutoClosePairs returns the pairs auto-closed while typing in this language.
+func (l *Language) AutoClosePairs() []BracketPair {
+	if l == nil || l.AutoPairs == nil {
+		return DefaultAutoPairs
+	}
+	return l.AutoPairs
 }
 
 // Highlighter provides syntax highlighting for source code.
@@ -13,8 +13,10 @@ func init() {
 
 // CLang defines syntax highlighting rules for C.
 var CLang = &syntax.Language{
-	Name:       "C",
-	Extensions: []string{".c", ".h"},
+	Name:         "C",
+	Extensions:   []string{".c", ".h"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
@@ -45,8 +47,10 @@ var CLang = &syntax.Language{
 
 // CppLang defines syntax highlighting rules for C++.
 var CppLang = &syntax.Language{
-	Name:       "C++",
-	Extensions: []string{".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx"},
+	Name:         "C++",
+	Extensions:   []string{".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
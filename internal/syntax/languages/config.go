@@ -18,8 +18,9 @@ func init() {
 
 // TOMLLang defines syntax highlighting rules for TOML.
 var TOMLLang = &syntax.Language{
-	Name:       "TOML",
-	Extensions: []string{".toml"},
+	Name:        "TOML",
+	Extensions:  []string{".toml"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenKeyword, Pattern: regexp.MustCompile(`^\s*\[[^\]]+\]`)},
@@ -39,8 +40,9 @@ var TOMLLang = &syntax.Language{
 
 // INILang defines syntax highlighting rules for INI files.
 var INILang = &syntax.Language{
-	Name:       "INI",
-	Extensions: []string{".ini", ".cfg", ".conf", ".properties"},
+	Name:        "INI",
+	Extensions:  []string{".ini", ".cfg", ".conf", ".properties"},
+	LineComment: ";",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`[;#].*$`)},
 		{Type: syntax.TokenKeyword, Pattern: regexp.MustCompile(`^\s*\[[^\]]+\]`)},
@@ -54,8 +56,10 @@ var INILang = &syntax.Language{
 
 // XMLLang defines syntax highlighting rules for XML.
 var XMLLang = &syntax.Language{
-	Name:       "XML",
-	Extensions: []string{".xml", ".xsl", ".xslt", ".xsd", ".svg", ".plist", ".rss", ".atom"},
+	Name:         "XML",
+	Extensions:   []string{".xml", ".xsl", ".xslt", ".xsd", ".svg", ".plist", ".rss", ".atom"},
+	BlockComment: syntax.BracketPair{Open: "<!--", Close: "-->"},
+	MatchTags:    true,
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`<!--[\s\S]*?-->`)},
 		{Type: syntax.TokenBuiltin, Pattern: regexp.MustCompile(`<\?[\s\S]*?\?>`)},
@@ -74,8 +78,9 @@ var XMLLang = &syntax.Language{
 
 // DockerfileLang defines syntax highlighting rules for Dockerfile.
 var DockerfileLang = &syntax.Language{
-	Name:       "Dockerfile",
-	Extensions: []string{".dockerfile", "Dockerfile"},
+	Name:        "Dockerfile",
+	Extensions:  []string{".dockerfile", "Dockerfile"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenKeyword, Pattern: regexp.MustCompile(`(?i)^(FROM|RUN|CMD|LABEL|MAINTAINER|EXPOSE|ENV|ADD|COPY|ENTRYPOINT|VOLUME|USER|WORKDIR|ARG|ONBUILD|STOPSIGNAL|HEALTHCHECK|SHELL)\b`)},
@@ -88,8 +93,9 @@ var DockerfileLang = &syntax.Language{
 
 // MakefileLang defines syntax highlighting rules for Makefile.
 var MakefileLang = &syntax.Language{
-	Name:       "Makefile",
-	Extensions: []string{".mk", "Makefile", "makefile", "GNUmakefile"},
+	Name:        "Makefile",
+	Extensions:  []string{".mk", "Makefile", "makefile", "GNUmakefile"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenKeyword, Pattern: regexp.MustCompile(`^\.\w+:`)},
@@ -105,8 +111,9 @@ var MakefileLang = &syntax.Language{
 
 // EnvLang defines syntax highlighting rules for .env files.
 var EnvLang = &syntax.Language{
-	Name:       "Env",
-	Extensions: []string{".env", ".env.local", ".env.development", ".env.production", ".env.test"},
+	Name:        "Env",
+	Extensions:  []string{".env", ".env.local", ".env.development", ".env.production", ".env.test"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenVariable, Pattern: regexp.MustCompile(`^[\w]+\s*=`)},
@@ -119,8 +126,9 @@ var EnvLang = &syntax.Language{
 
 // NginxLang defines syntax highlighting rules for Nginx config.
 var NginxLang = &syntax.Language{
-	Name:       "Nginx",
-	Extensions: []string{".nginx", "nginx.conf"},
+	Name:        "Nginx",
+	Extensions:  []string{".nginx", "nginx.conf"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenKeyword, Pattern: regexp.MustCompile(`\b(server|location|upstream|http|events|stream|mail|types|map|geo|split_clients|if|set|rewrite|return|break|include|root|index|alias|try_files|error_page|access_log|error_log|proxy_pass|fastcgi_pass|uwsgi_pass|scgi_pass|memcached_pass|listen|server_name|ssl_certificate|ssl_certificate_key|ssl_protocols|ssl_ciphers|gzip|gzip_types|add_header|expires|deny|allow|auth_basic|auth_basic_user_file|limit_req|limit_conn|worker_processes|worker_connections|use|multi_accept|sendfile|tcp_nopush|tcp_nodelay|keepalive_timeout|client_max_body_size|proxy_set_header|proxy_read_timeout|proxy_connect_timeout|proxy_buffer_size|proxy_buffers|fastcgi_param|uwsgi_param)\b`)},
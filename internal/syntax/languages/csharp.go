@@ -13,8 +13,10 @@ func init() {
 
 // CSharpLang defines syntax highlighting rules for C#.
 var CSharpLang = &syntax.Language{
-	Name:       "C#",
-	Extensions: []string{".cs", ".csx"},
+	Name:         "C#",
+	Extensions:   []string{".cs", ".csx"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -35,8 +37,10 @@ var CSharpLang = &syntax.Language{
 
 // FSharpLang defines syntax highlighting rules for F#.
 var FSharpLang = &syntax.Language{
-	Name:       "F#",
-	Extensions: []string{".fs", ".fsx", ".fsi"},
+	Name:         "F#",
+	Extensions:   []string{".fs", ".fsx", ".fsi"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "(*", Close: "*)"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`\(\*[\s\S]*?\*\)`)},
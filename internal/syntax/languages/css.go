@@ -13,8 +13,9 @@ func init() {
 
 // CSSLang defines syntax highlighting rules for CSS.
 var CSSLang = &syntax.Language{
-	Name:       "CSS",
-	Extensions: []string{".css"},
+	Name:         "CSS",
+	Extensions:   []string{".css"},
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -52,8 +53,10 @@ var CSSLang = &syntax.Language{
 
 // SCSSLang defines syntax highlighting rules for SCSS/Sass.
 var SCSSLang = &syntax.Language{
-	Name:       "SCSS",
-	Extensions: []string{".scss", ".sass"},
+	Name:         "SCSS",
+	Extensions:   []string{".scss", ".sass"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
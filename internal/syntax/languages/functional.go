@@ -16,8 +16,16 @@ func init() {
 
 // HaskellLang defines syntax highlighting rules for Haskell.
 var HaskellLang = &syntax.Language{
-	Name:       "Haskell",
-	Extensions: []string{".hs", ".lhs"},
+	Name:         "Haskell",
+	Extensions:   []string{".hs", ".lhs"},
+	LineComment:  "--",
+	BlockComment: syntax.BracketPair{Open: "{-", Close: "-}"},
+	AutoPairs: []syntax.BracketPair{
+		{Open: "(", Close: ")"},
+		{Open: "[", Close: "]"},
+		{Open: "{", Close: "}"},
+		{Open: "\"", Close: "\""},
+	},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`--.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`\{-[\s\S]*?-\}`)},
@@ -35,8 +43,9 @@ var HaskellLang = &syntax.Language{
 
 // ElixirLang defines syntax highlighting rules for Elixir.
 var ElixirLang = &syntax.Language{
-	Name:       "Elixir",
-	Extensions: []string{".ex", ".exs"},
+	Name:        "Elixir",
+	Extensions:  []string{".ex", ".exs"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile(`"""[\s\S]*?"""`)},
@@ -57,8 +66,9 @@ var ElixirLang = &syntax.Language{
 
 // ErlangLang defines syntax highlighting rules for Erlang.
 var ErlangLang = &syntax.Language{
-	Name:       "Erlang",
-	Extensions: []string{".erl", ".hrl"},
+	Name:        "Erlang",
+	Extensions:  []string{".erl", ".hrl"},
+	LineComment: "%",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`%.*$`)},
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)},
@@ -75,8 +85,15 @@ var ErlangLang = &syntax.Language{
 
 // ClojureLang defines syntax highlighting rules for Clojure.
 var ClojureLang = &syntax.Language{
-	Name:       "Clojure",
-	Extensions: []string{".clj", ".cljs", ".cljc", ".edn"},
+	Name:        "Clojure",
+	Extensions:  []string{".clj", ".cljs", ".cljc", ".edn"},
+	LineComment: ";",
+	AutoPairs: []syntax.BracketPair{
+		{Open: "(", Close: ")"},
+		{Open: "[", Close: "]"},
+		{Open: "{", Close: "}"},
+		{Open: "\"", Close: "\""},
+	},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`;.*$`)},
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)},
@@ -91,8 +108,15 @@ var ClojureLang = &syntax.Language{
 
 // OCamlLang defines syntax highlighting rules for OCaml.
 var OCamlLang = &syntax.Language{
-	Name:       "OCaml",
-	Extensions: []string{".ml", ".mli"},
+	Name:         "OCaml",
+	Extensions:   []string{".ml", ".mli"},
+	BlockComment: syntax.BracketPair{Open: "(*", Close: "*)"},
+	AutoPairs: []syntax.BracketPair{
+		{Open: "(", Close: ")"},
+		{Open: "[", Close: "]"},
+		{Open: "{", Close: "}"},
+		{Open: "\"", Close: "\""},
+	},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`\(\*[\s\S]*?\*\)`)},
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)},
@@ -12,8 +12,13 @@ func init() {
 
 // GoLang defines syntax highlighting rules for Go.
 var GoLang = &syntax.Language{
-	Name:       "Go",
-	Extensions: []string{".go"},
+	Name:         "Go",
+	Extensions:   []string{".go"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
+	AutoPairs: append([]syntax.BracketPair{
+		{Open: "`", Close: "`"},
+	}, syntax.DefaultAutoPairs...),
 	Rules: []syntax.Rule{
 		// Comments (must come first to take precedence)
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
@@ -12,8 +12,10 @@ func init() {
 
 // HTMLLang defines syntax highlighting rules for HTML.
 var HTMLLang = &syntax.Language{
-	Name:       "HTML",
-	Extensions: []string{".html", ".htm", ".xhtml"},
+	Name:         "HTML",
+	Extensions:   []string{".html", ".htm", ".xhtml"},
+	BlockComment: syntax.BracketPair{Open: "<!--", Close: "-->"},
+	MatchTags:    true,
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`<!--[\s\S]*?-->`)},
@@ -15,8 +15,10 @@ func init() {
 
 // JavaLang defines syntax highlighting rules for Java.
 var JavaLang = &syntax.Language{
-	Name:       "Java",
-	Extensions: []string{".java"},
+	Name:         "Java",
+	Extensions:   []string{".java"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -35,8 +37,10 @@ var JavaLang = &syntax.Language{
 
 // KotlinLang defines syntax highlighting rules for Kotlin.
 var KotlinLang = &syntax.Language{
-	Name:       "Kotlin",
-	Extensions: []string{".kt", ".kts"},
+	Name:         "Kotlin",
+	Extensions:   []string{".kt", ".kts"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -55,8 +59,10 @@ var KotlinLang = &syntax.Language{
 
 // ScalaLang defines syntax highlighting rules for Scala.
 var ScalaLang = &syntax.Language{
-	Name:       "Scala",
-	Extensions: []string{".scala", ".sc"},
+	Name:         "Scala",
+	Extensions:   []string{".scala", ".sc"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -74,8 +80,10 @@ var ScalaLang = &syntax.Language{
 
 // GroovyLang defines syntax highlighting rules for Groovy.
 var GroovyLang = &syntax.Language{
-	Name:       "Groovy",
-	Extensions: []string{".groovy", ".gradle"},
+	Name:         "Groovy",
+	Extensions:   []string{".groovy", ".gradle"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -13,8 +13,13 @@ func init() {
 
 // JavaScriptLang defines syntax highlighting rules for JavaScript.
 var JavaScriptLang = &syntax.Language{
-	Name:       "JavaScript",
-	Extensions: []string{".js", ".jsx", ".mjs", ".cjs"},
+	Name:         "JavaScript",
+	Extensions:   []string{".js", ".jsx", ".mjs", ".cjs"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
+	AutoPairs: append([]syntax.BracketPair{
+		{Open: "`", Close: "`"},
+	}, syntax.DefaultAutoPairs...),
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
@@ -47,8 +52,13 @@ var JavaScriptLang = &syntax.Language{
 
 // TypeScriptLang defines syntax highlighting rules for TypeScript.
 var TypeScriptLang = &syntax.Language{
-	Name:       "TypeScript",
-	Extensions: []string{".ts", ".tsx"},
+	Name:         "TypeScript",
+	Extensions:   []string{".ts", ".tsx"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
+	AutoPairs: append([]syntax.BracketPair{
+		{Open: "`", Close: "`"},
+	}, syntax.DefaultAutoPairs...),
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
@@ -13,8 +13,10 @@ func init() {
 
 // LuaLang defines syntax highlighting rules for Lua.
 var LuaLang = &syntax.Language{
-	Name:       "Lua",
-	Extensions: []string{".lua"},
+	Name:         "Lua",
+	Extensions:   []string{".lua"},
+	LineComment:  "--",
+	BlockComment: syntax.BracketPair{Open: "--[[", Close: "]]"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`--\[\[[\s\S]*?\]\]`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`--.*$`)},
@@ -33,8 +35,9 @@ var LuaLang = &syntax.Language{
 
 // RLang defines syntax highlighting rules for R.
 var RLang = &syntax.Language{
-	Name:       "R",
-	Extensions: []string{".r", ".R", ".rmd", ".Rmd"},
+	Name:        "R",
+	Extensions:  []string{".r", ".R", ".rmd", ".Rmd"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)},
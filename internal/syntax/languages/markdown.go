@@ -12,8 +12,13 @@ func init() {
 
 // MarkdownLang defines syntax highlighting rules for Markdown.
 var MarkdownLang = &syntax.Language{
-	Name:       "Markdown",
-	Extensions: []string{".md", ".markdown", ".mkd"},
+	Name:         "Markdown",
+	Extensions:   []string{".md", ".markdown", ".mkd"},
+	BlockComment: syntax.BracketPair{Open: "<!--", Close: "-->"},
+	Prose:        true,
+	AutoPairs: append([]syntax.BracketPair{
+		{Open: "`", Close: "`"},
+	}, syntax.DefaultAutoPairs...),
 	Rules: []syntax.Rule{
 		// Code blocks (inline)
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile("`[^`]+`")},
@@ -16,8 +16,13 @@ func init() {
 
 // LaTeXLang defines syntax highlighting rules for LaTeX.
 var LaTeXLang = &syntax.Language{
-	Name:       "LaTeX",
-	Extensions: []string{".tex", ".latex", ".ltx", ".sty", ".cls"},
+	Name:        "LaTeX",
+	Extensions:  []string{".tex", ".latex", ".ltx", ".sty", ".cls"},
+	LineComment: "%",
+	Prose:       true,
+	WordPairs: []syntax.BracketPair{
+		{Open: "begin", Close: "end"},
+	},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`%.*$`)},
 		{Type: syntax.TokenKeyword, Pattern: regexp.MustCompile(`\\[a-zA-Z@]+\*?`)},
@@ -60,8 +65,10 @@ var RegexLang = &syntax.Language{
 
 // TerraformLang defines syntax highlighting rules for Terraform (HCL).
 var TerraformLang = &syntax.Language{
-	Name:       "Terraform",
-	Extensions: []string{".tf", ".tfvars", ".hcl"},
+	Name:         "Terraform",
+	Extensions:   []string{".tf", ".tfvars", ".hcl"},
+	LineComment:  "#",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
@@ -82,8 +89,10 @@ var TerraformLang = &syntax.Language{
 
 // ProtobufLang defines syntax highlighting rules for Protocol Buffers.
 var ProtobufLang = &syntax.Language{
-	Name:       "Protobuf",
-	Extensions: []string{".proto"},
+	Name:         "Protobuf",
+	Extensions:   []string{".proto"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
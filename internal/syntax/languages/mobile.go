@@ -14,8 +14,10 @@ func init() {
 
 // SwiftLang defines syntax highlighting rules for Swift.
 var SwiftLang = &syntax.Language{
-	Name:       "Swift",
-	Extensions: []string{".swift"},
+	Name:         "Swift",
+	Extensions:   []string{".swift"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -35,8 +37,10 @@ var SwiftLang = &syntax.Language{
 
 // ObjectiveCLang defines syntax highlighting rules for Objective-C.
 var ObjectiveCLang = &syntax.Language{
-	Name:       "Objective-C",
-	Extensions: []string{".m", ".mm"},
+	Name:         "Objective-C",
+	Extensions:   []string{".m", ".mm"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -55,8 +59,10 @@ var ObjectiveCLang = &syntax.Language{
 
 // DartLang defines syntax highlighting rules for Dart.
 var DartLang = &syntax.Language{
-	Name:       "Dart",
-	Extensions: []string{".dart"},
+	Name:         "Dart",
+	Extensions:   []string{".dart"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
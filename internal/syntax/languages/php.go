@@ -12,8 +12,10 @@ func init() {
 
 // PHPLang defines syntax highlighting rules for PHP.
 var PHPLang = &syntax.Language{
-	Name:       "PHP",
-	Extensions: []string{".php", ".phtml", ".php3", ".php4", ".php5", ".php7", ".phps"},
+	Name:         "PHP",
+	Extensions:   []string{".php", ".phtml", ".php3", ".php4", ".php5", ".php7", ".phps"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
@@ -12,8 +12,9 @@ func init() {
 
 // PythonLang defines syntax highlighting rules for Python.
 var PythonLang = &syntax.Language{
-	Name:       "Python",
-	Extensions: []string{".py", ".pyw", ".pyi"},
+	Name:        "Python",
+	Extensions:  []string{".py", ".pyw", ".pyi"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
@@ -13,8 +13,10 @@ func init() {
 
 // SQLLang defines syntax highlighting rules for SQL.
 var SQLLang = &syntax.Language{
-	Name:       "SQL",
-	Extensions: []string{".sql", ".mysql", ".pgsql", ".sqlite"},
+	Name:         "SQL",
+	Extensions:   []string{".sql", ".mysql", ".pgsql", ".sqlite"},
+	LineComment:  "--",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`--.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -32,8 +34,9 @@ var SQLLang = &syntax.Language{
 
 // GraphQLLang defines syntax highlighting rules for GraphQL.
 var GraphQLLang = &syntax.Language{
-	Name:       "GraphQL",
-	Extensions: []string{".graphql", ".gql"},
+	Name:        "GraphQL",
+	Extensions:  []string{".graphql", ".gql"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile(`"""[\s\S]*?"""`)},
@@ -13,8 +13,9 @@ func init() {
 
 // RubyLang defines syntax highlighting rules for Ruby.
 var RubyLang = &syntax.Language{
-	Name:       "Ruby",
-	Extensions: []string{".rb", ".rake", ".gemspec", ".ru", ".erb"},
+	Name:        "Ruby",
+	Extensions:  []string{".rb", ".rake", ".gemspec", ".ru", ".erb"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`=begin[\s\S]*?=end`)},
@@ -37,8 +38,9 @@ var RubyLang = &syntax.Language{
 
 // PerlLang defines syntax highlighting rules for Perl.
 var PerlLang = &syntax.Language{
-	Name:       "Perl",
-	Extensions: []string{".pl", ".pm", ".pod", ".t", ".psgi"},
+	Name:        "Perl",
+	Extensions:  []string{".pl", ".pm", ".pod", ".t", ".psgi"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`=\w+[\s\S]*?=cut`)},
@@ -12,8 +12,16 @@ func init() {
 
 // RustLang defines syntax highlighting rules for Rust.
 var RustLang = &syntax.Language{
-	Name:       "Rust",
-	Extensions: []string{".rs"},
+	Name:         "Rust",
+	Extensions:   []string{".rs"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
+	AutoPairs: []syntax.BracketPair{
+		{Open: "(", Close: ")"},
+		{Open: "[", Close: "]"},
+		{Open: "{", Close: "}"},
+		{Open: "\"", Close: "\""},
+	},
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
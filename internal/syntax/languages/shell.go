@@ -12,8 +12,14 @@ func init() {
 
 // ShellLang defines syntax highlighting rules for Shell/Bash.
 var ShellLang = &syntax.Language{
-	Name:       "Shell",
-	Extensions: []string{".sh", ".bash", ".zsh", ".fish"},
+	Name:        "Shell",
+	Extensions:  []string{".sh", ".bash", ".zsh", ".fish"},
+	LineComment: "#",
+	WordPairs: []syntax.BracketPair{
+		{Open: "if", Close: "fi"},
+		{Open: "case", Close: "esac"},
+		{Open: "do", Close: "done"},
+	},
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
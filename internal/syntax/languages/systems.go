@@ -16,8 +16,9 @@ func init() {
 
 // ZigLang defines syntax highlighting rules for Zig.
 var ZigLang = &syntax.Language{
-	Name:       "Zig",
-	Extensions: []string{".zig"},
+	Name:        "Zig",
+	Extensions:  []string{".zig"},
+	LineComment: "//",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)},
@@ -36,8 +37,10 @@ var ZigLang = &syntax.Language{
 
 // NimLang defines syntax highlighting rules for Nim.
 var NimLang = &syntax.Language{
-	Name:       "Nim",
-	Extensions: []string{".nim", ".nims", ".nimble"},
+	Name:         "Nim",
+	Extensions:   []string{".nim", ".nims", ".nimble"},
+	LineComment:  "#",
+	BlockComment: syntax.BracketPair{Open: "#[", Close: "]#"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#\[[\s\S]*?\]#`)},
@@ -56,8 +59,10 @@ var NimLang = &syntax.Language{
 
 // DLang defines syntax highlighting rules for D.
 var DLang = &syntax.Language{
-	Name:       "D",
-	Extensions: []string{".d", ".di"},
+	Name:         "D",
+	Extensions:   []string{".d", ".di"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -77,8 +82,9 @@ var DLang = &syntax.Language{
 
 // AdaLang defines syntax highlighting rules for Ada.
 var AdaLang = &syntax.Language{
-	Name:       "Ada",
-	Extensions: []string{".adb", ".ads", ".ada"},
+	Name:        "Ada",
+	Extensions:  []string{".adb", ".ads", ".ada"},
+	LineComment: "--",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`--.*$`)},
 		{Type: syntax.TokenString, Pattern: regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)},
@@ -94,8 +100,9 @@ var AdaLang = &syntax.Language{
 
 // FortranLang defines syntax highlighting rules for Fortran.
 var FortranLang = &syntax.Language{
-	Name:       "Fortran",
-	Extensions: []string{".f", ".for", ".f90", ".f95", ".f03", ".f08"},
+	Name:        "Fortran",
+	Extensions:  []string{".f", ".for", ".f90", ".f95", ".f03", ".f08"},
+	LineComment: "!",
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`!.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`(?i)^[c*].*$`)},
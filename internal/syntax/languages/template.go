@@ -14,8 +14,9 @@ func init() {
 
 // JinjaLang defines syntax highlighting rules for Jinja2/Django templates.
 var JinjaLang = &syntax.Language{
-	Name:       "Jinja",
-	Extensions: []string{".jinja", ".jinja2", ".j2", ".html.j2", ".django"},
+	Name:         "Jinja",
+	Extensions:   []string{".jinja", ".jinja2", ".j2", ".html.j2", ".django"},
+	BlockComment: syntax.BracketPair{Open: "{#", Close: "#}"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`\{#[\s\S]*?#\}`)},
 		{Type: syntax.TokenBuiltin, Pattern: regexp.MustCompile(`\{%-?\s*(if|elif|else|endif|for|endfor|block|endblock|extends|include|import|from|macro|endmacro|call|endcall|filter|endfilter|set|endset|raw|endraw|autoescape|endautoescape|with|endwith|trans|endtrans|pluralize)\b[^%]*-?%\}`)},
@@ -31,8 +32,9 @@ var JinjaLang = &syntax.Language{
 
 // HandleBarsLang defines syntax highlighting rules for Handlebars/Mustache templates.
 var HandleBarsLang = &syntax.Language{
-	Name:       "Handlebars",
-	Extensions: []string{".hbs", ".handlebars", ".mustache"},
+	Name:         "Handlebars",
+	Extensions:   []string{".hbs", ".handlebars", ".mustache"},
+	BlockComment: syntax.BracketPair{Open: "{{!--", Close: "--}}"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`\{\{!--[\s\S]*?--\}\}`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`\{\{![^}]*\}\}`)},
@@ -50,8 +52,9 @@ var HandleBarsLang = &syntax.Language{
 
 // EJSLang defines syntax highlighting rules for EJS templates.
 var EJSLang = &syntax.Language{
-	Name:       "EJS",
-	Extensions: []string{".ejs"},
+	Name:         "EJS",
+	Extensions:   []string{".ejs"},
+	BlockComment: syntax.BracketPair{Open: "<%#", Close: "%>"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`<%#[\s\S]*?%>`)},
 		{Type: syntax.TokenBuiltin, Pattern: regexp.MustCompile(`<%-[\s\S]*?%>`)},
@@ -16,8 +16,10 @@ func init() {
 
 // CoffeeScriptLang defines syntax highlighting rules for CoffeeScript.
 var CoffeeScriptLang = &syntax.Language{
-	Name:       "CoffeeScript",
-	Extensions: []string{".coffee", ".cson", ".litcoffee"},
+	Name:         "CoffeeScript",
+	Extensions:   []string{".coffee", ".cson", ".litcoffee"},
+	LineComment:  "#",
+	BlockComment: syntax.BracketPair{Open: "###", Close: "###"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`###[\s\S]*?###`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
@@ -37,8 +39,10 @@ var CoffeeScriptLang = &syntax.Language{
 
 // VueLang defines syntax highlighting rules for Vue SFC.
 var VueLang = &syntax.Language{
-	Name:       "Vue",
-	Extensions: []string{".vue"},
+	Name:         "Vue",
+	Extensions:   []string{".vue"},
+	BlockComment: syntax.BracketPair{Open: "<!--", Close: "-->"},
+	MatchTags:    true,
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`<!--[\s\S]*?-->`)},
 		{Type: syntax.TokenKeyword, Pattern: regexp.MustCompile(`</?(template|script|style)\b[^>]*>`)},
@@ -56,8 +60,10 @@ var VueLang = &syntax.Language{
 
 // SvelteLang defines syntax highlighting rules for Svelte.
 var SvelteLang = &syntax.Language{
-	Name:       "Svelte",
-	Extensions: []string{".svelte"},
+	Name:         "Svelte",
+	Extensions:   []string{".svelte"},
+	BlockComment: syntax.BracketPair{Open: "<!--", Close: "-->"},
+	MatchTags:    true,
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`<!--[\s\S]*?-->`)},
 		{Type: syntax.TokenKeyword, Pattern: regexp.MustCompile(`</?(script|style)\b[^>]*>`)},
@@ -78,8 +84,10 @@ var SvelteLang = &syntax.Language{
 
 // LessLang defines syntax highlighting rules for Less CSS.
 var LessLang = &syntax.Language{
-	Name:       "Less",
-	Extensions: []string{".less"},
+	Name:         "Less",
+	Extensions:   []string{".less"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
@@ -98,8 +106,10 @@ var LessLang = &syntax.Language{
 
 // StylusLang defines syntax highlighting rules for Stylus CSS.
 var StylusLang = &syntax.Language{
-	Name:       "Stylus",
-	Extensions: []string{".styl", ".stylus"},
+	Name:         "Stylus",
+	Extensions:   []string{".styl", ".stylus"},
+	LineComment:  "//",
+	BlockComment: syntax.BracketPair{Open: "/*", Close: "*/"},
 	Rules: []syntax.Rule{
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`//.*$`)},
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`/\*[\s\S]*?\*/`)},
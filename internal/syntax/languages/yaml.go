@@ -12,8 +12,9 @@ func init() {
 
 // YAMLLang defines syntax highlighting rules for YAML.
 var YAMLLang = &syntax.Language{
-	Name:       "YAML",
-	Extensions: []string{".yaml", ".yml"},
+	Name:        "YAML",
+	Extensions:  []string{".yaml", ".yml"},
+	LineComment: "#",
 	Rules: []syntax.Rule{
 		// Comments
 		{Type: syntax.TokenComment, Pattern: regexp.MustCompile(`#.*$`)},
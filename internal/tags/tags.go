@@ -0,0 +1,200 @@
+// Package tags reads symbol definitions from tags files written by
+// Universal Ctags (or Exuberant Ctags).
+//
+// Each line of a tags file names a symbol, the file defining it and an ex
+// address locating the definition, optionally followed by extension fields:
+//
+//	main	cmd/main.go	/^func main() {$/;"	f	line:12
+//	VERSION	version.go	8;"	c
+//
+// Lines starting with "!_TAG_" carry metadata and are skipped.
+package tags
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileName is the name of the tags file looked up by Find.
+const FileName = "tags"
+
+// Tag is one definition of a symbol.
+type Tag struct {
+	Name    string
+	File    string // path of the defining file, resolved against the tags file
+	Line    int    // 1-based line from a line address or field, 0 if unknown
+	Pattern string // search pattern text without delimiters and anchors
+	Kind    string // kind letter or name ("f", "function"), may be empty
+}
+
+// Find returns the path of the nearest tags file in dir or one of its
+// parent directories.
+func Find(dir string) (string, bool) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Lookup returns the definitions of name in the tags file at path, in file
+// order. The file is read on every call, so it may be regenerated while
+// the editor runs.
+func Lookup(path, name string) ([]Tag, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(path)
+	prefix := name + "\t"
+	var found []Tag
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		if tag, ok := Parse(line); ok {
+			if !filepath.IsAbs(tag.File) {
+				tag.File = filepath.Join(dir, filepath.FromSlash(tag.File))
+			}
+			found = append(found, tag)
+		}
+	}
+	return found, scanner.Err()
+}
+
+// Parse parses one line of a tags file. File paths are returned as written.
+func Parse(line string) (Tag, bool) {
+	if strings.HasPrefix(line, "!_TAG_") {
+		return Tag{}, false
+	}
+	name, rest, ok := strings.Cut(line, "\t")
+	if !ok {
+		return Tag{}, false
+	}
+	file, address, ok := strings.Cut(rest, "\t")
+	if !ok || name == "" || file == "" {
+		return Tag{}, false
+	}
+	tag := Tag{Name: name, File: file}
+
+	// The address ends at ;" when extension fields follow
+	var fields string
+	if i := addressEnd(address); i >= 0 {
+		address, fields = address[:i], address[i+2:]
+	}
+	if n, err := strconv.Atoi(address); err == nil {
+		tag.Line = n
+	} else {
+		tag.Pattern = unescapePattern(address)
+	}
+
+	for _, field := range strings.Split(fields, "\t") {
+		key, value, hasValue := strings.Cut(field, ":")
+		switch {
+		case field == "":
+		case !hasValue && tag.Kind == "":
+			tag.Kind = field
+		case key == "kind":
+			tag.Kind = value
+		case key == "line":
+			if n, err := strconv.Atoi(value); err == nil {
+				tag.Line = n
+			}
+		}
+	}
+	return tag, true
+}
+
+// addressEnd returns the index of the ;" ending an address, skipping over
+// a search pattern that may itself contain those characters.
+func addressEnd(address string) int {
+	start := 0
+	if address != "" && (address[0] == '/' || address[0] == '?') {
+		delim := address[0]
+		start = len(address)
+		for i := 1; i < len(address); i++ {
+			if address[i] == '\\' {
+				i++
+				continue
+			}
+			if address[i] == delim {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if i := strings.Index(address[start:], `;"`); i >= 0 {
+		return start + i
+	}
+	return -1
+}
+
+// unescapePattern strips the delimiters, anchors and escapes of a search
+// pattern address such as /^func main() {$/.
+func unescapePattern(address string) string {
+	if len(address) < 2 || (address[0] != '/' && address[0] != '?') {
+		return address
+	}
+	delim := address[0]
+	body := strings.TrimSuffix(address[1:], string(delim))
+	body = strings.TrimPrefix(body, "^")
+	if strings.HasSuffix(body, "$") && !strings.HasSuffix(body, `\$`) {
+		body = strings.TrimSuffix(body, "$")
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] == '\\' && i+1 < len(body) {
+			i++
+		}
+		b.WriteByte(body[i])
+	}
+	return b.String()
+}
+
+// Locate returns the 1-based line of a tag in the content of its file. The
+// pattern is preferred, as line numbers go stale when the file is edited;
+// of several matching lines the one nearest the recorded line wins.
+func (t Tag) Locate(content string) int {
+	if t.Pattern == "" {
+		return max(t.Line, 1)
+	}
+	best := 0
+	for i, line := range strings.Split(content, "\n") {
+		if line != t.Pattern && !strings.HasPrefix(line, t.Pattern) {
+			continue
+		}
+		n := i + 1
+		if best == 0 || abs(n-t.Line) < abs(best-t.Line) {
+			best = n
+		}
+	}
+	if best == 0 {
+		return max(t.Line, 1)
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,121 @@
+package tags
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Tag
+		ok   bool
+	}{
+		{
+			"pattern with fields",
+			"main\tcmd/main.go\t/^func main() {$/;\"\tf\tline:12",
+			Tag{Name: "main", File: "cmd/main.go", Line: 12, Pattern: "func main() {", Kind: "f"},
+			true,
+		},
+		{
+			"line number",
+			"VERSION\tversion.go\t8;\"\tkind:constant",
+			Tag{Name: "VERSION", File: "version.go", Line: 8, Kind: "constant"},
+			true,
+		},
+		{
+			"no fields",
+			"helper\tutil.go\t/^func helper() {}$/",
+			Tag{Name: "helper", File: "util.go", Pattern: "func helper() {}"},
+			true,
+		},
+		{
+			"escaped pattern",
+			"path\tpath.go\t/^var path = \"a\\/b;\\\"\"$/;\"\tv",
+			Tag{Name: "path", File: "path.go", Pattern: "var path = \"a/b;\"\"", Kind: "v"},
+			true,
+		},
+		{
+			"unanchored end",
+			"long\tlong.go\t/^func long(a int,/;\"\tf",
+			Tag{Name: "long", File: "long.go", Pattern: "func long(a int,", Kind: "f"},
+			true,
+		},
+		{"metadata", "!_TAG_FILE_FORMAT\t2\t/extended format/", Tag{}, false},
+		{"malformed", "name only", Tag{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Parse(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Parse() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFindAndLookup(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "pkg", "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted/\n" +
+		"helper\tpkg/a.go\t/^func helper() {$/;\"\tf\n" +
+		"helper\t/abs/b.go\t3;\"\tf\n" +
+		"helperTwo\tpkg/a.go\t9;\"\tf\n"
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, ok := Find(sub)
+	if !ok || path != filepath.Join(root, FileName) {
+		t.Fatalf("Find() = %q, %v", path, ok)
+	}
+	if _, ok := Find(t.TempDir()); ok {
+		t.Error("Find() found a tags file outside the tree")
+	}
+
+	found, err := Lookup(path, "helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Tag{
+		{Name: "helper", File: filepath.Join(root, "pkg", "a.go"), Pattern: "func helper() {", Kind: "f"},
+		{Name: "helper", File: "/abs/b.go", Line: 3, Kind: "f"},
+	}
+	if !filepath.IsAbs("/abs/b.go") {
+		want[1].File = filepath.Join(root, "abs", "b.go")
+	}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("Lookup() = %+v, want %+v", found, want)
+	}
+	if found, _ := Lookup(path, "missing"); len(found) != 0 {
+		t.Errorf("Lookup() of a missing name = %+v", found)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	content := "package main\n\nfunc a() {}\n\nfunc a() {}\n"
+	tests := []struct {
+		name string
+		tag  Tag
+		want int
+	}{
+		{"line only", Tag{Line: 4}, 4},
+		{"pattern", Tag{Pattern: "func a() {}"}, 3},
+		{"nearest match", Tag{Pattern: "func a() {}", Line: 6}, 5},
+		{"pattern moved", Tag{Pattern: "package main", Line: 9}, 1},
+		{"pattern missing", Tag{Pattern: "func b() {}", Line: 2}, 2},
+		{"nothing known", Tag{}, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.tag.Locate(content); got != tt.want {
+			t.Errorf("%s: Locate() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -24,6 +24,8 @@ func main() {
 	var noConfig bool
 	var noLineNumbers bool
 	var noSyntax bool
+	var listKeys bool
+	var viKeys bool
 
 	// Parse arguments
 	args := os.Args[1:]
@@ -65,6 +67,12 @@ func main() {
 		case arg == "--no-syntax":
 			noSyntax = true
 
+		case arg == "--list-keys":
+			listKeys = true
+
+		case arg == "--vi":
+			viKeys = true
+
 		case strings.HasPrefix(arg, "+"):
 			// Parse +N or +N:M
 			pos := arg[1:]
@@ -105,6 +113,26 @@ func main() {
 		cfg = config.DefaultConfig()
 	}
 
+	// Load key bindings unless --norc
+	keys := app.DefaultKeymap()
+	var keyWarnings []string
+	if !noConfig {
+		var err error
+		keyWarnings, err = keys.Load(config.GetKeybindingsPath())
+		if err != nil {
+			keyWarnings = append(keyWarnings, "failed to load key bindings: "+err.Error())
+		}
+		for _, warning := range keyWarnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+	if listKeys {
+		if err := app.WriteKeymap(os.Stdout, keys); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Apply theme: CLI flag takes precedence over config
 	if themeName != "" {
 		app.SetTheme(themeName)
@@ -115,7 +143,11 @@ func main() {
 	// Create the model
 	var model *app.Model
 
-	if filepath != "" {
+	if info, err := os.Stat(filepath); err == nil && info.IsDir() {
+		// Directory: start with an empty buffer and the file tree
+		model = app.New()
+		model.OpenDirectory(filepath)
+	} else if filepath != "" {
 		// Check if file is large
 		isLarge, fileSize, sizeErr := file.IsLargeFile(filepath)
 		if sizeErr == nil && isLarge {
@@ -175,6 +207,25 @@ func main() {
 	model.SetFinalNewline(cfg.Editor.FinalNewline)
 	model.SetCreateBackup(cfg.Editor.CreateBackup)
 	model.SetAutoSaveInterval(cfg.Editor.AutoSaveInterval)
+	model.SetAutoPairs(cfg.Editor.AutoPairs)
+	model.SetTabSize(cfg.Editor.TabSize)
+	model.SetInsertSpaces(cfg.Editor.InsertSpaces)
+	model.SetSpellCheck(cfg.Spell.Enabled, cfg.Spell.Dictionary)
+	model.SetLint(cfg.Lint.Commands, cfg.Lint.OnSave)
+	model.SetFormatters(cfg.Format.Commands)
+	model.SetLanguageServers(cfg.LSP.Servers)
+	model.SetKeymap(keys)
+	switch cfg.Editor.Keymap {
+	case "vi":
+		viKeys = true
+	case "", "nano":
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: unknown keymap %q, using nano\n", cfg.Editor.Keymap)
+	}
+	model.SetViMode(viKeys)
+	if len(keyWarnings) > 0 {
+		model.SetStatusMessage(fmt.Sprintf("%d key binding warning(s); see gesh --list-keys", len(keyWarnings)))
+	}
 
 	// Go to specific line/column if specified
 	if startLine > 0 {
@@ -184,7 +235,12 @@ func main() {
 	// Create and run the program with mouse support
 	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+	model.CloseLanguageServers()
+	if saveErr := model.SaveBookmarks(); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save bookmarks: %v\n", saveErr)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
@@ -193,7 +249,7 @@ func main() {
 func printHelp() {
 	fmt.Println("Gesh (𒄑) - A minimal TUI text editor")
 	fmt.Println()
-	fmt.Println("Usage: gesh [options] [+line[:col]] [file]")
+	fmt.Println("Usage: gesh [options] [+line[:col]] [file | directory]")
 	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  -h, --help         Show this help message")
@@ -203,6 +259,8 @@ func printHelp() {
 	fmt.Println("  -n, --norc         Do not load config file")
 	fmt.Println("  --no-line-numbers  Hide line numbers")
 	fmt.Println("  --no-syntax        Disable syntax highlighting")
+	fmt.Println("  --list-keys        Print the effective key bindings")
+	fmt.Println("  --vi               Use the modal vi keymap")
 	fmt.Println("  +N                 Open at line N")
 	fmt.Println("  +N:M               Open at line N, column M")
 	fmt.Println()